@@ -0,0 +1,15 @@
+package embeddedpostgres
+
+const (
+	applicationNameConfSnippet = "00-application-name"
+	// internalApplicationName names the connections made by this library.
+	internalApplicationName = "embedded-postgres"
+)
+
+func applicationNameSettings(config Config) map[string]string {
+	if config.applicationName == "" {
+		return nil
+	}
+
+	return map[string]string{"application_name": config.applicationName}
+}
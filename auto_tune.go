@@ -0,0 +1,101 @@
+package embeddedpostgres
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// autoTuneConfSnippet sorts after the preset so that tuned settings take precedence over it.
+const autoTuneConfSnippet = "00-tune"
+
+const (
+	mebibyte = 1024 * 1024
+	gibibyte = 1024 * mebibyte
+)
+
+// hostResources holds the memory in bytes and the CPUs available to the server.
+type hostResources struct {
+	memory uint64
+	cpus   int
+}
+
+// detectHostResources returns the memory and CPUs of the host, reduced to the limits of the cgroup of the current
+// process and to the memory limit of the server.
+func detectHostResources(config Config) (hostResources, error) {
+	memory, err := totalMemory()
+	if err != nil {
+		return hostResources{}, err
+	}
+
+	resources := hostResources{memory: memory, cpus: runtime.NumCPU()}
+
+	limits := cgroupLimits()
+	if limits.memory > 0 && limits.memory < resources.memory {
+		resources.memory = limits.memory
+	}
+
+	if limits.cpus > 0 && limits.cpus < resources.cpus {
+		resources.cpus = limits.cpus
+	}
+
+	if config.memoryLimit > 0 && config.memoryLimit < resources.memory {
+		resources.memory = config.memoryLimit
+	}
+
+	return resources, nil
+}
+
+// autoTuneSettings returns the settings sized to the resources, following the usual rules of thumb: a quarter of the
+// memory for shared_buffers up to 8GB, the rest shared out as work_mem between three operations on each of the default
+// 100 connections, and a parallel worker per CPU. Settings are left at their defaults where they would be lowered.
+func autoTuneSettings(version PostgresVersion, resources hostResources) map[string]string {
+	settings := map[string]string{}
+
+	sharedBuffers := resources.memory / 4
+	if sharedBuffers > 8*gibibyte {
+		sharedBuffers = 8 * gibibyte
+	}
+
+	if sharedBuffers > 128*mebibyte {
+		settings["shared_buffers"] = fmt.Sprintf("%dMB", sharedBuffers/mebibyte)
+	}
+
+	if workMem := (resources.memory - sharedBuffers) / 300; workMem > 4*mebibyte {
+		settings["work_mem"] = fmt.Sprintf("%dMB", workMem/mebibyte)
+	}
+
+	if resources.cpus > 8 {
+		settings["max_worker_processes"] = fmt.Sprintf("%d", resources.cpus)
+	}
+
+	// max_parallel_workers was introduced in Postgres 10, parallel query itself in 9.6
+	if majorVersion(version) >= 10 && resources.cpus > 8 {
+		settings["max_parallel_workers"] = fmt.Sprintf("%d", resources.cpus)
+	}
+
+	perGather := resources.cpus / 2
+	if perGather > 4 {
+		perGather = 4
+	}
+
+	if perGather > 2 {
+		settings["max_parallel_workers_per_gather"] = fmt.Sprintf("%d", perGather)
+	}
+
+	return settings
+}
+
+// writeAutoTuneConf writes the settings sized by Config.AutoTune into the drop-in configuration directory.
+func (ep *EmbeddedPostgres) writeAutoTuneConf() error {
+	if !ep.config.autoTune {
+		return ep.writeManagedConfSnippet(autoTuneConfSnippet, nil)
+	}
+
+	resources, err := detectHostResources(ep.config)
+	if err != nil {
+		ep.reportDiagnostic(fmt.Sprintf("unable to determine the resources available for auto-tuning, keeping the defaults: %s", err))
+		return ep.writeManagedConfSnippet(autoTuneConfSnippet, nil)
+	}
+
+	return ep.writeManagedConfSnippet(autoTuneConfSnippet, autoTuneSettings(ep.config.version, resources))
+}
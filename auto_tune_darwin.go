@@ -0,0 +1,22 @@
+package embeddedpostgres
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+func totalMemory() (uint64, error) {
+	output, err := exec.Command("sysctl", "-n", "hw.memsize").Output()
+	if err != nil {
+		return 0, fmt.Errorf("unable to read hw.memsize: %w", err)
+	}
+
+	return strconv.ParseUint(strings.TrimSpace(string(output)), 10, 64)
+}
+
+// cgroupLimits returns no limits as there are no cgroups on macOS.
+func cgroupLimits() hostResources {
+	return hostResources{}
+}
@@ -0,0 +1,84 @@
+package embeddedpostgres
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+const cgroupRoot = "/sys/fs/cgroup"
+
+func totalMemory() (uint64, error) {
+	var info syscall.Sysinfo_t
+	if err := syscall.Sysinfo(&info); err != nil {
+		return 0, err
+	}
+
+	return uint64(info.Totalram) * uint64(info.Unit), nil
+}
+
+// cgroupLimits returns the limits of the cgroup of the current process, which is the root within a container.
+func cgroupLimits() hostResources {
+	return readCgroupLimits(cgroupRoot)
+}
+
+// readCgroupLimits reads the memory and CPU limits of cgroup v2 or, failing that, v1 from the cgroup filesystem at
+// root, leaving those that are not limited as 0.
+func readCgroupLimits(root string) hostResources {
+	var limits hostResources
+
+	if memory, ok := readCgroupValue(filepath.Join(root, "memory.max")); ok {
+		limits.memory = uint64(memory)
+	} else if memory, ok := readCgroupValue(filepath.Join(root, "memory", "memory.limit_in_bytes")); ok {
+		limits.memory = uint64(memory)
+	}
+
+	if cpuMax, err := os.ReadFile(filepath.Join(root, "cpu.max")); err == nil {
+		fields := strings.Fields(string(cpuMax))
+		if len(fields) == 2 {
+			limits.cpus = cpusFromQuota(fields[0], fields[1])
+		}
+	} else {
+		quota, quotaErr := os.ReadFile(filepath.Join(root, "cpu", "cpu.cfs_quota_us"))
+		period, periodErr := os.ReadFile(filepath.Join(root, "cpu", "cpu.cfs_period_us"))
+		if quotaErr == nil && periodErr == nil {
+			limits.cpus = cpusFromQuota(strings.TrimSpace(string(quota)), strings.TrimSpace(string(period)))
+		}
+	}
+
+	return limits
+}
+
+// readCgroupValue reads a limit in bytes, reporting false when there is none. cgroup v1 reports no limit as a value
+// close to the maximum of int64 rather than "max".
+func readCgroupValue(path string) (int64, bool) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return 0, false
+	}
+
+	value, err := strconv.ParseInt(strings.TrimSpace(string(content)), 10, 64)
+	if err != nil || value <= 0 || value > math.MaxInt64/2 {
+		return 0, false
+	}
+
+	return value, true
+}
+
+// cpusFromQuota returns the CPUs allowed by a CFS quota and period, rounded up, or 0 when unlimited.
+func cpusFromQuota(quota, period string) int {
+	q, err := strconv.ParseFloat(quota, 64)
+	if err != nil || q <= 0 {
+		return 0
+	}
+
+	p, err := strconv.ParseFloat(period, 64)
+	if err != nil || p <= 0 {
+		return 0
+	}
+
+	return int(math.Ceil(q / p))
+}
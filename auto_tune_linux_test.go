@@ -0,0 +1,58 @@
+package embeddedpostgres
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeCgroupFile(t *testing.T, root, name, content string) {
+	t.Helper()
+
+	path := filepath.Join(root, name)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0700))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+}
+
+func Test_readCgroupLimits_V2(t *testing.T) {
+	root := t.TempDir()
+	writeCgroupFile(t, root, "memory.max", "2147483648\n")
+	writeCgroupFile(t, root, "cpu.max", "150000 100000\n")
+
+	assert.Equal(t, hostResources{memory: 2 * gibibyte, cpus: 2}, readCgroupLimits(root))
+}
+
+func Test_readCgroupLimits_V2Unlimited(t *testing.T) {
+	root := t.TempDir()
+	writeCgroupFile(t, root, "memory.max", "max\n")
+	writeCgroupFile(t, root, "cpu.max", "max 100000\n")
+
+	assert.Equal(t, hostResources{}, readCgroupLimits(root))
+}
+
+func Test_readCgroupLimits_V1(t *testing.T) {
+	root := t.TempDir()
+	writeCgroupFile(t, root, "memory/memory.limit_in_bytes", "1073741824\n")
+	writeCgroupFile(t, root, "cpu/cpu.cfs_quota_us", "400000\n")
+	writeCgroupFile(t, root, "cpu/cpu.cfs_period_us", "100000\n")
+
+	assert.Equal(t, hostResources{memory: gibibyte, cpus: 4}, readCgroupLimits(root))
+}
+
+func Test_readCgroupLimits_V1Unlimited(t *testing.T) {
+	root := t.TempDir()
+	writeCgroupFile(t, root, "memory/memory.limit_in_bytes", "9223372036854771712\n")
+	writeCgroupFile(t, root, "cpu/cpu.cfs_quota_us", "-1\n")
+	writeCgroupFile(t, root, "cpu/cpu.cfs_period_us", "100000\n")
+
+	assert.Equal(t, hostResources{}, readCgroupLimits(root))
+}
+
+func Test_totalMemory(t *testing.T) {
+	memory, err := totalMemory()
+	require.NoError(t, err)
+	assert.Greater(t, memory, uint64(0))
+}
@@ -0,0 +1,15 @@
+//go:build !linux && !darwin
+// +build !linux,!darwin
+
+package embeddedpostgres
+
+import "errors"
+
+// totalMemory is unsupported on this platform.
+func totalMemory() (uint64, error) {
+	return 0, errors.New("determining the memory of the host is not supported on this platform")
+}
+
+func cgroupLimits() hostResources {
+	return hostResources{}
+}
@@ -0,0 +1,65 @@
+package embeddedpostgres
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_autoTuneSettings(t *testing.T) {
+	settings := autoTuneSettings(V15, hostResources{memory: 16 * gibibyte, cpus: 16})
+
+	assert.Equal(t, map[string]string{
+		"shared_buffers":                  "4096MB",
+		"work_mem":                        "40MB",
+		"max_worker_processes":            "16",
+		"max_parallel_workers":            "16",
+		"max_parallel_workers_per_gather": "4",
+	}, settings)
+}
+
+func Test_autoTuneSettings_CapsSharedBuffers(t *testing.T) {
+	settings := autoTuneSettings(V15, hostResources{memory: 64 * gibibyte, cpus: 4})
+
+	assert.Equal(t, "8192MB", settings["shared_buffers"])
+	assert.NotContains(t, settings, "max_parallel_workers")
+	assert.NotContains(t, settings, "max_parallel_workers_per_gather")
+}
+
+func Test_autoTuneSettings_KeepsDefaultsOnSmallHosts(t *testing.T) {
+	assert.Empty(t, autoTuneSettings(V15, hostResources{memory: 512 * mebibyte, cpus: 1}))
+}
+
+func Test_autoTuneSettings_V9(t *testing.T) {
+	settings := autoTuneSettings(V9, hostResources{memory: 16 * gibibyte, cpus: 16})
+
+	assert.Equal(t, "16", settings["max_worker_processes"])
+	assert.NotContains(t, settings, "max_parallel_workers")
+}
+
+func Test_detectHostResources_MemoryLimit(t *testing.T) {
+	resources, err := detectHostResources(DefaultConfig().MemoryLimit(256*mebibyte, ""))
+	if err != nil {
+		t.Skipf("unable to determine host resources: %s", err)
+	}
+
+	assert.Equal(t, uint64(256*mebibyte), resources.memory)
+	assert.Greater(t, resources.cpus, 0)
+}
+
+func Test_writeAutoTuneConf(t *testing.T) {
+	tempDir := t.TempDir()
+	tunePath := filepath.Join(tempDir, confDirName, autoTuneConfSnippet+".conf")
+
+	require.NoError(t, os.MkdirAll(filepath.Dir(tunePath), 0700))
+	require.NoError(t, os.WriteFile(tunePath, []byte("shared_buffers = '1GB'\n"), 0600))
+
+	database := NewDatabase(DefaultConfig().DataPath(tempDir))
+	require.NoError(t, database.writeAutoTuneConf())
+
+	_, err := os.Stat(tunePath)
+	assert.True(t, os.IsNotExist(err))
+}
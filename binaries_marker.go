@@ -0,0 +1,132 @@
+package embeddedpostgres
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+)
+
+// binariesMarkerFile records what was extracted into the binaries directory, so that binaries extracted into the
+// runtime directory survive it being cleaned on start and are only extracted again once they change.
+const binariesMarkerFile = ".embedded-postgres-binaries"
+
+type binariesMarker struct {
+	Archive    string   `json:"archive"`
+	Extensions []string `json:"extensions,omitempty"`
+	Entries    []string `json:"entries"`
+}
+
+func readBinariesMarker(binariesPath string) (binariesMarker, bool) {
+	var marker binariesMarker
+
+	content, err := os.ReadFile(filepath.Join(binariesPath, binariesMarkerFile))
+	if err != nil {
+		return marker, false
+	}
+
+	if err := json.Unmarshal(content, &marker); err != nil {
+		return marker, false
+	}
+
+	return marker, true
+}
+
+// writeBinariesMarker records that archive and the extension archives were extracted into binariesPath, along with
+// the entries of the directory they were extracted into.
+func writeBinariesMarker(binariesPath, archive string, extensions []string) error {
+	dirEntries, err := os.ReadDir(binariesPath)
+	if err != nil {
+		return fmt.Errorf("unable to record extracted binaries in %s: %w", binariesPath, err)
+	}
+
+	marker := binariesMarker{
+		Archive:    archive,
+		Extensions: extensions,
+	}
+
+	for _, entry := range dirEntries {
+		if entry.Name() != binariesMarkerFile {
+			marker.Entries = append(marker.Entries, entry.Name())
+		}
+	}
+
+	content, err := json.Marshal(marker)
+	if err != nil {
+		return fmt.Errorf("unable to record extracted binaries in %s: %w", binariesPath, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(binariesPath, binariesMarkerFile), content, 0600); err != nil {
+		return fmt.Errorf("unable to record extracted binaries in %s: %w", binariesPath, err)
+	}
+
+	return nil
+}
+
+// binariesExtracted reports whether archive and the extension archives have already been extracted into
+// binariesPath and are still in place.
+func binariesExtracted(binariesPath, archive string, extensions []string) (binariesMarker, bool) {
+	marker, ok := readBinariesMarker(binariesPath)
+	if !ok || marker.Archive != archive || !reflect.DeepEqual(marker.Extensions, nonEmpty(extensions)) {
+		return marker, false
+	}
+
+	if _, err := os.Stat(filepath.Join(binariesPath, "bin")); err != nil {
+		return marker, false
+	}
+
+	for _, entry := range marker.Entries {
+		if _, err := os.Stat(filepath.Join(binariesPath, entry)); err != nil {
+			return marker, false
+		}
+	}
+
+	return marker, true
+}
+
+// nonEmpty returns nil for an empty slice, as it is decoded from the marker.
+func nonEmpty(values []string) []string {
+	if len(values) == 0 {
+		return nil
+	}
+
+	return values
+}
+
+// cleanRuntimeDirectory removes the runtime directory, other than binaries already extracted into it from the archive
+// at cacheLocation.
+func (ep *EmbeddedPostgres) cleanRuntimeDirectory(cacheLocation string) error {
+	runtimePath := ep.config.runtimePath
+
+	marker, extracted := binariesExtracted(ep.config.binariesPath, cacheLocation, ep.config.extensionArchives)
+	if filepath.Clean(ep.config.binariesPath) != filepath.Clean(runtimePath) || !extracted {
+		if err := os.RemoveAll(runtimePath); err != nil {
+			return fmt.Errorf("unable to clean up runtime directory %s with error: %s", runtimePath, err)
+		}
+
+		return nil
+	}
+
+	keep := map[string]bool{binariesMarkerFile: true}
+	for _, entry := range marker.Entries {
+		keep[entry] = true
+	}
+
+	dirEntries, err := os.ReadDir(runtimePath)
+	if err != nil {
+		return fmt.Errorf("unable to clean up runtime directory %s with error: %s", runtimePath, err)
+	}
+
+	for _, entry := range dirEntries {
+		if keep[entry.Name()] {
+			continue
+		}
+
+		if err := os.RemoveAll(filepath.Join(runtimePath, entry.Name())); err != nil {
+			return fmt.Errorf("unable to clean up runtime directory %s with error: %s", runtimePath, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,116 @@
+package embeddedpostgres
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func extractedBinaries(t *testing.T, archive string, extensions ...string) string {
+	dir := t.TempDir()
+
+	for _, entry := range []string{"bin", "lib", "share"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, entry), 0755))
+	}
+
+	require.NoError(t, writeBinariesMarker(dir, archive, extensions))
+
+	return dir
+}
+
+func Test_binariesExtracted(t *testing.T) {
+	dir := extractedBinaries(t, "cache.txz", "timescaledb.txz")
+
+	marker, ok := binariesExtracted(dir, "cache.txz", []string{"timescaledb.txz"})
+	assert.True(t, ok)
+	assert.Equal(t, []string{"bin", "lib", "share"}, marker.Entries)
+
+	_, ok = binariesExtracted(dir, "other.txz", []string{"timescaledb.txz"})
+	assert.False(t, ok)
+
+	_, ok = binariesExtracted(dir, "cache.txz", nil)
+	assert.False(t, ok)
+
+	require.NoError(t, os.RemoveAll(filepath.Join(dir, "share")))
+
+	_, ok = binariesExtracted(dir, "cache.txz", []string{"timescaledb.txz"})
+	assert.False(t, ok)
+}
+
+func Test_binariesExtracted_NoMarker(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "bin"), 0755))
+
+	_, ok := binariesExtracted(dir, "cache.txz", nil)
+	assert.False(t, ok)
+}
+
+func Test_cleanRuntimeDirectory_KeepsExtractedBinaries(t *testing.T) {
+	dir := extractedBinaries(t, "cache.txz")
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "data"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "postgresql.log"), []byte("log"), 0600))
+
+	database := NewDatabase(DefaultConfig().RuntimePath(dir).BinariesPath(dir))
+
+	require.NoError(t, database.cleanRuntimeDirectory("cache.txz"))
+
+	assert.DirExists(t, filepath.Join(dir, "bin"))
+	assert.DirExists(t, filepath.Join(dir, "lib"))
+	assert.DirExists(t, filepath.Join(dir, "share"))
+	assert.FileExists(t, filepath.Join(dir, binariesMarkerFile))
+	assert.NoDirExists(t, filepath.Join(dir, "data"))
+	assert.NoFileExists(t, filepath.Join(dir, "postgresql.log"))
+}
+
+func Test_cleanRuntimeDirectory_RemovesChangedBinaries(t *testing.T) {
+	dir := extractedBinaries(t, "cache.txz")
+
+	database := NewDatabase(DefaultConfig().RuntimePath(dir).BinariesPath(dir))
+
+	require.NoError(t, database.cleanRuntimeDirectory("other.txz"))
+
+	assert.NoDirExists(t, dir)
+}
+
+func Test_cleanRuntimeDirectory_SeparateBinaries(t *testing.T) {
+	binaries := extractedBinaries(t, "cache.txz")
+	runtimePath := t.TempDir()
+
+	database := NewDatabase(DefaultConfig().RuntimePath(runtimePath).BinariesPath(binaries))
+
+	require.NoError(t, database.cleanRuntimeDirectory("cache.txz"))
+
+	assert.NoDirExists(t, runtimePath)
+	assert.DirExists(t, filepath.Join(binaries, "bin"))
+}
+
+func Test_downloadAndExtractBinary_AlreadyExtracted(t *testing.T) {
+	dir := extractedBinaries(t, "cache.txz")
+
+	database := NewDatabase(DefaultConfig().BinariesPath(dir))
+	database.remoteFetchStrategy = func() error {
+		return errors.New("should not be fetched")
+	}
+
+	assert.NoError(t, database.downloadAndExtractBinary(false, "cache.txz"))
+}
+
+func Test_downloadAndExtractBinary_WritesMarker(t *testing.T) {
+	binariesPath := t.TempDir()
+
+	archive, cleanUp := createTempXzArchive()
+	defer cleanUp()
+
+	database := NewDatabase(DefaultConfig().BinariesPath(binariesPath))
+
+	require.NoError(t, database.downloadAndExtractBinary(true, archive))
+
+	marker, ok := readBinariesMarker(binariesPath)
+	require.True(t, ok)
+	assert.Equal(t, archive, marker.Archive)
+	assert.Equal(t, []string{"dir1"}, marker.Entries)
+}
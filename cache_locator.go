@@ -1,9 +1,13 @@
 package embeddedpostgres
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 // CacheLocator retrieves the location of the Postgres binary cache returning it to location.
@@ -35,3 +39,52 @@ func defaultCacheLocator(cacheDirectory string, versionStrategy VersionStrategy)
 		return cacheLocation, !info.IsDir()
 	}
 }
+
+// archiveChecksumPath returns the file recording the checksum of the cached archive at cacheLocation.
+func archiveChecksumPath(cacheLocation string) string {
+	return cacheLocation + ".sha256"
+}
+
+func writeArchiveChecksum(cacheLocation string, archive []byte) error {
+	checksum := sha256.Sum256(archive)
+	return os.WriteFile(archiveChecksumPath(cacheLocation), []byte(hex.EncodeToString(checksum[:])), 0600)
+}
+
+// verifyCachedArchive checks the cached archive at cacheLocation against the checksum recorded when it was downloaded,
+// removing both when they do not match so that the archive is downloaded again. Archives cached without a checksum,
+// such as by earlier versions, are accepted as they are.
+func verifyCachedArchive(cacheLocation string) error {
+	recorded, err := os.ReadFile(archiveChecksumPath(cacheLocation))
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("unable to read checksum of cached archive %s: %w", cacheLocation, err)
+	}
+
+	archive, err := os.Open(cacheLocation)
+	if err != nil {
+		return fmt.Errorf("unable to verify cached archive %s: %w", cacheLocation, err)
+	}
+
+	h := sha256.New()
+	_, err = io.Copy(h, archive)
+	_ = archive.Close()
+
+	if err != nil {
+		return fmt.Errorf("unable to verify cached archive %s: %w", cacheLocation, err)
+	}
+
+	if strings.TrimSpace(string(recorded)) == hex.EncodeToString(h.Sum(nil)) {
+		return nil
+	}
+
+	if err := os.Remove(cacheLocation); err != nil {
+		return fmt.Errorf("unable to remove corrupted cached archive %s: %w", cacheLocation, err)
+	}
+
+	_ = os.Remove(archiveChecksumPath(cacheLocation))
+
+	return fmt.Errorf("cached archive %s does not match its checksum", cacheLocation)
+}
@@ -1,9 +1,13 @@
 package embeddedpostgres
 
 import (
+	"bytes"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func Test_defaultCacheLocator_NotExists(t *testing.T) {
@@ -27,3 +31,46 @@ func Test_defaultCacheLocator_CustomPath(t *testing.T) {
 	assert.Equal(t, cacheLocation, "/custom/path/embedded-postgres-binaries-a-b-1.2.3.txz")
 	assert.False(t, exists)
 }
+
+func Test_verifyCachedArchive(t *testing.T) {
+	cacheLocation := filepath.Join(t.TempDir(), "embedded-postgres-binaries-a-b-1.2.3.txz")
+	require.NoError(t, os.WriteFile(cacheLocation, []byte("binaries"), 0600))
+
+	// archives cached without a checksum are accepted
+	assert.NoError(t, verifyCachedArchive(cacheLocation))
+
+	require.NoError(t, writeArchiveChecksum(cacheLocation, []byte("binaries")))
+	assert.NoError(t, verifyCachedArchive(cacheLocation))
+
+	// a truncated archive is removed along with its checksum
+	require.NoError(t, os.WriteFile(cacheLocation, []byte("bin"), 0600))
+	assert.EqualError(t, verifyCachedArchive(cacheLocation), "cached archive "+cacheLocation+" does not match its checksum")
+	assert.NoFileExists(t, cacheLocation)
+	assert.NoFileExists(t, archiveChecksumPath(cacheLocation))
+}
+
+func Test_downloadAndExtractBinary_RedownloadsCorruptedArchive(t *testing.T) {
+	archive, cleanUp := createTempXzArchive()
+	defer cleanUp()
+
+	content, err := os.ReadFile(archive)
+	require.NoError(t, err)
+
+	cacheLocation := filepath.Join(t.TempDir(), "cache.txz")
+	require.NoError(t, os.WriteFile(cacheLocation, content[:len(content)/2], 0600))
+	require.NoError(t, writeArchiveChecksum(cacheLocation, content))
+
+	var logger bytes.Buffer
+
+	fetched := false
+	database := NewDatabase(DefaultConfig().BinariesPath(t.TempDir()).LibraryLogger(&logger))
+	database.remoteFetchStrategy = func() error {
+		fetched = true
+		return os.WriteFile(cacheLocation, content, 0600)
+	}
+
+	require.NoError(t, database.downloadAndExtractBinary(true, cacheLocation))
+
+	assert.True(t, fetched)
+	assert.Contains(t, logger.String(), "downloading the binaries again: cached archive "+cacheLocation+" does not match its checksum")
+}
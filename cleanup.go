@@ -0,0 +1,205 @@
+package embeddedpostgres
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// resources tracks what the library creates during the lifetime of the process so that it can be removed by CleanupAll.
+var resources = &resourceRegistry{
+	processes: map[*postgresProcess]struct{}{},
+	paths:     map[string]struct{}{},
+	releases:  map[string]func() error{},
+}
+
+type resourceRegistry struct {
+	mu sync.Mutex
+	// processes are tracked rather than instances so that forgotten instances can still be garbage collected and reported.
+	processes map[*postgresProcess]struct{}
+	paths     map[string]struct{}
+	// releases free what cannot simply be removed, such as RAM disks, keyed by what they free.
+	releases map[string]func() error
+}
+
+func (r *resourceRegistry) addProcess(process *postgresProcess) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.processes[process] = struct{}{}
+}
+
+func (r *resourceRegistry) removeProcess(process *postgresProcess) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.processes, process)
+}
+
+func (r *resourceRegistry) addPath(path string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.paths[path] = struct{}{}
+}
+
+func (r *resourceRegistry) addRelease(name string, release func() error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.releases[name] = release
+}
+
+// runtimeOwnerFile marks a default runtime directory as created by the library for the process it is named after.
+const runtimeOwnerFile = ".embedded-postgres-owner"
+
+// runtimeOwner identifies the process pid as seen from this one. The host name and, on Linux, the PID namespace are
+// included so that a directory on a volume shared between containers is only ever reaped from the container whose
+// processes it belongs to, as PIDs mean nothing outside of it.
+func runtimeOwner(pid int) string {
+	hostname, _ := os.Hostname()
+	namespace, _ := os.Readlink("/proc/self/ns/pid")
+
+	return fmt.Sprintf("%s %s %d", hostname, namespace, pid)
+}
+
+// writeRuntimeOwner marks runtimePath as a default runtime directory belonging to this process.
+func writeRuntimeOwner(runtimePath string) error {
+	if err := os.WriteFile(filepath.Join(runtimePath, runtimeOwnerFile), []byte(runtimeOwner(os.Getpid())), 0600); err != nil {
+		return fmt.Errorf("unable to mark runtime directory %s: %w", runtimePath, err)
+	}
+
+	return nil
+}
+
+// reapRuntimeDirectories removes the default runtime directories within dir, named after the PID of the process they
+// belong to, of processes that have exited without calling CleanupAll. Only directories marked by writeRuntimeOwner as
+// belonging to a process of this host and PID namespace are removed, and those holding a data directory whose server
+// is still running, such as one orphaned by a killed process, are left in place.
+func reapRuntimeDirectories(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+
+		return fmt.Errorf("unable to reap runtime directories in %s: %w", dir, err)
+	}
+
+	var failures []string
+
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || !entry.IsDir() || pid == os.Getpid() || processExists(pid) {
+			continue
+		}
+
+		runtimePath := filepath.Join(dir, entry.Name())
+
+		owner, err := os.ReadFile(filepath.Join(runtimePath, runtimeOwnerFile))
+		if err != nil || string(owner) != runtimeOwner(pid) || serverRunning(filepath.Join(runtimePath, "data")) {
+			continue
+		}
+
+		if err := os.RemoveAll(runtimePath); err != nil {
+			failures = append(failures, err.Error())
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("unable to reap runtime directories in %s: %s", dir, strings.Join(failures, ", "))
+	}
+
+	return nil
+}
+
+// serverRunning reports whether the PID on the first line of the postmaster.pid of dataPath is that of a process.
+func serverRunning(dataPath string) bool {
+	content, err := os.ReadFile(filepath.Join(dataPath, "postmaster.pid"))
+	if err != nil {
+		return false
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(strings.SplitN(string(content), "\n", 2)[0]))
+
+	return err == nil && processExists(pid)
+}
+
+// CleanupAll stops every postgres process started by this process that is still running, then removes the runtime
+// directories and log files created along the way, and frees data directories placed in memory with DataInMemory.
+// Directories given with RuntimePath are included as they are cleaned on every start anyway, whereas those given with
+// DataPath, BinariesPath and CachePath are left in place.
+func CleanupAll() error {
+	resources.mu.Lock()
+	defer resources.mu.Unlock()
+
+	var failures []string
+
+	for process := range resources.processes {
+		if err := process.Stop(); err != nil {
+			failures = append(failures, fmt.Sprintf("unable to stop postgres on port %d: %s", process.Config.port, err))
+		}
+
+		delete(resources.processes, process)
+	}
+
+	paths := make([]string, 0, len(resources.paths))
+	for path := range resources.paths {
+		paths = append(paths, path)
+	}
+
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		if err := os.RemoveAll(path); err != nil {
+			failures = append(failures, fmt.Sprintf("unable to remove %s: %s", path, err))
+		}
+
+		delete(resources.paths, path)
+	}
+
+	names := make([]string, 0, len(resources.releases))
+	for name := range resources.releases {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := resources.releases[name](); err != nil {
+			failures = append(failures, fmt.Sprintf("unable to release %s: %s", name, err))
+		}
+
+		delete(resources.releases, name)
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("unable to clean up: %s", strings.Join(failures, ", "))
+	}
+
+	return nil
+}
+
+// RunAndCleanupAll runs the tests and then CleanupAll, reporting any failure to clean up, for use in TestMain:
+//
+//	func TestMain(m *testing.M) {
+//		os.Exit(embeddedpostgres.RunAndCleanupAll(m))
+//	}
+func RunAndCleanupAll(m *testing.M) int {
+	code := m.Run()
+
+	if err := CleanupAll(); err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err)
+
+		if code == 0 {
+			code = 1
+		}
+	}
+
+	return code
+}
@@ -0,0 +1,164 @@
+//go:build !windows
+// +build !windows
+
+package embeddedpostgres
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_CleanupAll(t *testing.T) {
+	runtimePath := filepath.Join(t.TempDir(), "runtime")
+	require.NoError(t, os.MkdirAll(filepath.Join(runtimePath, "data"), 0755))
+
+	resources.addPath(runtimePath)
+
+	assert.NoError(t, CleanupAll())
+	assert.NoDirExists(t, runtimePath)
+	assert.Empty(t, resources.paths)
+}
+
+func Test_CleanupAll_Releases(t *testing.T) {
+	var released []string
+
+	resources.addRelease("b", func() error {
+		released = append(released, "b")
+		return errors.New("busy")
+	})
+	resources.addRelease("a", func() error {
+		released = append(released, "a")
+		return nil
+	})
+
+	assert.EqualError(t, CleanupAll(), "unable to clean up: unable to release b: busy")
+	assert.Equal(t, []string{"a", "b"}, released)
+	assert.Empty(t, resources.releases)
+}
+
+func Test_CleanupAll_RemovesStartResources(t *testing.T) {
+	runtimePath := filepath.Join(t.TempDir(), "runtime")
+	binariesPath := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(binariesPath, "bin"), 0755))
+
+	database := NewDatabase(DefaultConfig().RuntimePath(runtimePath).BinariesPath(binariesPath))
+	database.initDatabase = func(binaryExtractLocation, runtimePath, pgDataDir, username, password, locale string, extraArgs []string, logger *os.File) error {
+		return errors.New("ah it did not work")
+	}
+
+	// initialising fails, but by then the runtime directory and log have been created
+	assert.EqualError(t, database.Start(), "ah it did not work")
+
+	assert.DirExists(t, runtimePath)
+	assert.FileExists(t, database.syncedLogger.file.Name())
+
+	assert.NoError(t, CleanupAll())
+	assert.NoDirExists(t, runtimePath)
+	assert.NoFileExists(t, database.syncedLogger.file.Name())
+}
+
+func Test_CleanupAll_RemovesDefaultRuntimePath(t *testing.T) {
+	execDir := t.TempDir()
+	binariesPath := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(binariesPath, "bin"), 0755))
+
+	database := NewDatabase(DefaultConfig().ExecDir(execDir).BinariesPath(binariesPath))
+	database.initDatabase = func(binaryExtractLocation, runtimePath, pgDataDir, username, password, locale string, extraArgs []string, logger *os.File) error {
+		return errors.New("ah it did not work")
+	}
+
+	assert.EqualError(t, database.Start(), "ah it did not work")
+
+	runtimePath := filepath.Join(execDir, "extracted", strconv.Itoa(os.Getpid()))
+	assert.DirExists(t, runtimePath)
+	assert.FileExists(t, filepath.Join(runtimePath, runtimeOwnerFile))
+
+	assert.NoError(t, CleanupAll())
+	assert.NoDirExists(t, runtimePath)
+}
+
+func Test_reapRuntimeDirectories(t *testing.T) {
+	// a process that has exited, whose PID is very unlikely to have been reused yet
+	exited := exec.Command(os.Args[0], "-test.run=^$")
+	require.NoError(t, exited.Run())
+
+	exitedPid := strconv.Itoa(exited.Process.Pid)
+
+	dir := t.TempDir()
+	dead := filepath.Join(dir, exitedPid)
+	own := filepath.Join(dir, strconv.Itoa(os.Getpid()))
+	alive := filepath.Join(dir, strconv.Itoa(os.Getppid()))
+	orphaned := filepath.Join(dir, "999999999")
+	unmarked := filepath.Join(dir, "999999998")
+	otherContainer := filepath.Join(dir, "999999997")
+	other := filepath.Join(dir, "cache")
+
+	for _, path := range []string{dead, own, alive, orphaned, unmarked, otherContainer, other} {
+		require.NoError(t, os.MkdirAll(filepath.Join(path, "data"), 0700))
+	}
+
+	markRuntimeDirectory(t, dead, runtimeOwner(exited.Process.Pid))
+	markRuntimeDirectory(t, own, runtimeOwner(os.Getpid()))
+	markRuntimeDirectory(t, alive, runtimeOwner(os.Getppid()))
+	markRuntimeDirectory(t, orphaned, runtimeOwner(999999999))
+	// a process of another container sharing the volume, whose PID cannot be checked from here
+	markRuntimeDirectory(t, otherContainer, "ci-runner-2 pid:[4026532000] 999999997")
+
+	// the server of a killed process is still running from its runtime directory
+	require.NoError(t, os.WriteFile(filepath.Join(orphaned, "data", "postmaster.pid"), []byte(strconv.Itoa(os.Getpid())+"\n"+orphaned+"\n1700000000\n5432\n"), 0600))
+
+	require.NoError(t, reapRuntimeDirectories(dir))
+
+	assert.NoDirExists(t, dead)
+	assert.DirExists(t, own)
+	assert.DirExists(t, alive)
+	assert.DirExists(t, orphaned)
+	assert.DirExists(t, unmarked)
+	assert.DirExists(t, otherContainer)
+	assert.DirExists(t, other)
+
+	assert.NoError(t, reapRuntimeDirectories(filepath.Join(dir, "missing")))
+}
+
+func Test_reapRuntimeDirectories_ExecDir(t *testing.T) {
+	exited := exec.Command(os.Args[0], "-test.run=^$")
+	require.NoError(t, exited.Run())
+
+	execDir := t.TempDir()
+	config := DefaultConfig().ExecDir(execDir)
+
+	// a directory of the user's own that happens to be named like a PID
+	users := filepath.Join(execDir, strconv.Itoa(exited.Process.Pid))
+	require.NoError(t, os.MkdirAll(users, 0700))
+
+	dead := filepath.Join(execDir, "extracted", strconv.Itoa(exited.Process.Pid))
+	require.NoError(t, os.MkdirAll(dead, 0700))
+	markRuntimeDirectory(t, dead, runtimeOwner(exited.Process.Pid))
+
+	require.NoError(t, reapRuntimeDirectories(filepath.Dir(defaultRuntimePath(config, ""))))
+
+	assert.NoDirExists(t, dead)
+	assert.DirExists(t, users)
+}
+
+func markRuntimeDirectory(t *testing.T, runtimePath, owner string) {
+	t.Helper()
+
+	require.NoError(t, os.WriteFile(filepath.Join(runtimePath, runtimeOwnerFile), []byte(owner), 0600))
+}
+
+func Test_CleanupAll_StopsProcesses(t *testing.T) {
+	database := startFakeServer(t)
+	resources.addProcess(database.cmd)
+
+	assert.NoError(t, CleanupAll())
+	assert.NotNil(t, database.cmd.cmd.ProcessState)
+	assert.Empty(t, resources.processes)
+}
@@ -3,7 +3,10 @@ package embeddedpostgres
 import (
 	"fmt"
 	"io"
+	"net/url"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -17,12 +20,95 @@ type Config struct {
 	cachePath           string
 	runtimePath         string
 	dataPath            string
+	walPath             string
+	initDBNoSync        bool
+	allowGroupAccess    bool
+	authLocal           AuthMethod
+	authHost            AuthMethod
+	databaseSettings    map[string]string
+	roleSettings        map[string]string
+	schemas             []string
+	schemaOwner         string
+	commonExtensions    bool
+	preset              Preset
+	captureStatements   bool
+	extensionArchives   []string
+	timescaleDB         bool
+	pgCron              bool
 	binariesPath        string
 	locale              string
 	startParameters     map[string]string
 	binaryRepositoryURL string
+	downloadProgress    func(received, total int64)
 	startTimeout        time.Duration
+	idleTimeout         time.Duration
+	maxLifetime         time.Duration
+	openFilesLimit      uint64
+	coreFileSizeLimit   *uint64
+	memoryLimit         uint64
+	cgroupParent        string
+	niceness            int
+	ioPriorityClass     IOPriorityClass
+	ioPriorityLevel     int
+	applicationName     string
+	databaseCollate     string
+	databaseCType       string
+	clientEncoding      string
+	encoding            string
+	databaseEncoding    string
+	localeProvider      LocaleProvider
+	icuLocale           string
+	databases           []DatabaseSpec
+	roles               []RoleSpec
+	extensions          []string
+	initScripts         []string
+	initSQL             []string
+	seedDirectory       string
+	migrations          []MigrationRunner
+	hooks               Hooks
+	jit                 *bool
+	parallelWorkers     *int
+	plannerMethods      map[PlannerMethod]bool
+	faults              faultHooks
+	tarReader           TarReader
+	extractFileMask     os.FileMode
+	extractDirMode      os.FileMode
+	extractOwner        *extractionOwner
+	execDir             string
+	statsTempDirectory  string
+	tempTablespaces     []string
+	tempFileDirectory   string
+	statsPollInterval   time.Duration
+	standbyOf           string
+	initDBStrategy      InitDatabaseStrategy
+	createDBStrategy    CreateDatabaseStrategy
+	walArchive          string
+	socketOnly          bool
+	socketDir           string
+	tls                 bool
+	tlsCertFile         string
+	tlsKeyFile          string
+	mutualTLS           bool
+	hbaEntries          []HBAEntry
+	preloadLibraries    []string
+	initDBArgs          []string
 	logger              io.Writer
+	diagnostic          func(message string)
+	logTailLines        int
+	stdoutLogger        io.Writer
+	logLevel            LogSeverity
+	diagnosticsDir      string
+	snapshotPath        string
+	dataArchive         string
+	cacheInitDB         bool
+	sharedBinaries      bool
+	dataInMemory        bool
+	dataInMemorySize    int64
+	autoTune            bool
+	csvLogHandler       func(entry LogEntry)
+	postgresConfPath    string
+	postgresConfMode    PostgresConfMode
+	confSnippets        map[string]map[string]string
 }
 
 // DefaultConfig provides a default set of configuration to be used "as is" or modified using the provided builders.
@@ -42,6 +128,7 @@ func DefaultConfig() Config {
 		password:            "postgres",
 		startTimeout:        15 * time.Second,
 		logger:              os.Stdout,
+		logTailLines:        20,
 		binaryRepositoryURL: "https://repo1.maven.org/maven2",
 	}
 }
@@ -53,6 +140,7 @@ func (c Config) Version(version PostgresVersion) Config {
 }
 
 // Port sets the runtime port that Postgres can be accessed on.
+// A port of 0 picks a free port each time the server is started, which EmbeddedPostgres.Port then returns.
 func (c Config) Port(port uint32) Config {
 	c.port = port
 	return c
@@ -64,6 +152,31 @@ func (c Config) Database(database string) Config {
 	return c
 }
 
+// Databases creates further databases alongside the one set with Database, owned by the configured user. Databases
+// that already exist, such as those of a reused data directory, are left as they are.
+func (c Config) Databases(names ...string) Config {
+	c.databases = make([]DatabaseSpec, 0, len(names))
+	for _, name := range names {
+		c.databases = append(c.databases, DatabaseSpec{Name: name})
+	}
+
+	return c
+}
+
+// DatabaseSpecs creates further databases alongside the one set with Database as Databases does, with an owner and
+// encoding for each.
+func (c Config) DatabaseSpecs(specs ...DatabaseSpec) Config {
+	c.databases = append([]DatabaseSpec(nil), specs...)
+	return c
+}
+
+// Roles creates the roles on start, before the databases set with Databases so that they can own them. Roles that
+// already exist are altered to match, so that passwords and attributes follow the Config on reused data directories.
+func (c Config) Roles(roles ...RoleSpec) Config {
+	c.roles = append([]RoleSpec(nil), roles...)
+	return c
+}
+
 // Username sets the username that will be used to connect.
 func (c Config) Username(username string) Config {
 	c.username = username
@@ -78,6 +191,8 @@ func (c Config) Password(password string) Config {
 
 // RuntimePath sets the path that will be used for the extracted Postgres runtime directory.
 // If Postgres data directory is not set with DataPath(), this directory is also used as data directory.
+// It is cleaned on every start, other than binaries already extracted into it, which are only extracted again once the
+// version or extension archives change.
 func (c Config) RuntimePath(path string) Config {
 	c.runtimePath = path
 	return c
@@ -97,6 +212,62 @@ func (c Config) DataPath(path string) Config {
 	return c
 }
 
+// DataInMemory places the data directory in memory for much faster I/O, on a tmpfs mount such as /dev/shm with at
+// least sizeLimit bytes free on Linux, or on a RAM disk of sizeLimit bytes, 1GiB when 0, on macOS. Where memory cannot
+// be used, such as on other platforms, the data directory is placed in the runtime directory as usual. It has no
+// effect when DataPath is set. The memory is only freed by CleanupAll, and everything in it is lost should the host
+// restart.
+func (c Config) DataInMemory(sizeLimit int64) Config {
+	c.dataInMemory = true
+	c.dataInMemorySize = sizeLimit
+	return c
+}
+
+// WALPath sets the directory initdb places the write-ahead log in, for example a tmpfs mount or a separate volume.
+// The directory is cleaned whenever the data directory is initialised.
+func (c Config) WALPath(path string) Config {
+	c.walPath = path
+	return c
+}
+
+// CacheInitDB caches the data directory initdb produces alongside the cached binaries, keyed by the version, locale,
+// credentials and initdb arguments such as --data-checksums, and restores it rather than running initdb whenever the
+// data directory is initialised again with the same settings. initdb is often the slowest part of Start. It has no
+// effect with a custom InitDBStrategy or for a standby.
+func (c Config) CacheInitDB(enabled bool) Config {
+	c.cacheInitDB = enabled
+	return c
+}
+
+// InitDBNoSync skips waiting for initdb to fsync the new data directory to disk.
+// This makes initialisation considerably faster, particularly on network filesystems, at the cost of the data directory
+// being corrupted should the operating system crash, which is usually acceptable for tests.
+func (c Config) InitDBNoSync(noSync bool) Config {
+	c.initDBNoSync = noSync
+	return c
+}
+
+// AllowGroupAccess makes the data directory readable by the group of the user running Postgres, allowing tools such as
+// backup or monitoring agents running as a different user in the same group to read it. Requires Postgres 11 or later.
+func (c Config) AllowGroupAccess(allow bool) Config {
+	c.allowGroupAccess = allow
+	return c
+}
+
+// AuthLocal sets the authentication method initdb configures for connections over Unix-domain sockets.
+// If this option is not set, password authentication is used.
+func (c Config) AuthLocal(method AuthMethod) Config {
+	c.authLocal = method
+	return c
+}
+
+// AuthHost sets the authentication method initdb configures for TCP/IP connections.
+// If this option is not set, password authentication is used.
+func (c Config) AuthHost(method AuthMethod) Config {
+	c.authHost = method
+	return c
+}
+
 // BinariesPath sets the path of the pre-downloaded postgres binaries.
 // If this option is left unset, the binaries will be downloaded.
 func (c Config) BinariesPath(path string) Config {
@@ -104,6 +275,14 @@ func (c Config) BinariesPath(path string) Config {
 	return c
 }
 
+// SharedBinaries extracts the binaries once into a directory alongside the cache, or within ExecDir when set, named
+// after the version and any extension archives, which every instance started with the same binaries shares rather than
+// extracting its own copy into its runtime directory. It has no effect when BinariesPath is set.
+func (c Config) SharedBinaries(shared bool) Config {
+	c.sharedBinaries = shared
+	return c
+}
+
 // Locale sets the default locale for initdb
 func (c Config) Locale(locale string) Config {
 	c.locale = locale
@@ -115,7 +294,234 @@ func (c Config) Locale(locale string) Config {
 // These parameters can be used to override the default configuration values in postgres.conf such
 // as max_connections=100. See https://www.postgresql.org/docs/current/runtime-config.html
 func (c Config) StartParameters(parameters map[string]string) Config {
+	c.startParameters = make(map[string]string, len(parameters))
+	for name, value := range parameters {
+		c.startParameters[name] = value
+	}
+
+	return c
+}
+
+// StartParameter sets a single run-time parameter when starting Postgres, keeping any set previously.
+func (c Config) StartParameter(name, value string) Config {
+	parameters := make(map[string]string, len(c.startParameters)+1)
+	for existing, existingValue := range c.startParameters {
+		parameters[existing] = existingValue
+	}
+
+	parameters[name] = value
 	c.startParameters = parameters
+
+	return c
+}
+
+// MaxConnections sets the maximum number of concurrent connections to the server, between 1 and 262143.
+func (c Config) MaxConnections(connections int) Config {
+	return c.StartParameter("max_connections", strconv.Itoa(connections))
+}
+
+// SharedBuffers sets the memory the server uses for shared buffers, which Postgres rounds down to whole kilobytes and
+// requires to be at least 128kB.
+func (c Config) SharedBuffers(bytes uint64) Config {
+	return c.StartParameter("shared_buffers", fmt.Sprintf("%dkB", bytes/1024))
+}
+
+// WorkMem sets the memory each sort or hash operation of a query may use before spilling to temporary files, which
+// Postgres rounds down to whole kilobytes and requires to be at least 64kB.
+func (c Config) WorkMem(bytes uint64) Config {
+	return c.StartParameter("work_mem", fmt.Sprintf("%dkB", bytes/1024))
+}
+
+// StatementTimeout aborts statements running for longer than the timeout, rounded up to whole milliseconds, where 0
+// disables the timeout.
+func (c Config) StatementTimeout(timeout time.Duration) Config {
+	milliseconds := timeout / time.Millisecond
+	if timeout%time.Millisecond > 0 {
+		milliseconds++
+	}
+
+	return c.StartParameter("statement_timeout", fmt.Sprintf("%dms", milliseconds))
+}
+
+// WALLevel sets how much information is written to the write-ahead log, for example WALLevelLogical to test logical
+// decoding.
+func (c Config) WALLevel(level WALLevel) Config {
+	return c.StartParameter("wal_level", string(level))
+}
+
+// PostgresConf sets a user supplied postgresql.conf that either replaces or overlays the one generated by initdb.
+//
+// The file is rendered as a Go text/template on every start, so it may reference {{.Port}}, {{.DataPath}},
+// {{.RuntimePath}}, {{.BinariesPath}}, {{.Username}} and {{.Database}} instead of hard coding them.
+func (c Config) PostgresConf(path string, mode PostgresConfMode) Config {
+	c.postgresConfPath = path
+	c.postgresConfMode = mode
+	return c
+}
+
+// ConfSnippet adds a drop-in configuration file named name.conf to the conf.d directory included by postgresql.conf.
+//
+// Snippets are written on every start and are applied in lexical order of their names, after postgresql.conf and any
+// file supplied with PostgresConf, so prefixing names with a number such as "10-logging" controls precedence.
+func (c Config) ConfSnippet(name string, settings map[string]string) Config {
+	snippets := make(map[string]map[string]string, len(c.confSnippets)+1)
+	for k, v := range c.confSnippets {
+		snippets[k] = v
+	}
+
+	snippets[name] = settings
+	c.confSnippets = snippets
+
+	return c
+}
+
+// DatabaseSettings sets session defaults for the created database using ALTER DATABASE ... SET, for example
+// statement_timeout, lock_timeout, idle_in_transaction_session_timeout or search_path.
+// The settings are applied on every start and take effect for new connections.
+func (c Config) DatabaseSettings(settings map[string]string) Config {
+	c.databaseSettings = settings
+	return c
+}
+
+// RoleSettings sets session defaults for the configured user using ALTER ROLE ... SET.
+// The settings are applied on every start and take effect for new connections.
+func (c Config) RoleSettings(settings map[string]string) Config {
+	c.roleSettings = settings
+	return c
+}
+
+// Schemas sets schemas that will be created in the created database if they do not already exist.
+// The schemas are owned by the configured user unless SchemaOwner is set.
+func (c Config) Schemas(schemas ...string) Config {
+	c.schemas = schemas
+	return c
+}
+
+// SchemaOwner sets the role that will own the schemas created by Schemas.
+func (c Config) SchemaOwner(owner string) Config {
+	c.schemaOwner = owner
+	return c
+}
+
+// CommonExtensions enables the contrib extensions most applications rely on in the created database: uuid-ossp,
+// pgcrypto, citext, pg_trgm and btree_gin. Extensions missing from the Postgres binaries are skipped rather than failing
+// start and can be listed with EmbeddedPostgres.UnavailableExtensions.
+func (c Config) CommonExtensions(enable bool) Config {
+	c.commonExtensions = enable
+	return c
+}
+
+// Extensions creates the extensions, such as "pgcrypto" or "hstore", in the created database on every start, failing
+// start when any are missing from the Postgres binaries.
+func (c Config) Extensions(extensions ...string) Config {
+	c.extensions = append([]string(nil), extensions...)
+	return c
+}
+
+// InitScripts runs the SQL files within the created database once the data directory has been initialised and
+// provisioned, in the manner of docker-entrypoint-initdb.d. A directory runs the .sql files it contains in lexical
+// order. They do not run again when the data directory is reused and must complete within the StartTimeout.
+func (c Config) InitScripts(paths ...string) Config {
+	c.initScripts = append([]string(nil), paths...)
+	return c
+}
+
+// InitSQL runs the statements within the created database once, after any InitScripts.
+func (c Config) InitSQL(statements ...string) Config {
+	c.initSQL = append([]string(nil), statements...)
+	return c
+}
+
+// Migrations runs the migration runners in order on every start, once the server accepts connections and before the
+// SeedDirectory is applied, failing start when any returns an error.
+func (c Config) Migrations(runners ...MigrationRunner) Config {
+	c.migrations = append([]MigrationRunner(nil), runners...)
+	return c
+}
+
+// Hooks sets the hooks called as the server is initialised, started and stopped, such as to template configuration,
+// load fixtures or tear down state. A hook returning an error fails the Start or Stop it was called from.
+func (c Config) Hooks(hooks Hooks) Config {
+	c.hooks = hooks
+	return c
+}
+
+// SeedDirectory applies the .sql files within dir on every start using EmbeddedPostgres.Seed, so that files already
+// applied to a reused data directory are skipped.
+func (c Config) SeedDirectory(dir string) Config {
+	c.seedDirectory = dir
+	return c
+}
+
+// Preset applies a named bundle of server settings such as ManagedCloud, FastEphemeral, Durable, CI or Benchmark.
+// Settings from StartParameters, ConfSnippet and ALTER SYSTEM take precedence over those of the preset. Presets with a
+// start timeout of their own, such as CI, replace any StartTimeout set before them, whilst one set after them is kept.
+func (c Config) Preset(preset Preset) Config {
+	c.preset = preset
+
+	// an unknown preset is reported when the server is started
+	definition, err := preset.definition(c.version)
+	if err == nil && definition.startTimeout > 0 {
+		c.startTimeout = definition.startTimeout
+	}
+
+	// a certificate given to TLS is kept, otherwise one is generated
+	if err == nil && definition.requireTLS {
+		c.tls = true
+	}
+
+	return c
+}
+
+// AutoTune sizes shared_buffers, work_mem and the parallel workers to the memory and CPUs available, taking the limits
+// of the container's cgroup and any MemoryLimit into account, as the Postgres defaults are sized for the smallest of
+// hosts. Tuned settings take precedence over those of a Preset, whilst StartParameters, ConfSnippet and ALTER SYSTEM
+// take precedence over them. Where the available resources cannot be determined the defaults are kept with a
+// diagnostic.
+func (c Config) AutoTune(enabled bool) Config {
+	c.autoTune = enabled
+	return c
+}
+
+// CaptureStatements logs every statement executed by the server so that they can be retrieved with
+// EmbeddedPostgres.CapturedStatements, allowing tests to assert on the SQL an application generates.
+func (c Config) CaptureStatements(capture bool) Config {
+	c.captureStatements = capture
+	return c
+}
+
+// ExtensionArchives adds tar.xz archives laid out like the Postgres binaries, with shared libraries under lib and control
+// and script files under share/extension, that are extracted over the binaries on start. The extensions they contain
+// must be built for the configured version and platform.
+func (c Config) ExtensionArchives(paths ...string) Config {
+	c.extensionArchives = append(append([]string{}, c.extensionArchives...), paths...)
+	return c
+}
+
+// TimescaleDB preloads the timescaledb library and creates the extension in the configured database.
+// archivePath is an archive as accepted by ExtensionArchives containing TimescaleDB, and can be left empty when the
+// binaries fetched from BinaryRepositoryURL already include it.
+func (c Config) TimescaleDB(archivePath string) Config {
+	if archivePath != "" {
+		c = c.ExtensionArchives(archivePath)
+	}
+
+	c.timescaleDB = true
+
+	return c
+}
+
+// PgCron preloads the pg_cron library, schedules jobs within the configured database and creates the extension there.
+// Jobs run in background workers so need no connection credentials. archivePath is an archive as accepted by
+// ExtensionArchives containing pg_cron, and can be left empty when the binaries fetched from BinaryRepositoryURL already
+// include it.
+func (c Config) PgCron(archivePath string) Config {
+	if archivePath != "" {
+		c = c.ExtensionArchives(archivePath)
+	}
+
+	c.pgCron = true
+
 	return c
 }
 
@@ -125,6 +531,366 @@ func (c Config) StartTimeout(timeout time.Duration) Config {
 	return c
 }
 
+// IdleTimeout stops the server once no client has been connected for the timeout, releasing its resources until it is
+// started again with Start or EnsureStarted. Configure a DataPath outside of the RuntimePath to keep data across such
+// restarts. A timeout of zero, the default, never stops the server.
+func (c Config) IdleTimeout(timeout time.Duration) Config {
+	c.idleTimeout = timeout
+	return c
+}
+
+// MaxLifetime stops the server once it has been running for longer than the lifetime, logging the connections still open
+// to help find what left it running. On platforms other than Windows the server is also stopped shortly afterwards should
+// this process exit without calling Stop, such as when a test suite panics. A lifetime of zero, the default, never
+// stops the server.
+func (c Config) MaxLifetime(lifetime time.Duration) Config {
+	c.maxLifetime = lifetime
+	return c
+}
+
+// OpenFilesLimit sets the maximum number of files the postgres process may have open. Not supported on Windows.
+func (c Config) OpenFilesLimit(limit uint64) Config {
+	c.openFilesLimit = limit
+	return c
+}
+
+// CoreFileSizeLimit sets the maximum size in bytes of core files written by the postgres process, rounded up to the
+// nearest 512 bytes, where zero disables them. Not supported on Windows.
+func (c Config) CoreFileSizeLimit(bytes uint64) Config {
+	c.coreFileSizeLimit = &bytes
+	return c
+}
+
+// MemoryLimit caps the memory used by the postgres process and its backends in bytes, so a runaway query cannot exhaust
+// the memory of the host. Only supported on Linux with cgroup v2, where a cgroup for the server is created within
+// cgroupParent, a cgroup directory such as /sys/fs/cgroup/user.slice/user-1000.slice/user@1000.service/app.slice that
+// the current user may create cgroups in and has the memory controller enabled for its children.
+func (c Config) MemoryLimit(bytes uint64, cgroupParent string) Config {
+	c.memoryLimit = bytes
+	c.cgroupParent = cgroupParent
+	return c
+}
+
+// Niceness adjusts the CPU scheduling priority of the postgres process relative to the current process, where a positive
+// value such as 10 lowers it so that a background server does not compete with other work. On Windows a positive value
+// runs postgres with the below normal priority class, or the idle class when 15 or above, and negative values are not
+// supported.
+func (c Config) Niceness(niceness int) Config {
+	c.niceness = niceness
+	return c
+}
+
+// IOPriority sets the IO scheduling class of the postgres process, along with the priority from 0, the highest, to 7
+// within the best effort class. Only supported on Linux.
+func (c Config) IOPriority(class IOPriorityClass, level int) Config {
+	c.ioPriorityClass = class
+	c.ioPriorityLevel = level
+	return c
+}
+
+// DatabaseLocale sets the LC_COLLATE and LC_CTYPE of the configured database when it is created, allowing it to differ
+// from the locale of the cluster set with Locale. Either can be left empty to use that of the cluster. Not supported for
+// the postgres database, which is created by initdb.
+func (c Config) DatabaseLocale(collate, ctype string) Config {
+	c.databaseCollate = collate
+	c.databaseCType = ctype
+	return c
+}
+
+// Encoding sets the encoding initdb gives the template databases, and therefore the postgres database and those
+// created afterwards, such as "SQL_ASCII" or "LATIN1". It must be compatible with the Locale, which "C" is for all
+// encodings.
+func (c Config) Encoding(encoding string) Config {
+	c.encoding = encoding
+	return c
+}
+
+// DatabaseEncoding sets the encoding of the configured database when it is created, allowing it to differ from that of
+// the cluster set with Encoding. Like DatabaseLocale, it is not supported for the postgres database.
+func (c Config) DatabaseEncoding(encoding string) Config {
+	c.databaseEncoding = encoding
+	return c
+}
+
+// LocaleProvider sets the provider of the default collation of the cluster. With LocaleProviderICU, icuLocale is the
+// ICU locale to use, such as "de-DE", defaulting to the root locale "und" when empty. Versions before Postgres 15
+// cannot change the provider and use libc, with ICU collations still available to use explicitly.
+func (c Config) LocaleProvider(provider LocaleProvider, icuLocale string) Config {
+	c.localeProvider = provider
+	c.icuLocale = icuLocale
+	return c
+}
+
+// DatabaseClientEncoding sets the default client_encoding of sessions connecting to the configured database.
+// It takes precedence over a client_encoding given with DatabaseSettings.
+func (c Config) DatabaseClientEncoding(encoding string) Config {
+	c.clientEncoding = encoding
+	return c
+}
+
+// ApplicationName sets the application_name of connections that do not set their own, both as the server default and
+// as the fallback_application_name of GetConnectionURL, so they can be told apart in pg_stat_activity and the logs.
+// Connections made by this library are always named embedded-postgres.
+func (c Config) ApplicationName(name string) Config {
+	c.applicationName = name
+	return c
+}
+
+// JIT sets whether queries may be just-in-time compiled, which is on by default from Postgres 12 and can change plans
+// and timings depending on the hardware. JIT is not available before Postgres 11, where disabling it has no effect.
+func (c Config) JIT(enabled bool) Config {
+	c.jit = &enabled
+	return c
+}
+
+// ParallelWorkersPerGather sets max_parallel_workers_per_gather, with 0 disabling parallel query so that plans do not
+// depend on the number of CPUs of the machine running the tests.
+func (c Config) ParallelWorkersPerGather(workers int) Config {
+	c.parallelWorkers = &workers
+	return c
+}
+
+// PlannerMethod enables or disables one of the planner's enable_* settings, for example to force an index scan with
+// PlannerMethod(PlannerSeqScan, false). Disabling a method the configured version does not have is ignored.
+func (c Config) PlannerMethod(method PlannerMethod, enabled bool) Config {
+	methods := make(map[PlannerMethod]bool, len(c.plannerMethods)+1)
+	for k, v := range c.plannerMethods {
+		methods[k] = v
+	}
+
+	methods[method] = enabled
+	c.plannerMethods = methods
+
+	return c
+}
+
+// FileFault sets a hook called before each file the library writes, allowing tests to simulate storage errors.
+func (c Config) FileFault(fault FileFault) Config {
+	c.faults.file = fault
+	return c
+}
+
+// CommandFault sets a hook called before each external command the library runs, allowing tests to simulate failing or
+// hanging binaries.
+func (c Config) CommandFault(fault CommandFault) Config {
+	c.faults.command = fault
+	return c
+}
+
+// TarReader sets how the binaries archive and any ExtensionArchives are read, for example to support a different
+// compression format or to decompress using a FIPS validated library. It defaults to DefaultTarReader.
+func (c Config) TarReader(tarReader TarReader) Config {
+	c.tarReader = tarReader
+	return c
+}
+
+// ExecDir sets a directory that allows executing binaries, under which the runtime directory is created when
+// RuntimePath is not set, for hosts where the cache or temporary directories are mounted noexec or restricted by
+// SELinux or AppArmor. The runtime directory is created within an extracted directory in dir and named after the PID
+// of the process, and those left there by processes that have exited are removed on start. Nothing else within dir is
+// removed.
+func (c Config) ExecDir(dir string) Config {
+	c.execDir = dir
+	return c
+}
+
+// StatsTempDirectory sets stats_temp_directory, where the statistics collector of Postgres 14 and earlier writes
+// temporary statistics files, relative to the data directory unless absolute. It is ignored by Postgres 15 and later,
+// which keep statistics in shared memory.
+func (c Config) StatsTempDirectory(dir string) Config {
+	c.statsTempDirectory = dir
+	return c
+}
+
+// TempTablespaces sets temp_tablespaces, the existing tablespaces temporary tables and files spilled by large sorts
+// and hashes are created in.
+func (c Config) TempTablespaces(tablespaces ...string) Config {
+	c.tempTablespaces = append([]string(nil), tablespaces...)
+	return c
+}
+
+// TempFileDirectory directs temporary tables and spill files to dir, for example on fast storage, through a tablespace
+// created within it. The directory is managed by the library: it is emptied when the tablespace is created and the
+// temporary files within it are removed when the server stops.
+func (c Config) TempFileDirectory(dir string) Config {
+	c.tempFileDirectory = dir
+	return c
+}
+
+// StatsPollInterval samples the activity counters of the database at interval whilst the server is running, so that
+// soak tests can watch its health with LatestStats.
+func (c Config) StatsPollInterval(interval time.Duration) Config {
+	c.statsPollInterval = interval
+	return c
+}
+
+// StandbyOf bootstraps the data directory as a streaming standby of an external primary, copying it with pg_basebackup
+// rather than running initdb, for example "host=db.internal port=5432 user=replicator password=secret".
+// The primary must allow replication connections from this host, and the configured Version must match its major
+// version. The Username, Password and Database are not created but must exist on the primary, as a standby replicates
+// them along with the primary's pg_hba.conf.
+func (c Config) StandbyOf(primaryConnInfo string) Config {
+	c.standbyOf = primaryConnInfo
+	return c
+}
+
+// WALArchive archives completed WAL segments, along with any base backup taken with EmbeddedPostgres.BaseBackup,
+// into dir, enabling point-in-time recovery with RestoreToTime and RestoreToLSN on Postgres 10 and later.
+func (c Config) WALArchive(dir string) Config {
+	c.walArchive = dir
+	return c
+}
+
+// UnixSocketOnly disables TCP, so that the server listens only on a Unix-domain socket in dir, or in the runtime
+// directory when dir is empty. The port is then only used to name the socket, so it need not be free and defaults to
+// 5432 when set to 0. Connections made by the library, and connection strings, use the socket instead of localhost.
+// Paths to sockets are limited to around 100 characters, so dir should be short.
+func (c Config) UnixSocketOnly(dir string) Config {
+	c.socketOnly = true
+	c.socketDir = dir
+	return c
+}
+
+// TLS enables TLS on the server using the certificate and key files given, or with a certificate for localhost that
+// is generated into the runtime directory when both are empty. The authority signing a generated certificate is
+// available from EmbeddedPostgres.TLSRootCertFile. Connections are not required to use TLS unless the Preset requires it.
+func (c Config) TLS(certFile, keyFile string) Config {
+	c.tls = true
+	c.tlsCertFile = certFile
+	c.tlsKeyFile = keyFile
+	return c
+}
+
+// MutualTLS requires connections over TLS to present a client certificate issued to the connecting user by the
+// authority generated by TLS, as returned by EmbeddedPostgres.ClientCertificate. Connections without TLS are still
+// accepted as configured by initdb.
+func (c Config) MutualTLS() Config {
+	c.mutualTLS = true
+	return c
+}
+
+// HBAEntries adds client authentication rules to pg_hba.conf each time the server starts. They are matched in the
+// order given and before the rules written by initdb, so can reject connections that would otherwise be accepted.
+func (c Config) HBAEntries(entries ...HBAEntry) Config {
+	c.hbaEntries = append([]HBAEntry(nil), entries...)
+	return c
+}
+
+// SharedPreloadLibraries loads the libraries, such as "pg_stat_statements" or "auto_explain", when the server starts,
+// as they cannot be loaded into a running server. They are loaded after those of extensions enabled by the other
+// builders, such as TimescaleDB.
+func (c Config) SharedPreloadLibraries(libraries ...string) Config {
+	c.preloadLibraries = append([]string(nil), libraries...)
+	return c
+}
+
+// InitDBArgs passes further arguments to initdb, such as "--data-checksums" or "--wal-segsize=64", after those derived
+// from the rest of the Config so that they take precedence. The data directory, username and password are always
+// those of the Config.
+func (c Config) InitDBArgs(args ...string) Config {
+	c.initDBArgs = append([]string(nil), args...)
+	return c
+}
+
+// InitDatabaseStrategy replaces running initdb to create the data directory, for example to restore it from a backup.
+// It is only called when the data directory does not already hold a data directory of the configured version.
+func (c Config) InitDatabaseStrategy(strategy InitDatabaseStrategy) Config {
+	c.initDBStrategy = strategy
+	return c
+}
+
+// CreateDatabaseStrategy replaces how the configured database is created once a newly initialised server has started.
+// Strategies can wrap DefaultCreateDatabase to customise rather than replace it, passing on the ctx they are given so
+// that it connects to the server as the library does.
+func (c Config) CreateDatabaseStrategy(strategy CreateDatabaseStrategy) Config {
+	c.createDBStrategy = strategy
+	return c
+}
+
+// ExtractionPermissions restricts the permissions of the extracted binaries, for example to satisfy security scanners
+// that flag world writable files. The permissions of each file in the archive are masked with fileMask, so 0755 keeps
+// binaries executable whilst removing group and world write, and directories are given dirMode. A zero value leaves
+// the permissions of the archive and umask in place.
+func (c Config) ExtractionPermissions(fileMask, dirMode os.FileMode) Config {
+	c.extractFileMask = fileMask
+	c.extractDirMode = dirMode
+	return c
+}
+
+// ExtractionOwner changes the owner of the extracted binaries to uid and gid, which requires running as root.
+func (c Config) ExtractionOwner(uid, gid int) Config {
+	c.extractOwner = &extractionOwner{uid: uid, gid: gid}
+	return c
+}
+
+// ServerOutput sends the standard output of the server to stdout and its standard error, where it logs, to stderr, in
+// place of Logger. The output of initdb and the other Postgres tools is sent to stderr.
+func (c Config) ServerOutput(stdout, stderr io.Writer) Config {
+	c.stdoutLogger = stdout
+	c.logger = stderr
+	return c
+}
+
+// LogLevel leaves the server log lines less severe than min, along with the DETAIL, STATEMENT and other lines that
+// follow them, out of what is sent to the Logger, such as LogSeverityWarning to leave out routine LOG messages. It
+// does not change what the server logs, so the log tail in errors and captured statements are unaffected.
+func (c Config) LogLevel(min LogSeverity) Config {
+	c.logLevel = min
+	return c
+}
+
+// DiagnosticsOnFailure dumps diagnostics with EmbeddedPostgres.DumpDiagnostics into a new directory within dir
+// whenever Start or Restart fails once its log has been created, such as when initdb or the health check fails, and
+// names that directory in the error returned.
+func (c Config) DiagnosticsOnFailure(dir string) Config {
+	c.diagnosticsDir = dir
+	return c
+}
+
+// SnapshotPath sets the directory EmbeddedPostgres.Snapshot keeps snapshots of the data directory in. It defaults to
+// a directory within the runtime directory, where snapshots are lost once Start cleans it up.
+func (c Config) SnapshotPath(dir string) Config {
+	c.snapshotPath = dir
+	return c
+}
+
+// DataArchive starts the server from a data directory archived with EmbeddedPostgres.ExportDataArchive, such as one
+// migrated and seeded once by a setup job, rather than running initdb. The archive is restored whenever the data
+// directory would otherwise be initialised, and the database creation and init scripts are skipped as for a reused
+// data directory, whilst migrations and seeds already applied to the archive have nothing left to do. The archive keeps
+// the users and databases it was exported with.
+func (c Config) DataArchive(path string) Config {
+	c.dataArchive = path
+	return c
+}
+
+// LibraryLogger sends problems the library runs into that cannot be returned as errors, such as failing to stop a
+// server from a watcher or a server that was never stopped, to logger a line at a time rather than to the postgres
+// log and standard error.
+func (c Config) LibraryLogger(logger io.Writer) Config {
+	c.diagnostic = func(message string) {
+		_, _ = fmt.Fprintln(logger, strings.TrimRight(message, "\n"))
+	}
+
+	return c
+}
+
+// LogTailLines sets how many of the last lines of the postgres log are included in the error returned when initdb, the
+// server or its health check fail during Start, or the server fails to stop, which defaults to 20. Zero leaves the log
+// out of errors.
+func (c Config) LogTailLines(lines int) Config {
+	c.logTailLines = lines
+	return c
+}
+
+// CSVLog has the server log in CSV format, passing each row to handle as a LogEntry once it is written, such as to
+// assert on the errors a test caused. The rows are also written to the Logger in the format the server would otherwise
+// have used. handle is called from a background goroutine whilst the server runs, and for the last rows by Stop.
+func (c Config) CSVLog(handle func(entry LogEntry)) Config {
+	c.csvLogHandler = handle
+	return c
+}
+
 // Logger sets the logger for postgres output
 func (c Config) Logger(logger io.Writer) Config {
 	c.logger = logger
@@ -137,10 +903,59 @@ func (c Config) BinaryRepositoryURL(binaryRepositoryURL string) Config {
 	return c
 }
 
+// DownloadProgress calls progress as the binaries archive is downloaded with the bytes received so far and the total,
+// or -1 when the repository does not report the size, so that long first-run downloads can be shown progressing. It is
+// called from the goroutine downloading, often for every few kilobytes received, and not at all when the archive is
+// already cached.
+func (c Config) DownloadProgress(progress func(received, total int64)) Config {
+	c.downloadProgress = progress
+	return c
+}
+
 func (c Config) GetConnectionURL() string {
-	return fmt.Sprintf("postgresql://%s:%s@%s:%d/%s", c.username, c.password, "localhost", c.port, c.database)
+	connectionURL := fmt.Sprintf("postgresql://%s:%s@%s:%d/%s", c.username, c.password, "localhost", c.port, c.database)
+
+	if c.applicationName != "" {
+		connectionURL += "?fallback_application_name=" + url.QueryEscape(c.applicationName)
+	}
+
+	return connectionURL
 }
 
+// PostgresConfMode determines how a user supplied postgresql.conf is combined with the one generated by initdb.
+type PostgresConfMode int
+
+const (
+	// ReplacePostgresConf replaces the generated postgresql.conf entirely.
+	ReplacePostgresConf PostgresConfMode = iota
+	// OverlayPostgresConf includes the supplied file after the generated postgresql.conf so its settings take precedence.
+	OverlayPostgresConf
+)
+
+// AuthMethod represents a Postgres client authentication method as used in pg_hba.conf.
+type AuthMethod string
+
+// Predefined Postgres authentication methods.
+const (
+	AuthTrust       = AuthMethod("trust")
+	AuthReject      = AuthMethod("reject")
+	AuthPassword    = AuthMethod("password")
+	AuthMD5         = AuthMethod("md5")
+	AuthScramSHA256 = AuthMethod("scram-sha-256")
+	AuthPeer        = AuthMethod("peer")
+	AuthIdent       = AuthMethod("ident")
+	AuthCert        = AuthMethod("cert")
+)
+
+// LocaleProvider represents the library providing the default collation and character classification of a cluster.
+type LocaleProvider string
+
+// Predefined locale providers.
+const (
+	LocaleProviderLibc = LocaleProvider("libc")
+	LocaleProviderICU  = LocaleProvider("icu")
+)
+
 // PostgresVersion represents the semantic version used to fetch and run the Postgres process.
 type PostgresVersion string
 
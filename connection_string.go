@@ -0,0 +1,152 @@
+package embeddedpostgres
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ConnectionOption customises a connection string built with EmbeddedPostgres.ConnectionString.
+type ConnectionOption func(*connectionParameters)
+
+type connectionParameters struct {
+	host         string
+	port         uint32
+	user         string
+	password     string
+	database     string
+	parameters   map[string]string
+	keywordValue bool
+}
+
+// WithDatabase connects to database rather than the configured one.
+func WithDatabase(database string) ConnectionOption {
+	return func(p *connectionParameters) {
+		p.database = database
+	}
+}
+
+// WithUser connects as user rather than the configured username.
+func WithUser(user, password string) ConnectionOption {
+	return func(p *connectionParameters) {
+		p.user = user
+		p.password = password
+	}
+}
+
+// WithSSLMode sets the sslmode of the connection, which defaults to disable, or to require when the preset requires TLS.
+func WithSSLMode(mode string) ConnectionOption {
+	return WithParameter("sslmode", mode)
+}
+
+// WithParameter sets a further connection parameter, such as connect_timeout.
+func WithParameter(name, value string) ConnectionOption {
+	return func(p *connectionParameters) {
+		p.parameters[name] = value
+	}
+}
+
+// WithClientCertificate presents the client certificate and key, such as those returned by
+// EmbeddedPostgres.ClientCertificate, when connecting over TLS.
+func WithClientCertificate(certFile, keyFile string) ConnectionOption {
+	return func(p *connectionParameters) {
+		p.parameters["sslcert"] = certFile
+		p.parameters["sslkey"] = keyFile
+	}
+}
+
+// WithKeywordValueFormat formats the connection string as keyword/value pairs, such as
+// "host=localhost port=5432 user=postgres", rather than as a URL.
+func WithKeywordValueFormat() ConnectionOption {
+	return func(p *connectionParameters) {
+		p.keywordValue = true
+	}
+}
+
+// ConnectionString returns a connection string for the server reflecting its actual host, port, credentials and
+// database, formatted as a postgresql:// URL unless WithKeywordValueFormat is given.
+func (ep *EmbeddedPostgres) ConnectionString(opts ...ConnectionOption) string {
+	p := &connectionParameters{
+		host:       connectionHost(ep.config),
+		port:       ep.Port(),
+		user:       ep.config.username,
+		password:   ep.config.password,
+		database:   ep.config.database,
+		parameters: map[string]string{"sslmode": internalSSLMode(ep.config)},
+	}
+
+	if ep.config.applicationName != "" {
+		p.parameters["fallback_application_name"] = ep.config.applicationName
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	if p.keywordValue {
+		return p.keywordValueString()
+	}
+
+	return p.url()
+}
+
+func (p *connectionParameters) url() string {
+	query := url.Values{}
+	for name, value := range p.parameters {
+		query.Set(name, value)
+	}
+
+	u := url.URL{
+		Scheme: "postgresql",
+		User:   url.UserPassword(p.user, p.password),
+		Host:   net.JoinHostPort(p.host, strconv.Itoa(int(p.port))),
+		Path:   "/" + p.database,
+	}
+
+	if strings.HasPrefix(p.host, "/") {
+		// a socket directory cannot be given as the host of a URL
+		u.Host = ""
+		query.Set("host", p.host)
+		query.Set("port", strconv.Itoa(int(p.port)))
+	}
+
+	u.RawQuery = query.Encode()
+
+	return u.String()
+}
+
+func (p *connectionParameters) keywordValueString() string {
+	pairs := []string{
+		"host=" + quoteConnectionValue(p.host),
+		fmt.Sprintf("port=%d", p.port),
+		"user=" + quoteConnectionValue(p.user),
+		"password=" + quoteConnectionValue(p.password),
+		"dbname=" + quoteConnectionValue(p.database),
+	}
+
+	names := make([]string, 0, len(p.parameters))
+	for name := range p.parameters {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		pairs = append(pairs, name+"="+quoteConnectionValue(p.parameters[name]))
+	}
+
+	return strings.Join(pairs, " ")
+}
+
+// quoteConnectionValue quotes a keyword/value connection string value when it is empty or contains spaces, quotes or
+// backslashes, which are escaped.
+func quoteConnectionValue(value string) string {
+	if value != "" && !strings.ContainsAny(value, ` '\`) {
+		return value
+	}
+
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+}
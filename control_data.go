@@ -0,0 +1,133 @@
+package embeddedpostgres
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ControlData represents the contents of the Postgres control file as reported by pg_controldata.
+type ControlData struct {
+	SystemIdentifier             string
+	ClusterState                 string
+	LatestCheckpointLocation     string
+	LatestCheckpointREDOLocation string
+	LatestCheckpointTimeLineID   uint32
+	WALLevel                     string
+	WALLogHints                  bool
+	BytesPerWALSegment           uint64
+	DataPageChecksumVersion      uint32
+	// Raw holds every value reported by pg_controldata keyed by its label.
+	Raw map[string]string
+}
+
+// DataChecksumsEnabled reports whether the cluster was initialised with data page checksums.
+func (c ControlData) DataChecksumsEnabled() bool {
+	return c.DataPageChecksumVersion != 0
+}
+
+// ControlData runs pg_controldata against the data directory and parses its output.
+// It can be called while the server is running or after it has been stopped.
+func (ep *EmbeddedPostgres) ControlData() (ControlData, error) {
+	output, err := ep.controlDataOutput()
+	if err != nil {
+		return ControlData{}, err
+	}
+
+	return parseControlData(output)
+}
+
+// controlDataOutput runs pg_controldata against the data directory, returning its output.
+func (ep *EmbeddedPostgres) controlDataOutput() (string, error) {
+	if ep.config.binariesPath == "" || ep.config.dataPath == "" {
+		return "", errors.New("data directory has not been initialised")
+	}
+
+	cmd := exec.Command(filepath.Join(ep.config.binariesPath, "bin/pg_controldata"), "-D", ep.config.dataPath)
+	// pg_controldata output is localised, force the C locale so it can be parsed
+	cmd.Env = append(os.Environ(), "LC_ALL=C", "LANG=C")
+
+	buf := &bytes.Buffer{}
+	cmd.Stdout = buf
+	cmd.Stderr = buf
+
+	err := ep.config.faults.checkCommand(cmd)
+	if err == nil {
+		err = cmd.Run()
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("unable to read control data using %s: %w\n%s", cmd.String(), err, buf.String())
+	}
+
+	return buf.String(), nil
+}
+
+func parseControlData(output string) (ControlData, error) {
+	controlData := ControlData{Raw: map[string]string{}}
+
+	sc := bufio.NewScanner(strings.NewReader(output))
+	for sc.Scan() {
+		key, value, found := strings.Cut(sc.Text(), ":")
+		if !found {
+			continue
+		}
+
+		controlData.Raw[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+
+	if err := sc.Err(); err != nil {
+		return controlData, fmt.Errorf("unable to parse control data: %w", err)
+	}
+
+	if len(controlData.Raw) == 0 {
+		return controlData, errors.New("unable to parse control data: no values found")
+	}
+
+	raw := controlData.Raw
+	controlData.SystemIdentifier = raw["Database system identifier"]
+	controlData.ClusterState = raw["Database cluster state"]
+	controlData.LatestCheckpointLocation = raw["Latest checkpoint location"]
+	controlData.LatestCheckpointREDOLocation = raw["Latest checkpoint's REDO location"]
+	controlData.WALLevel = raw["wal_level setting"]
+	controlData.WALLogHints = raw["wal_log_hints setting"] == "on"
+
+	timeLineID, err := parseControlDataUint(raw, "Latest checkpoint's TimeLineID", 32)
+	if err != nil {
+		return controlData, err
+	}
+
+	checksumVersion, err := parseControlDataUint(raw, "Data page checksum version", 32)
+	if err != nil {
+		return controlData, err
+	}
+
+	if controlData.BytesPerWALSegment, err = parseControlDataUint(raw, "Bytes per WAL segment", 64); err != nil {
+		return controlData, err
+	}
+
+	controlData.LatestCheckpointTimeLineID = uint32(timeLineID)
+	controlData.DataPageChecksumVersion = uint32(checksumVersion)
+
+	return controlData, nil
+}
+
+func parseControlDataUint(raw map[string]string, key string, bitSize int) (uint64, error) {
+	value, ok := raw[key]
+	if !ok {
+		return 0, nil
+	}
+
+	parsed, err := strconv.ParseUint(value, 10, bitSize)
+	if err != nil {
+		return 0, fmt.Errorf("unable to parse control data value %q for %s: %w", value, key, err)
+	}
+
+	return parsed, nil
+}
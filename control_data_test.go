@@ -0,0 +1,82 @@
+package embeddedpostgres
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testControlDataOutput = `pg_control version number:            1300
+Catalog version number:               202209061
+Database system identifier:           7263190011474542602
+Database cluster state:               in production
+pg_control last modified:             Tue 21 Nov 2023 10:00:00 GMT
+Latest checkpoint location:           0/1539B60
+Latest checkpoint's REDO location:    0/1539B28
+Latest checkpoint's REDO WAL file:    000000010000000000000001
+Latest checkpoint's TimeLineID:       1
+wal_level setting:                    replica
+wal_log_hints setting:                off
+Bytes per WAL segment:                16777216
+Data page checksum version:           1
+`
+
+func Test_parseControlData(t *testing.T) {
+	controlData, err := parseControlData(testControlDataOutput)
+	require.NoError(t, err)
+
+	assert.Equal(t, "7263190011474542602", controlData.SystemIdentifier)
+	assert.Equal(t, "in production", controlData.ClusterState)
+	assert.Equal(t, "0/1539B60", controlData.LatestCheckpointLocation)
+	assert.Equal(t, "0/1539B28", controlData.LatestCheckpointREDOLocation)
+	assert.Equal(t, uint32(1), controlData.LatestCheckpointTimeLineID)
+	assert.Equal(t, "replica", controlData.WALLevel)
+	assert.False(t, controlData.WALLogHints)
+	assert.Equal(t, uint64(16777216), controlData.BytesPerWALSegment)
+	assert.True(t, controlData.DataChecksumsEnabled())
+	assert.Equal(t, "Tue 21 Nov 2023 10:00:00 GMT", controlData.Raw["pg_control last modified"])
+}
+
+func Test_parseControlData_ErrorWhenEmpty(t *testing.T) {
+	_, err := parseControlData("")
+
+	assert.EqualError(t, err, "unable to parse control data: no values found")
+}
+
+func Test_parseControlData_ErrorWhenInvalidNumber(t *testing.T) {
+	_, err := parseControlData("Bytes per WAL segment: lots")
+
+	assert.EqualError(t, err, `unable to parse control data value "lots" for Bytes per WAL segment: strconv.ParseUint: parsing "lots": invalid syntax`)
+}
+
+func Test_ControlData_ErrorWhenNotInitialised(t *testing.T) {
+	database := NewDatabase()
+
+	_, err := database.ControlData()
+
+	assert.EqualError(t, err, "data directory has not been initialised")
+}
+
+func Test_ControlData(t *testing.T) {
+	database := NewDatabase()
+	if err := database.Start(); err != nil {
+		shutdownDBAndFail(t, err, database)
+	}
+
+	controlData, err := database.ControlData()
+	if err != nil {
+		shutdownDBAndFail(t, err, database)
+	}
+
+	assert.Equal(t, "in production", controlData.ClusterState)
+	assert.NotEmpty(t, controlData.SystemIdentifier)
+
+	if err := database.Stop(); err != nil {
+		shutdownDBAndFail(t, err, database)
+	}
+
+	controlData, err = database.ControlData()
+	require.NoError(t, err)
+	assert.Equal(t, "shut down", controlData.ClusterState)
+}
@@ -0,0 +1,203 @@
+package embeddedpostgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// CopyOption customises how EmbeddedPostgres.CopyFrom loads CSV.
+type CopyOption func(*copyOptions)
+
+type copyOptions struct {
+	database  string
+	columns   []string
+	header    bool
+	delimiter rune
+	null      string
+}
+
+// CopyDatabase loads into a table of database rather than the configured one.
+func CopyDatabase(database string) CopyOption {
+	return func(o *copyOptions) {
+		o.database = database
+	}
+}
+
+// CopyColumns names the columns the CSV fields are loaded into, in order. By default they are the columns named by
+// the header when CopyHeader is given, otherwise all columns of the table.
+func CopyColumns(columns ...string) CopyOption {
+	return func(o *copyOptions) {
+		o.columns = columns
+	}
+}
+
+// CopyHeader skips the first record of the CSV, using it to name the columns unless CopyColumns is given.
+func CopyHeader() CopyOption {
+	return func(o *copyOptions) {
+		o.header = true
+	}
+}
+
+// CopyDelimiter separates fields with delimiter rather than a comma.
+func CopyDelimiter(delimiter rune) CopyOption {
+	return func(o *copyOptions) {
+		o.delimiter = delimiter
+	}
+}
+
+// CopyNull loads fields equal to null as NULL, rather than empty fields as Postgres does for CSV.
+func CopyNull(null string) CopyOption {
+	return func(o *copyOptions) {
+		o.null = null
+	}
+}
+
+// CopyFrom bulk loads the CSV records read from r into table, which may be qualified with its schema, using the COPY
+// protocol within a single transaction. It returns the number of rows loaded.
+func (ep *EmbeddedPostgres) CopyFrom(table string, r io.Reader, opts ...CopyOption) (rows int64, err error) {
+	if !ep.isStarted() {
+		return 0, errors.New("server has not been started")
+	}
+
+	ctx := context.Background()
+
+	o := &copyOptions{database: ep.config.database, delimiter: ','}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	reader := csv.NewReader(r)
+	reader.Comma = o.delimiter
+	reader.ReuseRecord = true
+
+	columns := o.columns
+
+	if o.header {
+		header, err := reader.Read()
+		if err != nil {
+			return 0, fmt.Errorf("unable to read CSV header: %w", err)
+		}
+
+		if columns == nil {
+			columns = append([]string(nil), header...)
+		}
+	}
+
+	db, err := ep.openDB(o.database)
+	if err != nil {
+		return 0, err
+	}
+
+	defer func() {
+		err = connectionClose(db, err)
+	}()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("unable to copy into %s: %w", table, err)
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	// resolving the table through regclass follows the search path and quotes its name as needed
+	var qualifiedTable string
+	if err := tx.QueryRowContext(ctx, "SELECT $1::regclass::text", table).Scan(&qualifiedTable); err != nil {
+		return 0, fmt.Errorf("unable to find table %s: %w", table, err)
+	}
+
+	if columns == nil {
+		if columns, err = tableColumns(ctx, tx, qualifiedTable); err != nil {
+			return 0, err
+		}
+	}
+
+	quotedColumns := make([]string, 0, len(columns))
+	for _, column := range columns {
+		quotedColumns = append(quotedColumns, pq.QuoteIdentifier(column))
+	}
+
+	stmt, err := tx.PrepareContext(ctx, fmt.Sprintf("COPY %s (%s) FROM STDIN", qualifiedTable, strings.Join(quotedColumns, ", ")))
+	if err != nil {
+		return 0, fmt.Errorf("unable to copy into %s: %w", table, err)
+	}
+
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			_ = stmt.Close()
+			return 0, fmt.Errorf("unable to read CSV: %w", err)
+		}
+
+		values := make([]interface{}, len(record))
+		for i, field := range record {
+			if field != o.null {
+				values[i] = field
+			}
+		}
+
+		if _, err := stmt.ExecContext(ctx, values...); err != nil {
+			_ = stmt.Close()
+			return 0, fmt.Errorf("unable to copy into %s: %w", table, err)
+		}
+
+		rows++
+	}
+
+	// the rows are only sent and checked once the statement is executed without values
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		_ = stmt.Close()
+		return 0, fmt.Errorf("unable to copy into %s: %w", table, err)
+	}
+
+	if err := stmt.Close(); err != nil {
+		return 0, fmt.Errorf("unable to copy into %s: %w", table, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("unable to copy into %s: %w", table, err)
+	}
+
+	return rows, nil
+}
+
+// tableColumns returns the columns of table in order.
+func tableColumns(ctx context.Context, tx *sql.Tx, table string) (columns []string, err error) {
+	rows, err := tx.QueryContext(ctx,
+		"SELECT attname FROM pg_attribute WHERE attrelid = $1::regclass AND attnum > 0 AND NOT attisdropped ORDER BY attnum",
+		table)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list columns of %s: %w", table, err)
+	}
+
+	defer func() {
+		if closeErr := rows.Close(); err == nil && closeErr != nil {
+			err = fmt.Errorf("unable to list columns of %s: %w", table, closeErr)
+		}
+	}()
+
+	for rows.Next() {
+		var column string
+		if err := rows.Scan(&column); err != nil {
+			return nil, fmt.Errorf("unable to list columns of %s: %w", table, err)
+		}
+
+		columns = append(columns, column)
+	}
+
+	return columns, rows.Err()
+}
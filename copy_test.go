@@ -0,0 +1,52 @@
+package embeddedpostgres
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_CopyFrom_ErrorWhenNotStarted(t *testing.T) {
+	_, err := NewDatabase().CopyFrom("brews", strings.NewReader("stout\n"))
+
+	assert.EqualError(t, err, "server has not been started")
+}
+
+func Test_CopyFrom(t *testing.T) {
+	database := NewDatabase(DefaultConfig().
+		RuntimePath(t.TempDir()).
+		Port(0).
+		InitSQL("CREATE SCHEMA cellar", "CREATE TABLE cellar.brews (name text, abv numeric, notes text)"))
+	require.NoError(t, database.Start())
+
+	defer func() {
+		assert.NoError(t, database.Stop())
+	}()
+
+	rows, err := database.CopyFrom("cellar.brews", strings.NewReader("notes,name\nroasty,stout\n,lager\n"), CopyHeader())
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), rows)
+
+	rows, err = database.CopyFrom("cellar.brews", strings.NewReader("porter;5.2;NULL\n"), CopyDelimiter(';'), CopyNull("NULL"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), rows)
+
+	db, err := database.Open("postgres")
+	require.NoError(t, err)
+
+	var summary string
+	require.NoError(t, db.QueryRow(`SELECT string_agg(name || ':' || coalesce(abv::text, '-') || ':' || coalesce(notes, '-'), ' ' ORDER BY name)
+		FROM cellar.brews`).Scan(&summary))
+	assert.Equal(t, "lager:-:- porter:5.2:- stout:-:roasty", summary)
+
+	// a failing row rolls back the whole load
+	_, err = database.CopyFrom("cellar.brews", strings.NewReader("ipa,6.5,\nmild,weak,\n"))
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "unable to copy into cellar.brews")
+
+	var count int
+	require.NoError(t, db.QueryRow("SELECT count(*) FROM cellar.brews").Scan(&count))
+	assert.Equal(t, 3, count)
+}
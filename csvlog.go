@@ -0,0 +1,197 @@
+package embeddedpostgres
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	csvLogConfSnippet   = "00-csvlog"
+	csvLogPollInterval  = 100 * time.Millisecond
+	csvLogTimeLayout    = "2006-01-02 15:04:05.000 MST"
+	csvLogFileName      = "postgresql.csv"
+	csvLogMinimumFields = 23
+)
+
+// LogEntry is a row of the server log in CSV format, as passed to the handler given with Config.CSVLog.
+type LogEntry struct {
+	Time            time.Time
+	User            string
+	Database        string
+	PID             int
+	Severity        string
+	SQLState        string
+	Message         string
+	Detail          string
+	Hint            string
+	Context         string
+	Query           string
+	ApplicationName string
+}
+
+// csvLogTail reads the rows appended to the CSV log since it last read it.
+type csvLogTail struct {
+	mu     sync.Mutex
+	offset int64
+}
+
+// ParseCSVLog parses the rows of a server log written with log_destination = 'csvlog'. The columns are those common
+// to all supported Postgres versions, so any added by later versions, such as backend_type, are ignored.
+func ParseCSVLog(r io.Reader) ([]LogEntry, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	var entries []LogEntry
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return entries, nil
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse csv log: %w", err)
+		}
+
+		entry, err := parseCSVLogRecord(record)
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, entry)
+	}
+}
+
+func parseCSVLogRecord(record []string) (LogEntry, error) {
+	if len(record) < csvLogMinimumFields {
+		return LogEntry{}, fmt.Errorf("unable to parse csv log: expected at least %d fields but got %d", csvLogMinimumFields, len(record))
+	}
+
+	logTime, err := time.Parse(csvLogTimeLayout, record[0])
+	if err != nil {
+		return LogEntry{}, fmt.Errorf("unable to parse csv log time %q: %w", record[0], err)
+	}
+
+	pid, err := strconv.Atoi(record[3])
+	if err != nil {
+		return LogEntry{}, fmt.Errorf("unable to parse csv log process id %q: %w", record[3], err)
+	}
+
+	return LogEntry{
+		Time:            logTime,
+		User:            record[1],
+		Database:        record[2],
+		PID:             pid,
+		Severity:        record[11],
+		SQLState:        record[12],
+		Message:         record[13],
+		Detail:          record[14],
+		Hint:            record[15],
+		Context:         record[18],
+		Query:           record[19],
+		ApplicationName: record[22],
+	}, nil
+}
+
+func csvLogDirectory(config Config) string {
+	return filepath.Join(config.runtimePath, "log")
+}
+
+func csvLogSettings(config Config) map[string]string {
+	if config.csvLogHandler == nil {
+		return nil
+	}
+
+	return map[string]string{
+		"log_destination":   "csvlog",
+		"logging_collector": "on",
+		"log_directory":     filepath.ToSlash(csvLogDirectory(config)),
+		"log_filename":      "postgresql.log",
+		"log_timezone":      "UTC",
+		"log_rotation_age":  "0",
+		"log_rotation_size": "0",
+	}
+}
+
+// watchCSVLog passes the rows of the CSV log to the configured handler as the server writes them.
+func (ep *instanceState) watchCSVLog() {
+	if ep.config.csvLogHandler == nil {
+		return
+	}
+
+	ep.watch(csvLogPollInterval, func(now time.Time) bool {
+		ep.syncCSVLog()
+		return false
+	})
+}
+
+// syncCSVLog passes the complete rows written to the CSV log since it was last read to the configured handler, and
+// copies them to the postgres log in the format the server would otherwise have written, so that Logger and
+// statement capture see them as usual.
+func (ep *instanceState) syncCSVLog() {
+	if ep.config.csvLogHandler == nil {
+		return
+	}
+
+	ep.csvLog.mu.Lock()
+	defer ep.csvLog.mu.Unlock()
+
+	file, err := os.Open(filepath.Join(csvLogDirectory(ep.config), csvLogFileName))
+	if err != nil {
+		// the logging collector creates the file once it starts
+		return
+	}
+
+	defer func() {
+		_ = file.Close()
+	}()
+
+	if _, err := file.Seek(ep.csvLog.offset, io.SeekStart); err != nil {
+		return
+	}
+
+	content, err := io.ReadAll(file)
+	if err != nil || len(content) == 0 || content[len(content)-1] != '\n' {
+		// a row is still being written
+		return
+	}
+
+	entries, err := ParseCSVLog(bytes.NewReader(content))
+	if err != nil {
+		// a row is still being written within a quoted field
+		return
+	}
+
+	ep.csvLog.offset += int64(len(content))
+
+	for _, entry := range entries {
+		_, _ = ep.syncedLogger.file.WriteString(formatLogEntry(entry))
+		ep.config.csvLogHandler(entry)
+	}
+}
+
+// formatLogEntry formats entry as the server logs to stderr, with any further lines of the message indented by a tab.
+func formatLogEntry(entry LogEntry) string {
+	buf := &strings.Builder{}
+	fmt.Fprintf(buf, "%s [%d] %s:  %s\n", entry.Time.Format(csvLogTimeLayout), entry.PID, entry.Severity,
+		strings.ReplaceAll(entry.Message, "\n", "\n\t"))
+
+	if entry.Detail != "" {
+		fmt.Fprintf(buf, "%s [%d] DETAIL:  %s\n", entry.Time.Format(csvLogTimeLayout), entry.PID, entry.Detail)
+	}
+
+	if entry.Query != "" {
+		fmt.Fprintf(buf, "%s [%d] STATEMENT:  %s\n", entry.Time.Format(csvLogTimeLayout), entry.PID,
+			strings.ReplaceAll(entry.Query, "\n", "\n\t"))
+	}
+
+	return buf.String()
+}
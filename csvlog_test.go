@@ -0,0 +1,140 @@
+package embeddedpostgres
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const csvLogRows = `2022-03-01 12:00:00.123 UTC,"postgres","beer",42,"[local]",621e0a3c.2a,1,"SELECT",2022-03-01 12:00:00 UTC,3/2,0,ERROR,42P01,"relation ""brews"" does not exist",,,,,,"SELECT *
+FROM brews",15,,"psql","client backend",,0
+2022-03-01 12:00:01.000 UTC,,,7,,621e0a3c.7,1,,2022-03-01 12:00:00 UTC,,0,LOG,00000,"checkpoint starting: shutdown immediate",,,,,,,,,"","checkpointer",,0
+`
+
+func Test_ParseCSVLog(t *testing.T) {
+	entries, err := ParseCSVLog(strings.NewReader(csvLogRows))
+	require.NoError(t, err)
+
+	assert.Equal(t, []LogEntry{
+		{
+			Time:            time.Date(2022, 3, 1, 12, 0, 0, 123000000, time.UTC),
+			User:            "postgres",
+			Database:        "beer",
+			PID:             42,
+			Severity:        "ERROR",
+			SQLState:        "42P01",
+			Message:         `relation "brews" does not exist`,
+			Query:           "SELECT *\nFROM brews",
+			ApplicationName: "psql",
+		},
+		{
+			Time:     time.Date(2022, 3, 1, 12, 0, 1, 0, time.UTC),
+			PID:      7,
+			Severity: "LOG",
+			SQLState: "00000",
+			Message:  "checkpoint starting: shutdown immediate",
+		},
+	}, entries)
+}
+
+func Test_ParseCSVLog_Errors(t *testing.T) {
+	_, err := ParseCSVLog(strings.NewReader("2022-03-01 12:00:00.123 UTC,postgres\n"))
+	assert.EqualError(t, err, "unable to parse csv log: expected at least 23 fields but got 2")
+
+	_, err = ParseCSVLog(strings.NewReader("yesterday" + strings.Repeat(",", 22) + "\n"))
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), `unable to parse csv log time "yesterday"`)
+}
+
+func Test_formatLogEntry(t *testing.T) {
+	entries, err := ParseCSVLog(strings.NewReader(csvLogRows))
+	require.NoError(t, err)
+
+	assert.Equal(t, "2022-03-01 12:00:00.123 UTC [42] ERROR:  relation \"brews\" does not exist\n"+
+		"2022-03-01 12:00:00.123 UTC [42] STATEMENT:  SELECT *\n\tFROM brews\n", formatLogEntry(entries[0]))
+}
+
+func Test_syncCSVLog(t *testing.T) {
+	logger, err := newSyncedLogger(t.TempDir(), io.Discard)
+	require.NoError(t, err)
+
+	var handled []LogEntry
+
+	database := NewDatabase(DefaultConfig().
+		RuntimePath(t.TempDir()).
+		CSVLog(func(entry LogEntry) {
+			handled = append(handled, entry)
+		}))
+	database.syncedLogger = logger
+
+	// nothing is read before the logging collector has created the file
+	database.syncCSVLog()
+
+	csvPath := filepath.Join(csvLogDirectory(database.config), csvLogFileName)
+	require.NoError(t, os.MkdirAll(filepath.Dir(csvPath), 0700))
+
+	rows := strings.SplitAfter(csvLogRows, "psql\",\"client backend\",,0\n")
+	require.NoError(t, os.WriteFile(csvPath, []byte(rows[0][:40]), 0600))
+
+	// a partly written row is left until it is complete
+	database.syncCSVLog()
+	assert.Empty(t, handled)
+
+	require.NoError(t, os.WriteFile(csvPath, []byte(csvLogRows), 0600))
+
+	database.syncCSVLog()
+	database.syncCSVLog()
+
+	require.Len(t, handled, 2)
+	assert.Equal(t, "checkpoint starting: shutdown immediate", handled[1].Message)
+
+	logContent, err := os.ReadFile(logger.file.Name())
+	require.NoError(t, err)
+	assert.Contains(t, string(logContent), "[7] LOG:  checkpoint starting: shutdown immediate\n")
+}
+
+func Test_CSVLog(t *testing.T) {
+	entries := make(chan LogEntry, 100)
+
+	database := NewDatabase(DefaultConfig().
+		RuntimePath(t.TempDir()).
+		Port(0).
+		CaptureStatements(true).
+		CSVLog(func(entry LogEntry) {
+			entries <- entry
+		}))
+	require.NoError(t, database.Start())
+
+	db, err := database.Open("postgres")
+	require.NoError(t, err)
+
+	_, err = db.Exec("SELECT * FROM brews")
+	require.NotNil(t, err)
+
+	statements, err := database.CapturedStatements()
+	require.NoError(t, err)
+	assert.Contains(t, statements, "SELECT * FROM brews")
+
+	require.NoError(t, database.Stop())
+	close(entries)
+
+	var failed *LogEntry
+
+	for entry := range entries {
+		if entry.Severity == "ERROR" {
+			entry := entry
+			failed = &entry
+		}
+	}
+
+	require.NotNil(t, failed)
+	assert.Equal(t, "42P01", failed.SQLState)
+	assert.Equal(t, `relation "brews" does not exist`, failed.Message)
+	assert.Equal(t, "SELECT * FROM brews", failed.Query)
+}
@@ -0,0 +1,326 @@
+package embeddedpostgres
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// walDirName returns the name of the directory the write-ahead log is held in within the data directory, and within a
+// data archive, unless kept elsewhere with Config.WALPath.
+func walDirName(version PostgresVersion) string {
+	// pg_xlog was renamed to pg_wal in Postgres 10
+	if majorVersion(version) < 10 {
+		return "pg_xlog"
+	}
+
+	return "pg_wal"
+}
+
+// ExportDataArchive writes the data directory, along with the write-ahead log when kept elsewhere with Config.WALPath,
+// to a gzipped tar archive at path, which Config.DataArchive starts servers from. A running server is stopped whilst it
+// is archived and started again afterwards.
+func (ep *EmbeddedPostgres) ExportDataArchive(path string) (err error) {
+	if ep.syncedLogger == nil || !dataDirIsValid(ep.config.dataPath, ep.config.version) {
+		return errors.New("data directory has not been initialised, call Start first")
+	}
+
+	wasStarted := ep.isStarted()
+	if wasStarted {
+		if err := ep.Stop(); err != nil {
+			return err
+		}
+	}
+
+	if err := writeDataArchive(path, ep.config.dataPath, ep.config.walPath, walDirName(ep.config.version)); err != nil {
+		return fmt.Errorf("unable to export data archive %s: %w", path, err)
+	}
+
+	if wasStarted {
+		return ep.Restart()
+	}
+
+	return nil
+}
+
+func writeDataArchive(archivePath, dataPath, walPath, walDir string) (err error) {
+	file, err := os.Create(archivePath)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	compressed := gzip.NewWriter(file)
+	archive := tar.NewWriter(compressed)
+
+	skip := ""
+	if walPath != "" {
+		// the data directory links to the WAL directory, which is archived in its place
+		skip = walDir
+	}
+
+	if err := addToDataArchive(archive, dataPath, "", skip); err != nil {
+		return err
+	}
+
+	if walPath != "" {
+		if err := addToDataArchive(archive, walPath, walDir, ""); err != nil {
+			return err
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return err
+	}
+
+	return compressed.Close()
+}
+
+// addToDataArchive adds the directory tree at dir to the archive under prefix, leaving out the entry skip.
+func addToDataArchive(archive *tar.Writer, dir, prefix, skip string) error {
+	return filepath.WalkDir(dir, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relative, err := filepath.Rel(dir, file)
+		if err != nil {
+			return err
+		}
+
+		name := path.Join(prefix, filepath.ToSlash(relative))
+		if name == "." {
+			return nil
+		}
+
+		if name == skip {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		link := ""
+		if info.Mode()&fs.ModeSymlink != 0 {
+			if link, err = os.Readlink(file); err != nil {
+				return err
+			}
+		}
+
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+
+		header.Name = name
+		if entry.IsDir() {
+			header.Name += "/"
+		}
+
+		if err := archive.WriteHeader(header); err != nil {
+			return err
+		}
+
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		content, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+
+		defer func() {
+			_ = content.Close()
+		}()
+
+		_, err = io.Copy(archive, content)
+
+		return err
+	})
+}
+
+// restoreDataArchive replaces the data directory, and the WAL directory when configured, with the contents of the
+// archive configured with Config.DataArchive.
+func (ep *EmbeddedPostgres) restoreDataArchive() error {
+	if err := os.RemoveAll(ep.config.dataPath); err != nil {
+		return fmt.Errorf("unable to clean up data directory %s with error: %s", ep.config.dataPath, err)
+	}
+
+	if ep.config.walPath != "" {
+		if err := os.RemoveAll(ep.config.walPath); err != nil {
+			return fmt.Errorf("unable to clean up WAL directory %s with error: %s", ep.config.walPath, err)
+		}
+	}
+
+	if err := extractDataArchive(ep.config.dataArchive, ep.config.dataPath, ep.config.walPath, walDirName(ep.config.version)); err != nil {
+		return fmt.Errorf("unable to restore data archive %s to %s: %w", ep.config.dataArchive, ep.config.dataPath, err)
+	}
+
+	if !dataDirIsValid(ep.config.dataPath, ep.config.version) {
+		return fmt.Errorf("data archive %s does not hold a data directory for Postgres %s", ep.config.dataArchive, ep.config.version)
+	}
+
+	return nil
+}
+
+func extractDataArchive(archivePath, dataPath, walPath, walDir string) error {
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = file.Close()
+	}()
+
+	compressed, err := gzip.NewReader(file)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dataPath, 0700); err != nil {
+		return err
+	}
+
+	if walPath != "" {
+		if err := os.MkdirAll(walPath, 0700); err != nil {
+			return err
+		}
+	}
+
+	archive := tar.NewReader(compressed)
+
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		name := path.Clean(header.Name)
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return fmt.Errorf("archive entry %s is outside of the data directory", header.Name)
+		}
+
+		base, target := dataPath, filepath.Join(dataPath, filepath.FromSlash(name))
+		if walPath != "" && (name == walDir || strings.HasPrefix(name, walDir+"/")) {
+			base, target = walPath, filepath.Join(walPath, filepath.FromSlash(strings.TrimPrefix(name, walDir)))
+		}
+
+		// a symlink is only created in place, whilst anything else would be written to wherever target links to
+		through := target
+		if header.Typeflag == tar.TypeSymlink {
+			through = filepath.Dir(target)
+		}
+
+		if err := checkNoSymlinks(base, through); err != nil {
+			return fmt.Errorf("archive entry %s is written through a symlink: %w", header.Name, err)
+		}
+
+		if err := extractDataArchiveEntry(archive, header, target); err != nil {
+			return err
+		}
+	}
+
+	if walPath != "" {
+		return os.Symlink(walPath, filepath.Join(dataPath, walDir))
+	}
+
+	return nil
+}
+
+// checkNoSymlinks reports an error when target, or any directory between base and target, is a symlink, such as one
+// created by an earlier entry of the archive, which writing to target would follow out of base.
+func checkNoSymlinks(base, target string) error {
+	relative, err := filepath.Rel(base, target)
+	if err != nil || relative == "." {
+		return err
+	}
+
+	current := base
+	for _, part := range strings.Split(relative, string(filepath.Separator)) {
+		current = filepath.Join(current, part)
+
+		info, err := os.Lstat(current)
+		if os.IsNotExist(err) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if info.Mode()&fs.ModeSymlink != 0 {
+			return fmt.Errorf("%s is a symlink", current)
+		}
+	}
+
+	return nil
+}
+
+func extractDataArchiveEntry(archive io.Reader, header *tar.Header, target string) error {
+	mode := os.FileMode(header.Mode).Perm()
+
+	switch header.Typeflag {
+	case tar.TypeDir:
+		if err := os.MkdirAll(target, mode); err != nil {
+			return err
+		}
+
+		// the data directory may have been created with other permissions
+		return os.Chmod(target, mode)
+	case tar.TypeSymlink:
+		if err := os.MkdirAll(filepath.Dir(target), 0700); err != nil {
+			return err
+		}
+
+		return os.Symlink(header.Linkname, target)
+	case tar.TypeReg:
+		if err := os.MkdirAll(filepath.Dir(target), 0700); err != nil {
+			return err
+		}
+
+		return copyToFile(archive, target, mode)
+	default:
+		return nil
+	}
+}
+
+func copyToFile(content io.Reader, target string, mode os.FileMode) (err error) {
+	out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if closeErr := out.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	_, err = io.Copy(out, content)
+
+	return err
+}
@@ -0,0 +1,160 @@
+package embeddedpostgres
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_walDirName(t *testing.T) {
+	assert.Equal(t, "pg_xlog", walDirName(V9))
+	assert.Equal(t, "pg_wal", walDirName(V10))
+	assert.Equal(t, "pg_wal", walDirName(V15))
+}
+
+func Test_DataArchive_RoundTrip(t *testing.T) {
+	dataPath := filepath.Join(t.TempDir(), "data")
+	require.NoError(t, os.MkdirAll(filepath.Join(dataPath, "base", "1"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(dataPath, "PG_VERSION"), []byte("15\n"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dataPath, "base", "1", "1234"), []byte("beer"), 0600))
+	require.NoError(t, os.Symlink("base", filepath.Join(dataPath, "link")))
+
+	walPath := filepath.Join(t.TempDir(), "wal")
+	require.NoError(t, os.MkdirAll(walPath, 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(walPath, "000000010000000000000001"), []byte("wal"), 0600))
+	require.NoError(t, os.Symlink(walPath, filepath.Join(dataPath, "pg_wal")))
+
+	archive := filepath.Join(t.TempDir(), "data.tar.gz")
+	require.NoError(t, writeDataArchive(archive, dataPath, walPath, "pg_wal"))
+
+	t.Run("without WAL directory", func(t *testing.T) {
+		restored := filepath.Join(t.TempDir(), "data")
+		require.NoError(t, extractDataArchive(archive, restored, "", "pg_wal"))
+
+		assert.True(t, dataDirIsValid(restored, V15))
+		assertFileContent(t, filepath.Join(restored, "base", "1", "1234"), "beer")
+		assertFileContent(t, filepath.Join(restored, "pg_wal", "000000010000000000000001"), "wal")
+
+		link, err := os.Readlink(filepath.Join(restored, "link"))
+		require.NoError(t, err)
+		assert.Equal(t, "base", link)
+
+		info, err := os.Stat(filepath.Join(restored, "base"))
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0700), info.Mode().Perm())
+	})
+
+	t.Run("with WAL directory", func(t *testing.T) {
+		restored := filepath.Join(t.TempDir(), "data")
+		restoredWAL := filepath.Join(t.TempDir(), "wal")
+		require.NoError(t, extractDataArchive(archive, restored, restoredWAL, "pg_wal"))
+
+		assertFileContent(t, filepath.Join(restoredWAL, "000000010000000000000001"), "wal")
+
+		link, err := os.Readlink(filepath.Join(restored, "pg_wal"))
+		require.NoError(t, err)
+		assert.Equal(t, restoredWAL, link)
+	})
+}
+
+func Test_extractDataArchive_OutsideDataDirectory(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "data.tar.gz")
+
+	file, err := os.Create(archive)
+	require.NoError(t, err)
+
+	compressed := gzip.NewWriter(file)
+	writer := tar.NewWriter(compressed)
+	require.NoError(t, writer.WriteHeader(&tar.Header{Name: "../escaped", Mode: 0600, Typeflag: tar.TypeReg}))
+	require.NoError(t, writer.Close())
+	require.NoError(t, compressed.Close())
+	require.NoError(t, file.Close())
+
+	dir := t.TempDir()
+	err = extractDataArchive(archive, filepath.Join(dir, "data"), "", "pg_wal")
+
+	assert.EqualError(t, err, "archive entry ../escaped is outside of the data directory")
+	assert.NoFileExists(t, filepath.Join(dir, "escaped"))
+}
+
+func Test_ExportDataArchive_NotInitialised(t *testing.T) {
+	database := NewDatabase(DefaultConfig().DataPath(t.TempDir()))
+
+	assert.EqualError(t, database.ExportDataArchive(filepath.Join(t.TempDir(), "data.tar.gz")), "data directory has not been initialised, call Start first")
+}
+
+func Test_DataArchive(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "data.tar.gz")
+
+	database := StartForTest(t)
+
+	db, err := database.Open("postgres")
+	require.NoError(t, err)
+
+	_, err = db.Exec("CREATE TABLE beer (name text); INSERT INTO beer VALUES ('stout')")
+	require.NoError(t, err)
+
+	require.NoError(t, database.ExportDataArchive(archive))
+	assert.True(t, database.isStarted())
+
+	restored := StartForTest(t, DefaultConfig().DataArchive(archive))
+
+	db, err = restored.Open("postgres")
+	require.NoError(t, err)
+
+	var name string
+	require.NoError(t, db.QueryRow("SELECT name FROM beer").Scan(&name))
+	assert.Equal(t, "stout", name)
+}
+
+func assertFileContent(t *testing.T, path, expected string) {
+	t.Helper()
+
+	content, err := os.ReadFile(path)
+	if assert.NoError(t, err) {
+		assert.Equal(t, expected, string(content))
+	}
+}
+
+func Test_extractDataArchive_ThroughSymlink(t *testing.T) {
+	outside := t.TempDir()
+	archive := filepath.Join(t.TempDir(), "data.tar.gz")
+
+	file, err := os.Create(archive)
+	require.NoError(t, err)
+
+	compressed := gzip.NewWriter(file)
+	writer := tar.NewWriter(compressed)
+	require.NoError(t, writer.WriteHeader(&tar.Header{Name: "x", Linkname: outside, Mode: 0777, Typeflag: tar.TypeSymlink}))
+	require.NoError(t, writer.WriteHeader(&tar.Header{Name: "x/passwd", Mode: 0600, Size: 5, Typeflag: tar.TypeReg}))
+	_, err = writer.Write([]byte("pwned"))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+	require.NoError(t, compressed.Close())
+	require.NoError(t, file.Close())
+
+	dataPath := filepath.Join(t.TempDir(), "data")
+	err = extractDataArchive(archive, dataPath, "", "pg_wal")
+
+	assert.EqualError(t, err, "archive entry x/passwd is written through a symlink: "+filepath.Join(dataPath, "x")+" is a symlink")
+	assert.NoFileExists(t, filepath.Join(outside, "passwd"))
+}
+
+func Test_checkNoSymlinks(t *testing.T) {
+	base := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(base, "pg_tblspc"), 0700))
+	require.NoError(t, os.Symlink(t.TempDir(), filepath.Join(base, "pg_tblspc", "16384")))
+
+	assert.NoError(t, checkNoSymlinks(base, base))
+	assert.NoError(t, checkNoSymlinks(base, filepath.Join(base, "pg_tblspc", "16385")))
+	assert.NoError(t, checkNoSymlinks(base, filepath.Join(base, "base", "1", "112")))
+	assert.EqualError(t, checkNoSymlinks(base, filepath.Join(base, "pg_tblspc", "16384", "PG_15")),
+		filepath.Join(base, "pg_tblspc", "16384")+" is a symlink")
+	assert.EqualError(t, checkNoSymlinks(base, filepath.Join(base, "pg_tblspc", "16384")),
+		filepath.Join(base, "pg_tblspc", "16384")+" is a symlink")
+}
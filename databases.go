@@ -0,0 +1,132 @@
+package embeddedpostgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	objectInUseErrorCode = pq.ErrorCode("55006")
+	templateRetries      = 5
+)
+
+// DatabaseSpec describes a database created on start in addition to the configured one. The Owner defaults to the
+// configured user and the Encoding to that of the cluster.
+type DatabaseSpec struct {
+	Name     string
+	Owner    string
+	Encoding string
+}
+
+// createDatabaseStatement returns the CREATE DATABASE statement for spec.
+func createDatabaseStatement(spec DatabaseSpec) string {
+	statement := "CREATE DATABASE " + pq.QuoteIdentifier(spec.Name)
+
+	if spec.Owner != "" {
+		statement += " OWNER " + pq.QuoteIdentifier(spec.Owner)
+	}
+
+	if spec.Encoding != "" {
+		// template1 may have a different encoding, which is only allowed when copying template0
+		statement += " TEMPLATE template0 ENCODING " + pq.QuoteLiteral(spec.Encoding)
+	}
+
+	return statement
+}
+
+// createDatabases creates the databases configured with Config.Databases that do not already exist.
+func (ep *EmbeddedPostgres) createDatabases(ctx context.Context) (err error) {
+	if len(ep.config.databases) == 0 {
+		return nil
+	}
+
+	db, err := ep.openDB("postgres")
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		err = connectionClose(db, err)
+	}()
+
+	for _, spec := range ep.config.databases {
+		var exists bool
+		if err := db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM pg_database WHERE datname = $1)", spec.Name).Scan(&exists); err != nil {
+			return fmt.Errorf("unable to check whether database %s exists: %w", spec.Name, err)
+		}
+
+		if exists {
+			continue
+		}
+
+		if _, err := db.ExecContext(ctx, createDatabaseStatement(spec)); err != nil {
+			return fmt.Errorf("unable to create database %s: %w", spec.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// CloneDatabase creates the database dst as a copy of src using CREATE DATABASE ... TEMPLATE.
+// Connections to src are terminated first, as Postgres refuses to copy a database that is in use.
+func (ep *EmbeddedPostgres) CloneDatabase(src, dst string) (err error) {
+	if !ep.started {
+		return errors.New("server has not been started")
+	}
+
+	db, err := ep.openDB("postgres")
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		err = connectionClose(db, err)
+	}()
+
+	return createDatabaseFromTemplate(context.Background(), db, src, dst)
+}
+
+// createDatabaseFromTemplate creates dst from the template src, terminating connections to src and retrying whilst
+// clients reconnect to it.
+func createDatabaseFromTemplate(ctx context.Context, db *sql.DB, src, dst string) error {
+	statement := fmt.Sprintf("CREATE DATABASE %s TEMPLATE %s", pq.QuoteIdentifier(dst), pq.QuoteIdentifier(src))
+
+	var err error
+
+	for attempt := 0; attempt < templateRetries; attempt++ {
+		if err = terminateConnections(ctx, db, src); err != nil {
+			return err
+		}
+
+		_, err = db.ExecContext(ctx, statement)
+
+		var pqErr *pq.Error
+		if !errors.As(err, &pqErr) || pqErr.Code != objectInUseErrorCode {
+			break
+		}
+
+		time.Sleep(time.Duration(attempt+1) * 50 * time.Millisecond)
+	}
+
+	if err != nil {
+		return fmt.Errorf("unable to create database %s from %s: %w", dst, src, err)
+	}
+
+	return nil
+}
+
+// terminateConnections terminates all other connections to the database.
+func terminateConnections(ctx context.Context, db *sql.DB, database string) error {
+	if _, err := db.ExecContext(ctx,
+		"SELECT pg_terminate_backend(pid) FROM pg_stat_activity WHERE datname = $1 AND pid <> pg_backend_pid()",
+		database); err != nil {
+		return fmt.Errorf("unable to terminate connections to %s: %w", database, err)
+	}
+
+	return nil
+}
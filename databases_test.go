@@ -0,0 +1,102 @@
+package embeddedpostgres
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_CloneDatabase_ErrorWhenNotStarted(t *testing.T) {
+	database := NewDatabase()
+
+	assert.EqualError(t, database.CloneDatabase("seeded", "scratch"), "server has not been started")
+}
+
+func Test_CloneDatabase(t *testing.T) {
+	database := NewDatabase(DefaultConfig().Database("seeded"))
+	if err := database.Start(); err != nil {
+		shutdownDBAndFail(t, err, database)
+	}
+
+	seeded, err := sql.Open("postgres", "host=localhost port=5432 user=postgres password=postgres dbname=seeded sslmode=disable")
+	if err != nil {
+		shutdownDBAndFail(t, err, database)
+	}
+
+	if _, err := seeded.Exec("CREATE TABLE beers(name text); INSERT INTO beers VALUES ('stout')"); err != nil {
+		shutdownDBAndFail(t, err, database)
+	}
+
+	// the open connection to seeded is terminated by the clone
+	if err := database.CloneDatabase("seeded", "scratch"); err != nil {
+		shutdownDBAndFail(t, err, database)
+	}
+
+	_ = seeded.Close()
+
+	scratch, err := sql.Open("postgres", "host=localhost port=5432 user=postgres password=postgres dbname=scratch sslmode=disable")
+	if err != nil {
+		shutdownDBAndFail(t, err, database)
+	}
+
+	var name string
+	if err := scratch.QueryRow("SELECT name FROM beers").Scan(&name); err != nil {
+		shutdownDBAndFail(t, err, database)
+	}
+
+	assert.Equal(t, "stout", name)
+
+	if err := scratch.Close(); err != nil {
+		shutdownDBAndFail(t, err, database)
+	}
+
+	if err := database.Stop(); err != nil {
+		shutdownDBAndFail(t, err, database)
+	}
+}
+
+func Test_createDatabaseStatement(t *testing.T) {
+	assert.Equal(t, `CREATE DATABASE "app"`, createDatabaseStatement(DatabaseSpec{Name: "app"}))
+	assert.Equal(t, `CREATE DATABASE "audit" OWNER "auditor" TEMPLATE template0 ENCODING 'LATIN1'`,
+		createDatabaseStatement(DatabaseSpec{Name: "audit", Owner: "auditor", Encoding: "LATIN1"}))
+}
+
+func Test_Databases(t *testing.T) {
+	config := DefaultConfig().Databases("app", "audit")
+	assert.Equal(t, []DatabaseSpec{{Name: "app"}, {Name: "audit"}}, config.databases)
+
+	specs := []DatabaseSpec{{Name: "queue", Encoding: "SQL_ASCII"}}
+	config = config.DatabaseSpecs(specs...)
+	specs[0].Name = "changed"
+	assert.Equal(t, []DatabaseSpec{{Name: "queue", Encoding: "SQL_ASCII"}}, config.databases)
+}
+
+func Test_Databases_Created(t *testing.T) {
+	database := NewDatabase(DefaultConfig().
+		RuntimePath(t.TempDir()).
+		Port(0).
+		Locale("C").
+		Database("app").
+		DatabaseSpecs(
+			DatabaseSpec{Name: "app"},
+			DatabaseSpec{Name: "audit"},
+			DatabaseSpec{Name: "queue", Encoding: "LATIN1"}))
+	require.NoError(t, database.Start())
+
+	defer func() {
+		assert.NoError(t, database.Stop())
+	}()
+
+	db, err := database.Open("postgres")
+	require.NoError(t, err)
+
+	var encoding string
+	require.NoError(t, db.QueryRow("SELECT pg_encoding_to_char(encoding) FROM pg_database WHERE datname = 'queue'").Scan(&encoding))
+	assert.Equal(t, "LATIN1", encoding)
+
+	var count int
+	require.NoError(t, db.QueryRow("SELECT count(*) FROM pg_database WHERE datname IN ('app', 'audit')").Scan(&count))
+	assert.Equal(t, 2, count)
+}
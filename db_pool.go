@@ -0,0 +1,158 @@
+package embeddedpostgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// defaultDBPoolSize is how many databases a DBPool hands out at once unless PoolMaxDatabases says otherwise.
+const defaultDBPoolSize = 10
+
+// dbPoolSequence numbers the databases created by every DBPool in the process, so that pools sharing an instance do
+// not collide.
+var dbPoolSequence uint64
+
+// DBPool hands out databases of their own to tests running in parallel against a single instance, creating each one
+// afresh, optionally from a template, and dropping it once released. At most a fixed number of databases are handed
+// out at once, with any further callers waiting for one to be released, so that parallel tests do not exhaust
+// max_connections.
+type DBPool struct {
+	ep       *EmbeddedPostgres
+	template string
+	slots    chan struct{}
+
+	mu       sync.Mutex
+	acquired map[string]struct{}
+}
+
+// DBPoolOption configures a DBPool.
+type DBPoolOption func(*DBPool)
+
+// PoolTemplate creates each database as a copy of the template database, such as one holding the migrated schema,
+// rather than an empty one.
+func PoolTemplate(database string) DBPoolOption {
+	return func(p *DBPool) {
+		p.template = database
+	}
+}
+
+// PoolMaxDatabases bounds how many databases are handed out at once, defaulting to 10.
+func PoolMaxDatabases(max int) DBPoolOption {
+	return func(p *DBPool) {
+		if max > 0 {
+			p.slots = make(chan struct{}, max)
+		}
+	}
+}
+
+// NewDBPool returns a DBPool creating databases on the running instance.
+func NewDBPool(ep *EmbeddedPostgres, opts ...DBPoolOption) (*DBPool, error) {
+	if !ep.isStarted() {
+		return nil, errors.New("server has not been started")
+	}
+
+	p := &DBPool{
+		ep:       ep,
+		slots:    make(chan struct{}, defaultDBPoolSize),
+		acquired: map[string]struct{}{},
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p, nil
+}
+
+// Acquire creates a database, waiting whilst the pool has handed out as many as it may, and returns its name. The
+// database is dropped by Release.
+func (p *DBPool) Acquire(ctx context.Context) (name string, err error) {
+	select {
+	case p.slots <- struct{}{}:
+	case <-ctx.Done():
+		return "", fmt.Errorf("unable to acquire database: %w", ctx.Err())
+	}
+
+	defer func() {
+		if err != nil {
+			<-p.slots
+		}
+	}()
+
+	name = fmt.Sprintf("pool_%d_%d", os.Getpid(), atomic.AddUint64(&dbPoolSequence, 1))
+
+	db, err := p.ep.openDB("postgres")
+	if err != nil {
+		return "", err
+	}
+
+	defer func() {
+		err = connectionClose(db, err)
+	}()
+
+	if p.template != "" {
+		if err := createDatabaseFromTemplate(ctx, db, p.template, name); err != nil {
+			return "", err
+		}
+	} else if _, err := db.ExecContext(ctx, createDatabaseStatement(DatabaseSpec{Name: name})); err != nil {
+		return "", fmt.Errorf("unable to create database %s: %w", name, err)
+	}
+
+	p.mu.Lock()
+	p.acquired[name] = struct{}{}
+	p.mu.Unlock()
+
+	return name, nil
+}
+
+// Release drops a database returned by Acquire, terminating any connections left open to it, and lets the next
+// caller waiting in Acquire proceed.
+func (p *DBPool) Release(name string) (err error) {
+	p.mu.Lock()
+	_, ok := p.acquired[name]
+	delete(p.acquired, name)
+	p.mu.Unlock()
+
+	if !ok {
+		return fmt.Errorf("database %s was not acquired from the pool", name)
+	}
+
+	defer func() {
+		<-p.slots
+	}()
+
+	db, err := p.ep.openDB("postgres")
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		err = connectionClose(db, err)
+	}()
+
+	return dropDatabase(context.Background(), db, name)
+}
+
+// ForTest acquires a database for the test, releasing it once the test and its subtests complete, and fails the test
+// should it not be created.
+func (p *DBPool) ForTest(t testing.TB) string {
+	t.Helper()
+
+	name, err := p.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("unable to acquire database: %s", err)
+	}
+
+	t.Cleanup(func() {
+		if err := p.Release(name); err != nil {
+			t.Errorf("unable to release database %s: %s", name, err)
+		}
+	})
+
+	return name
+}
@@ -0,0 +1,114 @@
+package embeddedpostgres
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_NewDBPool_NotStarted(t *testing.T) {
+	_, err := NewDBPool(NewDatabase())
+
+	assert.EqualError(t, err, "server has not been started")
+}
+
+func Test_DBPool(t *testing.T) {
+	database := StartForTest(t)
+
+	pool, err := NewDBPool(database)
+	require.NoError(t, err)
+
+	var names sync.Map
+
+	t.Run("parallel", func(t *testing.T) {
+		for _, test := range []string{"a", "b", "c"} {
+			test := test
+
+			t.Run(test, func(t *testing.T) {
+				t.Parallel()
+
+				name := pool.ForTest(t)
+				names.Store(test, name)
+
+				db, err := database.Open("postgres", WithDatabase(name))
+				require.NoError(t, err)
+
+				_, err = db.Exec("CREATE TABLE beer (name text)")
+				assert.NoError(t, err)
+			})
+		}
+	})
+
+	db, err := database.Open("postgres")
+	require.NoError(t, err)
+
+	seen := map[string]bool{}
+	names.Range(func(_, name interface{}) bool {
+		seen[name.(string)] = true
+
+		var exists bool
+		require.NoError(t, db.QueryRow("SELECT EXISTS (SELECT 1 FROM pg_database WHERE datname = $1)", name).Scan(&exists))
+		assert.False(t, exists)
+
+		return true
+	})
+	assert.Len(t, seen, 3)
+}
+
+func Test_DBPool_Template(t *testing.T) {
+	database := StartForTest(t)
+
+	db, err := database.Open("postgres")
+	require.NoError(t, err)
+
+	_, err = db.Exec("CREATE DATABASE brewery")
+	require.NoError(t, err)
+
+	brewery, err := database.Open("postgres", WithDatabase("brewery"))
+	require.NoError(t, err)
+
+	_, err = brewery.Exec("CREATE TABLE beer (name text); INSERT INTO beer VALUES ('stout')")
+	require.NoError(t, err)
+	require.NoError(t, brewery.Close())
+
+	pool, err := NewDBPool(database, PoolTemplate("brewery"))
+	require.NoError(t, err)
+
+	name := pool.ForTest(t)
+
+	copied, err := database.Open("postgres", WithDatabase(name))
+	require.NoError(t, err)
+
+	var beer string
+	require.NoError(t, copied.QueryRow("SELECT name FROM beer").Scan(&beer))
+	assert.Equal(t, "stout", beer)
+}
+
+func Test_DBPool_MaxDatabases(t *testing.T) {
+	database := StartForTest(t)
+
+	pool, err := NewDBPool(database, PoolMaxDatabases(1))
+	require.NoError(t, err)
+
+	first, err := pool.Acquire(context.Background())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	_, err = pool.Acquire(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	require.NoError(t, pool.Release(first))
+
+	second, err := pool.Acquire(context.Background())
+	require.NoError(t, err)
+	assert.NotEqual(t, first, second)
+	assert.NoError(t, pool.Release(second))
+
+	assert.EqualError(t, pool.Release(second), "database "+second+" was not acquired from the pool")
+}
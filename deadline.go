@@ -0,0 +1,111 @@
+package embeddedpostgres
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+)
+
+// deadlineGuardGrace is how long after the maximum lifetime the out of process guard stops the server, leaving the
+// watchdog within this process to do so first and report on why.
+const deadlineGuardGrace = 30 * time.Second
+
+// watchDeadline stops the server once it has been running for longer than the configured maximum lifetime.
+//
+// A watcher within this process stops the server and logs diagnostics. As that cannot help when the process exits
+// without stopping the server, such as after a panic, a guard process is also started that stops the server shortly
+// afterwards unless Stop is called first.
+func (ep *EmbeddedPostgres) watchDeadline() error {
+	if ep.config.maxLifetime <= 0 {
+		return nil
+	}
+
+	guard, err := startDeadlineGuard(ep.cmd, ep.config, ep.config.maxLifetime+deadlineGuardGrace)
+	if err != nil {
+		return fmt.Errorf("unable to start deadline guard: %w", err)
+	}
+
+	ep.deadlineGuard = guard
+
+	deadline := time.Now().Add(ep.config.maxLifetime)
+	background := ep.background()
+
+	ep.watch(watchInterval(ep.config.maxLifetime), func(now time.Time) bool {
+		if now.Before(deadline) {
+			return false
+		}
+
+		background.writeDeadlineDiagnostics(background.syncedLogger.file)
+
+		return true
+	})
+
+	return nil
+}
+
+func (ep *instanceState) stopDeadlineGuard() {
+	if ep.deadlineGuard != nil {
+		stopDeadlineGuard(ep.deadlineGuard)
+		ep.deadlineGuard = nil
+	}
+}
+
+// writeDeadlineDiagnostics reports the activity on the server at the point it is stopped for exceeding its maximum
+// lifetime, to help find whatever left it running.
+func (ep *EmbeddedPostgres) writeDeadlineDiagnostics(w io.Writer) {
+	_, _ = fmt.Fprintf(w, "embedded-postgres: stopping server on port %d as it has been running for longer than the maximum lifetime of %s\n",
+		ep.config.port, ep.config.maxLifetime)
+
+	activity, err := ep.serverActivity()
+	if err != nil {
+		_, _ = fmt.Fprintf(w, "embedded-postgres: unable to list server activity: %s\n", err)
+		return
+	}
+
+	for _, line := range activity {
+		_, _ = fmt.Fprintf(w, "embedded-postgres: %s\n", line)
+	}
+}
+
+// serverActivity describes each of the connections to the server other than the one used to describe them.
+func (ep *EmbeddedPostgres) serverActivity() (activity []string, err error) {
+	db, err := ep.openDB("postgres")
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		err = connectionClose(db, err)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := db.QueryContext(ctx, `SELECT pid, coalesce(usename, ''), coalesce(datname, ''), coalesce(state, ''), coalesce(query, '')
+FROM pg_stat_activity
+WHERE pid <> pg_backend_pid() AND usename IS NOT NULL
+ORDER BY pid`)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	for rows.Next() {
+		var (
+			pid                          int
+			user, database, state, query string
+		)
+
+		if err := rows.Scan(&pid, &user, &database, &state, &query); err != nil {
+			return nil, err
+		}
+
+		activity = append(activity, fmt.Sprintf("pid=%d user=%s database=%s state=%s query=%q", pid, user, database, state, query))
+	}
+
+	return activity, rows.Err()
+}
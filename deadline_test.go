@@ -0,0 +1,108 @@
+//go:build !windows
+// +build !windows
+
+package embeddedpostgres
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_deadlineGuardScript(t *testing.T) {
+	config := DefaultConfig().BinariesPath("/opt/it's").DataPath("/data")
+
+	assert.Equal(t, `sleep 90
+if [ "$(head -n 1 '/data/postmaster.pid' 2>/dev/null)" = "42" ]; then
+  '/opt/it'\''s/bin/pg_ctl' stop -D '/data' -m fast -t 30 >/dev/null 2>&1 || '/opt/it'\''s/bin/pg_ctl' stop -D '/data' -m immediate >/dev/null 2>&1
+fi`, deadlineGuardScript(config, 42, 90*time.Second))
+}
+
+func Test_startDeadlineGuard(t *testing.T) {
+	binariesPath := t.TempDir()
+	dataPath := t.TempDir()
+	stopped := filepath.Join(t.TempDir(), "stopped")
+
+	require.NoError(t, os.MkdirAll(filepath.Join(binariesPath, "bin"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(binariesPath, "bin", "pg_ctl"), []byte("#!/bin/sh\necho \"$@\" > "+stopped+"\n"), 0755))
+
+	database := startFakeServer(t)
+	defer func() {
+		_ = database.Stop()
+	}()
+
+	pid := database.cmd.cmd.Process.Pid
+	require.NoError(t, os.WriteFile(filepath.Join(dataPath, "postmaster.pid"), []byte(strconv.Itoa(pid)+"\n"+dataPath+"\n"), 0600))
+
+	_, err := startDeadlineGuard(database.cmd, DefaultConfig().BinariesPath(binariesPath).DataPath(dataPath), 0)
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		content, err := os.ReadFile(stopped)
+		return err == nil && strings.TrimSpace(string(content)) == "stop -D "+dataPath+" -m fast -t 30"
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func Test_startDeadlineGuard_IgnoresOtherServer(t *testing.T) {
+	binariesPath := t.TempDir()
+	dataPath := t.TempDir()
+	stopped := filepath.Join(t.TempDir(), "stopped")
+
+	require.NoError(t, os.MkdirAll(filepath.Join(binariesPath, "bin"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(binariesPath, "bin", "pg_ctl"), []byte("#!/bin/sh\ntouch "+stopped+"\n"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dataPath, "postmaster.pid"), []byte("1\n"), 0600))
+
+	database := startFakeServer(t)
+	defer func() {
+		_ = database.Stop()
+	}()
+
+	guard, err := startDeadlineGuard(database.cmd, DefaultConfig().BinariesPath(binariesPath).DataPath(dataPath), 0)
+	require.NoError(t, err)
+
+	time.Sleep(200 * time.Millisecond)
+	stopDeadlineGuard(guard)
+
+	assert.NoFileExists(t, stopped)
+}
+
+func Test_watchDeadline(t *testing.T) {
+	database := startFakeServer(t)
+	database.config = database.config.Port(1).MaxLifetime(100 * time.Millisecond)
+
+	require.NoError(t, database.watchDeadline())
+
+	assert.Eventually(t, func() bool {
+		return !database.isStarted()
+	}, 5*time.Second, 10*time.Millisecond)
+
+	logContent, err := os.ReadFile(database.syncedLogger.file.Name())
+	require.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(logContent, []byte(
+		"embedded-postgres: stopping server on port 1 as it has been running for longer than the maximum lifetime of 100ms\n"+
+			"embedded-postgres: unable to list server activity: ")))
+	assert.Nil(t, database.deadlineGuard)
+}
+
+func Test_MaxLifetime(t *testing.T) {
+	logger := &bytes.Buffer{}
+	database := NewDatabase(DefaultConfig().
+		RuntimePath(t.TempDir()).
+		Logger(logger).
+		MaxLifetime(time.Second))
+
+	require.NoError(t, database.Start())
+
+	assert.Eventually(t, func() bool {
+		return !database.isStarted()
+	}, 10*time.Second, 100*time.Millisecond)
+
+	assert.Contains(t, logger.String(), "as it has been running for longer than the maximum lifetime of 1s")
+}
@@ -0,0 +1,50 @@
+//go:build !windows
+// +build !windows
+
+package embeddedpostgres
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+// startDeadlineGuard starts a shell in its own process group that outlives this process and stops the server after
+// delay. The server is stopped through pg_ctl and only whilst postmaster.pid still names it, so the guard cannot stop
+// another server that has since reused the data directory or process id.
+func startDeadlineGuard(pp *postgresProcess, config Config, delay time.Duration) (*exec.Cmd, error) {
+	cmd := exec.Command("sh", "-c", deadlineGuardScript(config, pp.cmd.Process.Pid, delay))
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	go func() {
+		_ = cmd.Wait()
+	}()
+
+	return cmd, nil
+}
+
+func deadlineGuardScript(config Config, pid int, delay time.Duration) string {
+	pgCtl := shellQuote(filepath.Join(config.binariesPath, "bin", "pg_ctl"))
+	dataPath := shellQuote(config.dataPath)
+
+	return fmt.Sprintf(`sleep %d
+if [ "$(head -n 1 %s 2>/dev/null)" = "%d" ]; then
+  %s stop -D %s -m fast -t 30 >/dev/null 2>&1 || %s stop -D %s -m immediate >/dev/null 2>&1
+fi`,
+		int(delay.Round(time.Second)/time.Second),
+		shellQuote(filepath.Join(config.dataPath, "postmaster.pid")),
+		pid,
+		pgCtl, dataPath,
+		pgCtl, dataPath)
+}
+
+// stopDeadlineGuard kills the guard along with the sleep it is waiting on.
+func stopDeadlineGuard(cmd *exec.Cmd) {
+	_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
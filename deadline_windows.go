@@ -0,0 +1,16 @@
+//go:build windows
+// +build windows
+
+package embeddedpostgres
+
+import (
+	"os/exec"
+	"time"
+)
+
+// startDeadlineGuard does nothing on Windows, where the server is only stopped by the watchdog within this process.
+func startDeadlineGuard(_ *postgresProcess, _ Config, _ time.Duration) (*exec.Cmd, error) {
+	return nil, nil
+}
+
+func stopDeadlineGuard(_ *exec.Cmd) {}
@@ -10,17 +10,39 @@ import (
 	"github.com/xi2/xz"
 )
 
-func defaultTarReader(xzReader *xz.Reader) (func() (*tar.Header, error), func() io.Reader) {
+// TarReader reads the entries of the tar stream within a Postgres binaries archive, decompressing it as required.
+// It returns a function advancing to the next entry, which returns io.EOF after the last one, and a function returning
+// the content of the current entry. An error is returned when the archive is not in a format it can read.
+type TarReader func(archive io.Reader) (next func() (*tar.Header, error), content func() io.Reader, err error)
+
+// DefaultTarReader reads xz compressed tar archives, the format the Postgres binaries are published to Maven in.
+func DefaultTarReader(archive io.Reader) (func() (*tar.Header, error), func() io.Reader, error) {
+	xzReader, err := xz.NewReader(archive, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	tarReader := tar.NewReader(xzReader)
 
 	return func() (*tar.Header, error) {
 			return tarReader.Next()
 		}, func() io.Reader {
 			return tarReader
-		}
+		}, nil
 }
 
-func decompressTarXz(tarReader func(*xz.Reader) (func() (*tar.Header, error), func() io.Reader), path, extractPath string) error {
+// configuredTarReader returns the TarReader set with Config.TarReader, or DefaultTarReader, checking each entry with
+// the file fault hook.
+func configuredTarReader(config Config, extractPath string) TarReader {
+	tarReader := config.tarReader
+	if tarReader == nil {
+		tarReader = DefaultTarReader
+	}
+
+	return config.faults.tarReader(tarReader, extractPath)
+}
+
+func decompressTarXz(tarReader TarReader, path, extractPath string) error {
 	tempExtractPath, err := os.MkdirTemp(filepath.Dir(extractPath), "temp_")
 	if err != nil {
 		return errorUnableToExtract(path, extractPath, err)
@@ -42,13 +64,11 @@ func decompressTarXz(tarReader func(*xz.Reader) (func() (*tar.Header, error), fu
 		}
 	}()
 
-	xzReader, err := xz.NewReader(tarFile, 0)
+	readNext, reader, err := tarReader(tarFile)
 	if err != nil {
 		return errorUnableToExtract(path, extractPath, err)
 	}
 
-	readNext, reader := tarReader(xzReader)
-
 	for {
 		header, err := readNext()
 
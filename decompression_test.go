@@ -2,6 +2,8 @@ package embeddedpostgres
 
 import (
 	"archive/tar"
+	"bytes"
+	"compress/gzip"
 	"errors"
 	"fmt"
 	"io"
@@ -13,7 +15,6 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
-	"github.com/xi2/xz"
 )
 
 func Test_decompressTarXz(t *testing.T) {
@@ -28,7 +29,7 @@ func Test_decompressTarXz(t *testing.T) {
 	archive, cleanUp := createTempXzArchive()
 	defer cleanUp()
 
-	err = decompressTarXz(defaultTarReader, archive, tempDir)
+	err = decompressTarXz(DefaultTarReader, archive, tempDir)
 
 	assert.NoError(t, err)
 
@@ -42,7 +43,7 @@ func Test_decompressTarXz(t *testing.T) {
 }
 
 func Test_decompressTarXz_ErrorWhenFileNotExists(t *testing.T) {
-	err := decompressTarXz(defaultTarReader, "/does-not-exist", "/also-fake")
+	err := decompressTarXz(DefaultTarReader, "/does-not-exist", "/also-fake")
 
 	assert.Error(t, err)
 	assert.Contains(
@@ -64,10 +65,10 @@ func Test_decompressTarXz_ErrorWhenErrorDuringRead(t *testing.T) {
 	archive, cleanUp := createTempXzArchive()
 	defer cleanUp()
 
-	err = decompressTarXz(func(reader *xz.Reader) (func() (*tar.Header, error), func() io.Reader) {
+	err = decompressTarXz(func(reader io.Reader) (func() (*tar.Header, error), func() io.Reader, error) {
 		return func() (*tar.Header, error) {
 			return nil, errors.New("oh noes")
-		}, nil
+		}, nil, nil
 	}, archive, tempDir)
 
 	assert.EqualError(t, err, "unable to extract postgres archive: oh noes")
@@ -88,7 +89,7 @@ func Test_decompressTarXz_ErrorWhenFailedToReadFileToCopy(t *testing.T) {
 		panic(err)
 	}
 
-	fileBlockingExtractTarReader := func(reader *xz.Reader) (func() (*tar.Header, error), func() io.Reader) {
+	fileBlockingExtractTarReader := func(reader io.Reader) (func() (*tar.Header, error), func() io.Reader, error) {
 		shouldReadFile := true
 
 		return func() (*tar.Header, error) {
@@ -105,7 +106,7 @@ func Test_decompressTarXz_ErrorWhenFailedToReadFileToCopy(t *testing.T) {
 			}, func() io.Reader {
 				open, _ := os.Open("file_not_exists")
 				return open
-			}
+			}, nil
 	}
 
 	err = decompressTarXz(fileBlockingExtractTarReader, archive, tempDir)
@@ -125,7 +126,7 @@ func Test_decompressTarXz_ErrorWhenFileToCopyToNotExists(t *testing.T) {
 	archive, cleanUp := createTempXzArchive()
 	defer cleanUp()
 
-	fileBlockingExtractTarReader := func(reader *xz.Reader) (func() (*tar.Header, error), func() io.Reader) {
+	fileBlockingExtractTarReader := func(reader io.Reader) (func() (*tar.Header, error), func() io.Reader, error) {
 		shouldReadFile := true
 
 		return func() (*tar.Header, error) {
@@ -142,7 +143,7 @@ func Test_decompressTarXz_ErrorWhenFileToCopyToNotExists(t *testing.T) {
 			}, func() io.Reader {
 				open, _ := os.Open("file_not_exists")
 				return open
-			}
+			}, nil
 	}
 
 	err = decompressTarXz(fileBlockingExtractTarReader, archive, tempDir)
@@ -180,7 +181,7 @@ func Test_decompressTarXz_ErrorWhenArchiveCorrupted(t *testing.T) {
 		panic(err)
 	}
 
-	err = decompressTarXz(defaultTarReader, archive, tempDir)
+	err = decompressTarXz(DefaultTarReader, archive, tempDir)
 
 	assert.EqualError(t, err, "unable to extract postgres archive: xz: data is corrupt")
 }
@@ -197,10 +198,49 @@ func Test_decompressTarXz_ErrorWithInvalidDestination(t *testing.T) {
 
 	op := fmt.Sprintf(path.Join(tempDir, "%c"), rune(0))
 
-	err = decompressTarXz(defaultTarReader, archive, op)
+	err = decompressTarXz(DefaultTarReader, archive, op)
 	assert.EqualError(
 		t,
 		err,
 		fmt.Sprintf("unable to extract postgres archive: mkdir %s: invalid argument", op),
 	)
 }
+
+func Test_decompressTarXz_CustomTarReader(t *testing.T) {
+	buf := &bytes.Buffer{}
+	gzipWriter := gzip.NewWriter(buf)
+	tarWriter := tar.NewWriter(gzipWriter)
+	require.NoError(t, tarWriter.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: "bin/postgres", Mode: 0755, Size: 4}))
+	_, err := tarWriter.Write([]byte("beer"))
+	require.NoError(t, err)
+	require.NoError(t, tarWriter.Close())
+	require.NoError(t, gzipWriter.Close())
+
+	archive := filepath.Join(t.TempDir(), "postgres.tar.gz")
+	require.NoError(t, os.WriteFile(archive, buf.Bytes(), 0600))
+
+	gzipTarReader := func(archive io.Reader) (func() (*tar.Header, error), func() io.Reader, error) {
+		gzipReader, err := gzip.NewReader(archive)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		tarReader := tar.NewReader(gzipReader)
+
+		return tarReader.Next, func() io.Reader {
+			return tarReader
+		}, nil
+	}
+
+	extractPath := filepath.Join(t.TempDir(), "binaries")
+
+	require.NoError(t, decompressTarXz(configuredTarReader(DefaultConfig().TarReader(gzipTarReader), extractPath), archive, extractPath))
+
+	content, err := os.ReadFile(filepath.Join(extractPath, "bin", "postgres"))
+	require.NoError(t, err)
+	assert.Equal(t, "beer", string(content))
+
+	err = decompressTarXz(configuredTarReader(DefaultConfig(), extractPath), archive, extractPath)
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "xz: file format not recognized")
+}
@@ -0,0 +1,101 @@
+package embeddedpostgres
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DumpDiagnostics writes what is needed to investigate a server that failed into dir, which is created if need be:
+// the postgres log, the configuration files, and the output of pg_controldata and pg_ctl status. Everything that can
+// be collected is, with an error listing whatever could not be.
+func (ep *EmbeddedPostgres) DumpDiagnostics(dir string) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("unable to create diagnostics directory %s: %w", dir, err)
+	}
+
+	var failures []string
+
+	collect := func(name string, err error) {
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %s", name, err))
+		}
+	}
+
+	if ep.syncedLogger != nil {
+		_ = ep.syncedLogger.flush()
+		collect("postgres.log", copyFile(ep.syncedLogger.file.Name(), filepath.Join(dir, "postgres.log"), 0600))
+
+		if ep.syncedLogger.stdout != nil {
+			collect("postgres.stdout.log", copyFile(ep.syncedLogger.stdout.Name(), filepath.Join(dir, "postgres.stdout.log"), 0600))
+		}
+	}
+
+	if ep.config.csvLogHandler != nil {
+		collect(csvLogFileName, copyIfExists(filepath.Join(csvLogDirectory(ep.config), csvLogFileName), filepath.Join(dir, csvLogFileName)))
+	}
+
+	if ep.config.dataPath != "" {
+		for _, name := range []string{"postgresql.conf", "pg_hba.conf", "postmaster.pid"} {
+			collect(name, copyIfExists(filepath.Join(ep.config.dataPath, name), filepath.Join(dir, name)))
+		}
+
+		if _, err := os.Stat(ep.ConfDir()); err == nil {
+			collect(confDirName, copyDir(ep.ConfDir(), filepath.Join(dir, confDirName)))
+		}
+	}
+
+	controlData, err := ep.controlDataOutput()
+	if err == nil {
+		err = os.WriteFile(filepath.Join(dir, "pg_controldata.txt"), []byte(controlData), 0600)
+	}
+
+	collect("pg_controldata.txt", err)
+
+	status, err := pgCtlStatus(ep.config)
+	if err == nil {
+		err = os.WriteFile(filepath.Join(dir, "pg_ctl_status.txt"), []byte(status.Output), 0600)
+	}
+
+	collect("pg_ctl_status.txt", err)
+
+	if len(failures) > 0 {
+		return fmt.Errorf("unable to collect all diagnostics into %s: %s", dir, strings.Join(failures, "; "))
+	}
+
+	return nil
+}
+
+// copyIfExists copies the file at src to dst unless there is no such file.
+func copyIfExists(src, dst string) error {
+	info, err := os.Stat(src)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return copyFile(src, dst, info.Mode().Perm())
+}
+
+// withDiagnostics dumps diagnostics into a new directory within the one configured with Config.DiagnosticsOnFailure
+// when Start or Restart fails with err, naming the directory in the error.
+func (ep *EmbeddedPostgres) withDiagnostics(err error) error {
+	if err == nil || ep.config.diagnosticsDir == "" {
+		return err
+	}
+
+	dir := filepath.Join(ep.config.diagnosticsDir,
+		fmt.Sprintf("embedded-postgres-%d-%s", ep.config.port, time.Now().UTC().Format("20060102T150405.000000000")))
+
+	if dumpErr := ep.DumpDiagnostics(dir); dumpErr != nil {
+		ep.reportDiagnostic(dumpErr.Error())
+	}
+
+	return fmt.Errorf("%w\ndiagnostics written to %s", err, dir)
+}
@@ -0,0 +1,96 @@
+package embeddedpostgres
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_DumpDiagnostics(t *testing.T) {
+	logger, err := newSyncedLogger(t.TempDir(), nil)
+	require.NoError(t, err)
+
+	_, err = logger.file.WriteString("FATAL:  could not create shared memory segment\n")
+	require.NoError(t, err)
+
+	dataPath := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dataPath, "postgresql.conf"), []byte("include_dir = 'conf.d'\n"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dataPath, "pg_hba.conf"), []byte("local all all trust\n"), 0600))
+
+	database := NewDatabase(DefaultConfig().DataPath(dataPath).BinariesPath(t.TempDir()))
+	database.syncedLogger = logger
+	require.NoError(t, database.WriteConfSnippet("50-memory", map[string]string{"work_mem": "8MB"}))
+
+	dir := filepath.Join(t.TempDir(), "diagnostics")
+	err = database.DumpDiagnostics(dir)
+
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "unable to collect all diagnostics into "+dir+": pg_controldata.txt: unable to read control data")
+	assert.Contains(t, err.Error(), "; pg_ctl_status.txt: ")
+
+	for name, expected := range map[string]string{
+		"postgres.log":    "FATAL:  could not create shared memory segment\n",
+		"postgresql.conf": "include_dir = 'conf.d'\n",
+		"pg_hba.conf":     "local all all trust\n",
+		filepath.Join(confDirName, "50-memory.conf"): "work_mem = '8MB'\n",
+	} {
+		content, err := os.ReadFile(filepath.Join(dir, name))
+		require.NoError(t, err, name)
+		assert.Equal(t, expected, string(content), name)
+	}
+
+	assert.NoFileExists(t, filepath.Join(dir, "postmaster.pid"))
+}
+
+func Test_withDiagnostics(t *testing.T) {
+	diagnosticsDir := t.TempDir()
+	database := NewDatabase(DefaultConfig().DiagnosticsOnFailure(diagnosticsDir).LibraryLogger(&customLogger{}))
+
+	assert.Nil(t, database.withDiagnostics(nil))
+
+	err := database.withDiagnostics(errDiskFull)
+
+	entries, readErr := os.ReadDir(diagnosticsDir)
+	require.NoError(t, readErr)
+	require.Len(t, entries, 1)
+
+	assert.ErrorIs(t, err, errDiskFull)
+	assert.Equal(t, errDiskFull.Error()+"\ndiagnostics written to "+filepath.Join(diagnosticsDir, entries[0].Name()), err.Error())
+	assert.True(t, strings.HasPrefix(entries[0].Name(), "embedded-postgres-5432-"))
+
+	assert.Equal(t, errDiskFull, NewDatabase().withDiagnostics(errDiskFull))
+}
+
+func Test_DiagnosticsOnFailure(t *testing.T) {
+	diagnosticsDir := t.TempDir()
+
+	database := NewDatabase(DefaultConfig().
+		RuntimePath(t.TempDir()).
+		Port(0).
+		StartTimeout(3*time.Second).
+		StartParameter("maintenance_work_mem", "plenty").
+		DiagnosticsOnFailure(diagnosticsDir))
+
+	err := database.Start()
+	require.NotNil(t, err)
+
+	entries, readErr := os.ReadDir(diagnosticsDir)
+	require.NoError(t, readErr)
+	require.Len(t, entries, 1)
+
+	dir := filepath.Join(diagnosticsDir, entries[0].Name())
+	assert.Contains(t, err.Error(), "diagnostics written to "+dir)
+
+	log, readErr := os.ReadFile(filepath.Join(dir, "postgres.log"))
+	require.NoError(t, readErr)
+	assert.Contains(t, string(log), `invalid value for parameter "maintenance_work_mem": "plenty"`)
+
+	assert.FileExists(t, filepath.Join(dir, "pg_controldata.txt"))
+	assert.FileExists(t, filepath.Join(dir, "pg_ctl_status.txt"))
+	assert.FileExists(t, filepath.Join(dir, "postgresql.conf"))
+}
@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"net"
@@ -11,22 +12,53 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 )
 
 var mu sync.Mutex
 
 // EmbeddedPostgres maintains all configuration and runtime functions for maintaining the lifecycle of one Postgres process.
 type EmbeddedPostgres struct {
-	config              Config
-	cacheLocator        CacheLocator
-	remoteFetchStrategy RemoteFetchStrategy
-	initDatabase        initDatabase
-	createDatabase      createDatabase
-	started             bool
-	syncedLogger        *syncedLogger
-	cmd                 *postgresProcess
+	// the state is held through a pointer, as os.File does, so that the watchers running in the background can share
+	// it without keeping the instance itself reachable, leaving an instance that is never stopped to be garbage
+	// collected and reported by trackLeaks
+	*instanceState
+}
+
+// instanceState is the state of an EmbeddedPostgres, shared with its watchers.
+type instanceState struct {
+	config                Config
+	cacheLocator          CacheLocator
+	remoteFetchStrategy   RemoteFetchStrategy
+	initDatabase          InitDatabaseStrategy
+	createDatabase        CreateDatabaseStrategy
+	started               bool
+	syncedLogger          *syncedLogger
+	cmd                   *postgresProcess
+	unavailableExtensions []string
+	captureOffset         int64
+	lifecycleMu           sync.Mutex
+	watchers              []*watcher
+	deadlineGuard         *exec.Cmd
+	createdAt             []byte
+	locks                 []string
+	statsMu               sync.Mutex
+	latestStats           DatabaseStats
+	latestStatsErr        error
+	recovering            bool
+	dynamicPort           bool
+	sharedBinaries        bool
+	memoryDataDir         bool
+	poolsMu               sync.Mutex
+	pools                 []*sql.DB
+	eventsMu              sync.Mutex
+	events                chan Event
+	timingsMu             sync.Mutex
+	timings               Timings
+	csvLog                csvLogTail
 }
 
 // NewDatabase creates a new EmbeddedPostgres struct that can be used to start and stop a Postgres process.
@@ -41,101 +73,314 @@ func NewDatabase(config ...Config) *EmbeddedPostgres {
 }
 
 func newDatabaseWithConfig(config Config) *EmbeddedPostgres {
-	versionStrategy := defaultVersionStrategy(
-		config,
-		runtime.GOOS,
-		runtime.GOARCH,
-		linuxMachineName,
-		shouldUseAlpineLinuxBuild,
-	)
-	cacheLocator := defaultCacheLocator(config.cachePath, versionStrategy)
-	remoteFetchStrategy := defaultRemoteFetchStrategy(config.binaryRepositoryURL, versionStrategy, cacheLocator)
+	cacheLocator, remoteFetchStrategy := binaryStrategies(config)
+
+	initDatabase := config.initDBStrategy
+	if initDatabase == nil {
+		initDatabase = initDatabaseWithFaults(config.faults)
+		if config.standbyOf != "" {
+			initDatabase = standbyInitDatabase(config.standbyOf, config.faults)
+		}
+	}
+
+	createDatabase := config.createDBStrategy
+	if createDatabase == nil {
+		createDatabase = DefaultCreateDatabase
+	}
 
-	return &EmbeddedPostgres{
+	ep := &EmbeddedPostgres{instanceState: &instanceState{
 		config:              config,
 		cacheLocator:        cacheLocator,
 		remoteFetchStrategy: remoteFetchStrategy,
-		initDatabase:        defaultInitDatabase,
-		createDatabase:      defaultCreateDatabase,
+		initDatabase:        initDatabase,
+		createDatabase:      createDatabase,
 		started:             false,
+		dynamicPort:         config.port == 0 && !config.socketOnly,
+	}}
+
+	if config.socketOnly && config.port == 0 {
+		ep.config.port = 5432
 	}
+
+	reportLeak := reportLeakToStderr
+	if config.diagnostic != nil {
+		reportLeak = config.diagnostic
+	}
+
+	trackLeaks(ep, reportLeak)
+
+	return ep
 }
 
 // Start will try to start the configured Postgres process returning an error when there were any problems with invocation.
 // If any error occurs Start will try to also Stop the Postgres process in order to not leave any sub-process running.
 //
 //nolint:funlen
-func (ep *EmbeddedPostgres) Start() error {
+func (ep *EmbeddedPostgres) Start() (err error) {
 	if ep.started {
 		return errors.New("server is already started")
 	}
 
-	if err := ensurePortAvailable(ep.config.port); err != nil {
+	startedAt := time.Now()
+	ep.resetTimings()
+
+	defer func() {
+		ep.finishStart(startedAt, err)
+	}()
+
+	ep.stopWatchers()
+
+	if err := checkSocketOnly(ep.config, runtime.GOOS); err != nil {
+		return err
+	}
+
+	if err := checkTLS(ep.config); err != nil {
 		return err
 	}
 
-	logger, err := newSyncedLogger("", ep.config.logger)
+	if err := checkStartParameters(ep.config); err != nil {
+		return err
+	}
+
+	if ep.dynamicPort {
+		port, err := freePort()
+		if err != nil {
+			return err
+		}
+
+		ep.config.port = port
+	}
+
+	// a server listening only on a socket leaves the port free
+	if !ep.config.socketOnly {
+		if err := ensurePortAvailable(ep.config.port); err != nil {
+			return err
+		}
+	}
+
+	logger, err := newSyncedLogger("", filterLogSeverity(ep.config.logger, ep.config.logLevel))
 	if err != nil {
 		return errors.New("unable to create logger")
 	}
 
 	ep.syncedLogger = logger
+	resources.addPath(logger.file.Name())
+
+	if ep.config.stdoutLogger != nil {
+		if err := logger.splitStdout(ep.config.stdoutLogger); err != nil {
+			return errors.New("unable to create logger")
+		}
+
+		resources.addPath(logger.stdout.Name())
+	}
+	logger.stream(logStreamInterval)
+
+	defer func() {
+		// output is streamed whilst the server runs, or until Start fails
+		if !ep.started {
+			logger.stopStreaming()
+			_ = logger.flush()
+			err = ep.withDiagnostics(err)
+		}
+	}()
 
 	cacheLocation, cacheExists := ep.cacheLocator()
 
 	if ep.config.runtimePath == "" {
-		ep.config.runtimePath = filepath.Join(filepath.Dir(cacheLocation), "extracted")
+		ep.config.runtimePath = defaultRuntimePath(ep.config, cacheLocation)
+
+		if err := reapRuntimeDirectories(filepath.Dir(ep.config.runtimePath)); err != nil {
+			ep.reportDiagnostic(err.Error())
+		}
+
+		// binaries are extracted through a temporary directory alongside the runtime directory
+		if err := os.MkdirAll(filepath.Dir(ep.config.runtimePath), os.ModePerm); err != nil {
+			return fmt.Errorf("unable to create runtime directory %s with error: %s", filepath.Dir(ep.config.runtimePath), err)
+		}
+	}
+
+	if ep.config.dataInMemory && (ep.config.dataPath == "" || ep.memoryDataDir) {
+		if err := ep.placeDataInMemory(); err != nil {
+			return err
+		}
 	}
 
 	if ep.config.dataPath == "" {
 		ep.config.dataPath = filepath.Join(ep.config.runtimePath, "data")
 	}
 
-	if err := os.RemoveAll(ep.config.runtimePath); err != nil {
-		return fmt.Errorf("unable to clean up runtime directory %s with error: %s", ep.config.runtimePath, err)
+	if err := ep.lockDirectories(); err != nil {
+		return err
 	}
 
+	defer func() {
+		// the directories are only held whilst the server is running
+		if !ep.started {
+			ep.unlockDirectories()
+		}
+	}()
+
 	if ep.config.binariesPath == "" {
 		ep.config.binariesPath = ep.config.runtimePath
+
+		if ep.config.sharedBinaries {
+			ep.config.binariesPath = sharedBinariesPath(ep.config, cacheLocation)
+			ep.sharedBinaries = true
+
+			// checked for allowing binaries to be executed below
+			if err := os.MkdirAll(filepath.Dir(ep.config.binariesPath), os.ModePerm); err != nil {
+				return fmt.Errorf("unable to create binaries directory %s with error: %s", filepath.Dir(ep.config.binariesPath), err)
+			}
+		}
+	}
+
+	if err := ep.cleanRuntimeDirectory(cacheLocation); err != nil {
+		return err
+	}
+
+	resources.addPath(ep.config.runtimePath)
+	ep.csvLog.offset = 0
+
+	// the binaries are extracted through a directory alongside binariesPath, which is therefore known to exist
+	if err := checkExecutable(filepath.Dir(ep.config.binariesPath)); err != nil {
+		return err
 	}
 
 	if err := ep.downloadAndExtractBinary(cacheExists, cacheLocation); err != nil {
 		return err
 	}
 
+	if err := ep.config.faults.checkFile(ep.config.runtimePath); err != nil {
+		return fmt.Errorf("unable to create runtime directory %s with error: %s", ep.config.runtimePath, err)
+	}
+
 	if err := os.MkdirAll(ep.config.runtimePath, os.ModePerm); err != nil {
 		return fmt.Errorf("unable to create runtime directory %s with error: %s", ep.config.runtimePath, err)
 	}
 
+	if ep.config.runtimePath == defaultRuntimePath(ep.config, cacheLocation) {
+		if err := writeRuntimeOwner(ep.config.runtimePath); err != nil {
+			return err
+		}
+	}
+
 	reuseData := dataDirIsValid(ep.config.dataPath, ep.config.version)
 
+	if !reuseData && ep.config.dataArchive != "" {
+		if err := ep.restoreDataArchive(); err != nil {
+			return err
+		}
+
+		reuseData = true
+	}
+
 	if !reuseData {
-		if err := ep.cleanDataDirectoryAndInit(); err != nil {
+		if err := ep.runHook(context.Background(), "BeforeInit", ep.config.hooks.BeforeInit); err != nil {
 			return err
 		}
+
+		initDBStartedAt := time.Now()
+
+		if err := ep.cleanDataDirectoryAndInit(cacheLocation); err != nil {
+			return ep.withLogTail(err)
+		}
+
+		ep.recordTiming(&ep.timings.InitDB, time.Since(initDBStartedAt))
+
+		ep.emit(InitDBCompleted, nil)
+
+		if err := ep.runHook(context.Background(), "AfterInit", ep.config.hooks.AfterInit); err != nil {
+			return err
+		}
+	}
+
+	return ep.startServer(reuseData)
+}
+
+// startServer writes the configuration and starts Postgres against a data directory that is ready to use, creating
+// the configured database unless the data directory is being reused.
+//
+//nolint:funlen
+func (ep *EmbeddedPostgres) startServer(reuseData bool) error {
+	if err := ep.writePostgresConf(); err != nil {
+		return err
+	}
+
+	if err := ep.writeConfDir(); err != nil {
+		return err
+	}
+
+	if err := ep.writeHBAConf(); err != nil {
+		return err
 	}
 
 	ctx, cancelCtx := context.WithTimeout(context.Background(), ep.config.startTimeout)
 	defer cancelCtx()
 
+	if err := ep.runHook(ctx, "BeforeStart", ep.config.hooks.BeforeStart); err != nil {
+		return err
+	}
+
 	ep.cmd = &postgresProcess{
 		Config: ep.config,
 		Logger: ep.syncedLogger,
 	}
 
-	if err = ep.cmd.Start(ctx); err != nil {
-		return err
+	serverStartedAt := time.Now()
+
+	if err := ep.cmd.Start(ctx); err != nil {
+		return ep.withLogTail(err)
 	}
 
+	ep.recordTiming(&ep.timings.ServerStart, time.Since(serverStartedAt))
+	provisionStartedAt := time.Now()
+
+	resources.addProcess(ep.cmd)
+
 	if err := ep.syncedLogger.flush(); err != nil {
 		return err
 	}
 
 	ep.started = true
 
-	if !reuseData {
-		if err := ep.createDatabase(ctx, ep.config.port, ep.config.username, ep.config.password, ep.config.database); err != nil {
-			if stopErr := ep.Stop(); stopErr != nil {
+	if ep.recovering {
+		if err := ep.finishRecovery(ctx); err != nil {
+			if stopErr := ep.stop(); stopErr != nil {
+				return fmt.Errorf("unable to stop database casused by error %s", err)
+			}
+
+			return err
+		}
+	}
+
+	// a standby is a read only copy of its primary, which the database, roles and settings are replicated from
+	if !reuseData && ep.config.standbyOf == "" {
+		options, err := createDatabaseOptions(ep.config)
+		if err == nil {
+			err = ep.createDatabase(withServerAddress(ctx, ep.config), ep.config.port, ep.config.username, ep.config.password, ep.config.database, options)
+		}
+
+		if err != nil {
+			if stopErr := ep.stop(); stopErr != nil {
+				return fmt.Errorf("unable to stop database casused by error %s", err)
+			}
+
+			return err
+		}
+	}
+
+	if err := ep.provision(ctx); err != nil {
+		if stopErr := ep.stop(); stopErr != nil {
+			return fmt.Errorf("unable to stop database casused by error %s", err)
+		}
+
+		return err
+	}
+
+	// init scripts run against a newly initialised data directory only, after it has been provisioned
+	if !reuseData && ep.config.standbyOf == "" {
+		if err := ep.runInitScripts(ctx); err != nil {
+			if stopErr := ep.stop(); stopErr != nil {
 				return fmt.Errorf("unable to stop database casused by error %s", err)
 			}
 
@@ -143,50 +388,289 @@ func (ep *EmbeddedPostgres) Start() error {
 		}
 	}
 
-	if err := healthCheckDatabaseOrTimeout(ctx, ep.config); err != nil {
-		if stopErr := ep.Stop(); stopErr != nil {
+	healthCheckStartedAt := time.Now()
+	err := healthCheckDatabaseOrTimeout(ctx, ep.config)
+	ep.recordTiming(&ep.timings.HealthCheck, time.Since(healthCheckStartedAt))
+
+	if err != nil {
+		err = ep.withLogTail(err)
+
+		if stopErr := ep.stop(); stopErr != nil {
+			return fmt.Errorf("unable to stop database casused by error %s", err)
+		}
+
+		return err
+	}
+
+	// migrations and seeds run against the configured database once it accepts connections
+	if ep.config.standbyOf == "" {
+		if err := ep.runMigrations(ctx); err != nil {
+			if stopErr := ep.stop(); stopErr != nil {
+				return fmt.Errorf("unable to stop database casused by error %s", err)
+			}
+
+			return err
+		}
+	}
+
+	if ep.config.seedDirectory != "" && ep.config.standbyOf == "" {
+		if err := ep.Seed(ctx, ep.config.seedDirectory); err != nil {
+			if stopErr := ep.stop(); stopErr != nil {
+				return fmt.Errorf("unable to stop database casused by error %s", err)
+			}
+
+			return err
+		}
+	}
+
+	ep.recordTiming(&ep.timings.Provision, time.Since(provisionStartedAt)-ep.timings.HealthCheck)
+
+	if err := ep.runHook(ctx, "AfterStart", ep.config.hooks.AfterStart); err != nil {
+		if stopErr := ep.stop(); stopErr != nil {
+			return fmt.Errorf("unable to stop database casused by error %s", err)
+		}
+
+		return err
+	}
+
+	if err := ep.startWatchers(); err != nil {
+		if stopErr := ep.stop(); stopErr != nil {
 			return fmt.Errorf("unable to stop database casused by error %s", err)
 		}
 
 		return err
 	}
 
+	if ep.config.captureStatements {
+		// statements executed whilst starting are not of interest
+		if err := ep.ResetCapturedStatements(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
+// Restart stops the server if it is running and starts it again using the binaries and data directory already in
+// place, so that neither are extracted nor initialised again. The configuration files are rewritten on start, so
+// changes such as those made with WriteConfSnippet take effect, and crash recovery runs if the server stopped abruptly.
+func (ep *EmbeddedPostgres) Restart() (err error) {
+	if ep.isStarted() {
+		if err := ep.Stop(); err != nil {
+			return err
+		}
+	}
+
+	if ep.syncedLogger == nil || !dataDirIsValid(ep.config.dataPath, ep.config.version) {
+		return errors.New("data directory has not been initialised, call Start first")
+	}
+
+	startedAt := time.Now()
+	ep.resetTimings()
+
+	defer func() {
+		ep.finishStart(startedAt, err)
+	}()
+
+	ep.stopWatchers()
+
+	// a server listening only on a socket leaves the port free
+	if !ep.config.socketOnly {
+		if err := ensurePortAvailable(ep.config.port); err != nil {
+			return err
+		}
+	}
+
+	if err := ep.lockDirectories(); err != nil {
+		return err
+	}
+
+	ep.syncedLogger.stream(logStreamInterval)
+
+	defer func() {
+		if !ep.started {
+			ep.unlockDirectories()
+			ep.syncedLogger.stopStreaming()
+			err = ep.withDiagnostics(err)
+		}
+	}()
+
+	return ep.startServer(true)
+}
+
+// finishStart records how long Start or Restart took, reporting the server as ready when it succeeded.
+func (ep *EmbeddedPostgres) finishStart(startedAt time.Time, err error) {
+	ep.recordTiming(&ep.timings.Total, time.Since(startedAt))
+
+	if err == nil {
+		ep.emit(ServerReady, nil)
+	}
+}
+
+// defaultRuntimePath is unique to this process so that processes sharing a cache cannot clean up the runtime directory
+// of a server another process is running. It is removed by CleanupAll, or by reapRuntimeDirectories in a later process
+// should this one exit without calling it, which is why it lies in a directory of the library's own even within
+// ExecDir.
+func defaultRuntimePath(config Config, cacheLocation string) string {
+	if config.execDir != "" {
+		return filepath.Join(config.execDir, "extracted", strconv.Itoa(os.Getpid()))
+	}
+
+	return filepath.Join(filepath.Dir(cacheLocation), "extracted", strconv.Itoa(os.Getpid()))
+}
+
 func (ep *EmbeddedPostgres) downloadAndExtractBinary(cacheExists bool, cacheLocation string) error {
 	// lock to prevent collisions with duplicate downloads
 	mu.Lock()
 	defer mu.Unlock()
 
-	_, binDirErr := os.Stat(filepath.Join(ep.config.binariesPath, "bin"))
+	startedAt := time.Now()
+
+	defer func() {
+		ep.recordTiming(&ep.timings.Extraction, time.Since(startedAt)-ep.timings.Download)
+	}()
+
+	if _, ok := binariesExtracted(ep.config.binariesPath, cacheLocation, ep.config.extensionArchives); ok {
+		return nil
+	}
+
+	extractPath := ep.config.binariesPath
+
+	if ep.sharedBinaries {
+		// shared binaries are extracted alongside and moved into place once complete
+		if err := os.MkdirAll(filepath.Dir(extractPath), os.ModePerm); err != nil {
+			return errorUnableToExtract(cacheLocation, extractPath, err)
+		}
+
+		temp, err := os.MkdirTemp(filepath.Dir(extractPath), filepath.Base(extractPath)+".tmp_")
+		if err != nil {
+			return errorUnableToExtract(cacheLocation, extractPath, err)
+		}
+
+		defer func() {
+			_ = os.RemoveAll(temp)
+		}()
+
+		extractPath = temp
+	}
+
+	if cacheExists {
+		if err := verifyCachedArchive(cacheLocation); err != nil {
+			ep.reportDiagnostic(fmt.Sprintf("downloading the binaries again: %s", err))
+			cacheExists = false
+		}
+	}
+
+	extracted := len(ep.config.extensionArchives) > 0
+
+	_, binDirErr := os.Stat(filepath.Join(extractPath, "bin"))
 	if os.IsNotExist(binDirErr) {
+		extracted = true
+
 		if !cacheExists {
+			ep.emit(DownloadStarted, nil)
+
+			downloadStartedAt := time.Now()
+
 			if err := ep.remoteFetchStrategy(); err != nil {
 				return err
 			}
+
+			ep.recordTiming(&ep.timings.Download, time.Since(downloadStartedAt))
 		}
 
-		if err := decompressTarXz(defaultTarReader, cacheLocation, ep.config.binariesPath); err != nil {
+		if err := decompressTarXz(configuredTarReader(ep.config, extractPath), cacheLocation, extractPath); err != nil {
 			return err
 		}
 	}
+
+	for _, archive := range ep.config.extensionArchives {
+		if err := decompressTarXz(configuredTarReader(ep.config, extractPath), archive, extractPath); err != nil {
+			return err
+		}
+	}
+
+	if !extracted {
+		return nil
+	}
+
+	if err := applyExtractionPermissions(ep.config, extractPath); err != nil {
+		return err
+	}
+
+	if err := writeBinariesMarker(extractPath, cacheLocation, ep.config.extensionArchives); err != nil {
+		return err
+	}
+
+	if ep.sharedBinaries {
+		if err := publishSharedBinaries(extractPath, ep.config.binariesPath, cacheLocation, ep.config.extensionArchives); err != nil {
+			return err
+		}
+	}
+
+	ep.emit(Extracted, nil)
+
 	return nil
 }
 
-func (ep *EmbeddedPostgres) cleanDataDirectoryAndInit() error {
+func (ep *EmbeddedPostgres) cleanDataDirectoryAndInit(cacheLocation string) error {
+	extraArgs, err := initDBArgs(ep.config)
+	if err != nil {
+		return err
+	}
+
+	template, err := initDBTemplatePath(ep.config, cacheLocation)
+	if err != nil {
+		return err
+	}
+
 	if err := os.RemoveAll(ep.config.dataPath); err != nil {
 		return fmt.Errorf("unable to clean up data directory %s with error: %s", ep.config.dataPath, err)
 	}
 
-	if err := ep.initDatabase(ep.config.binariesPath, ep.config.runtimePath, ep.config.dataPath, ep.config.username, ep.config.password, ep.config.locale, ep.syncedLogger.file); err != nil {
+	if ep.config.walPath != "" {
+		if err := os.RemoveAll(ep.config.walPath); err != nil {
+			return fmt.Errorf("unable to clean up WAL directory %s with error: %s", ep.config.walPath, err)
+		}
+	}
+
+	if template != "" && ep.restoreInitDBTemplate(template) {
+		return nil
+	}
+
+	if err := ep.initDatabase(ep.config.binariesPath, ep.config.runtimePath, ep.config.dataPath, ep.config.username, ep.config.password, ep.config.locale, extraArgs, ep.syncedLogger.file); err != nil {
 		_ = ep.syncedLogger.flush()
 		return err
 	}
 
+	if template != "" {
+		ep.saveInitDBTemplate(template)
+	}
+
 	return nil
 }
 
+// freePort returns a port that is free at the time of calling, as chosen by the operating system.
+func freePort() (uint32, error) {
+	listener, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		return 0, fmt.Errorf("unable to find a free port: %w", err)
+	}
+
+	port := listener.Addr().(*net.TCPAddr).Port
+
+	if err := listener.Close(); err != nil {
+		return 0, err
+	}
+
+	return uint32(port), nil
+}
+
+// Port returns the port the server listens on, which is only known once Start has been called when Config.Port is 0.
+func (ep *EmbeddedPostgres) Port() uint32 {
+	return ep.config.port
+}
+
 func ensurePortAvailable(port uint32) error {
 	conn, err := net.Listen("tcp", fmt.Sprintf("localhost:%d", port))
 	if err != nil {
@@ -202,20 +686,123 @@ func ensurePortAvailable(port uint32) error {
 
 // Stop will try to stop the Postgres process gracefully returning an error when there were any problems.
 func (ep *EmbeddedPostgres) Stop() error {
+	if !ep.isStarted() {
+		return ep.stop()
+	}
+
+	ctx := context.Background()
+	hookErr := ep.runHook(ctx, "BeforeStop", ep.config.hooks.BeforeStop)
+
+	if err := ep.stop(); err != nil {
+		return err
+	}
+
+	if hookErr != nil {
+		return hookErr
+	}
+
+	return ep.runHook(ctx, "AfterStop", ep.config.hooks.AfterStop)
+}
+
+// stop stops the Postgres process without calling the stop hooks, as when Start fails.
+func (ep *EmbeddedPostgres) stop() error {
+	ep.stopWatchers()
+
+	ep.lifecycleMu.Lock()
+	defer ep.lifecycleMu.Unlock()
+
+	ep.stopDeadlineGuard()
+
 	if !ep.started {
 		return errors.New("server has not been started")
 	}
 
-	if err := ep.cmd.Stop(); err != nil {
-		return err
+	crashed, err := ep.stopProcess()
+	if err != nil && !crashed {
+		return ep.withLogTail(err)
 	}
 
+	// the watchers have ended, so a server that crashed just before Stop has not been reported as such
+	if crashed {
+		ep.emit(Crashed, ep.cmd.exitErr)
+	}
+
+	return ep.releaseStopped(crashed)
+}
+
+// stopProcess stops the server process, with lifecycleMu held, reporting whether it had already exited.
+func (ep *instanceState) stopProcess() (crashed bool, err error) {
+	ep.closePools()
+	resources.removeProcess(ep.cmd)
+
+	select {
+	case <-ep.cmd.exited():
+		crashed = true
+	default:
+	}
+
+	return crashed, ep.cmd.Stop()
+}
+
+// releaseStopped releases what the server held once its process has stopped, with lifecycleMu held. A server that has
+// crashed has already been reported as such, so Stopped is not emitted for it.
+func (ep *instanceState) releaseStopped(crashed bool) error {
+	ep.syncCSVLog()
 	ep.started = false
+	ep.unlockDirectories()
 
-	if err := ep.syncedLogger.flush(); err != nil {
+	if !crashed {
+		ep.emit(Stopped, nil)
+	}
+
+	ep.syncedLogger.stopStreaming()
+	flushErr := ep.syncedLogger.flush()
+
+	if err := removeTempFiles(ep.config); err != nil {
 		return err
 	}
 
+	return flushErr
+}
+
+// ReloadConfig signals the running server to reload postgresql.conf, pg_hba.conf and the drop-in configuration
+// directory using pg_ctl reload. Parameters that can only be set at server start require Restart instead.
+func (ep *EmbeddedPostgres) ReloadConfig() error {
+	if !ep.isStarted() {
+		return errors.New("server has not been started")
+	}
+
+	cmd := exec.Command(filepath.Join(ep.config.binariesPath, "bin/pg_ctl"), "reload", "-D", ep.config.dataPath)
+	buf := &bytes.Buffer{}
+	cmd.Stdout = buf
+	cmd.Stderr = buf
+
+	err := ep.config.faults.checkCommand(cmd)
+	if err == nil {
+		err = cmd.Run()
+	}
+
+	if err != nil {
+		return fmt.Errorf("unable to reload configuration using %s: %w\n%s", cmd.String(), err, buf.String())
+	}
+
+	return nil
+}
+
+// pgCtlStop stops the server with pg_ctl, waiting for it to shut down.
+func pgCtlStop(config Config, logger *syncedLogger) error {
+	cmd := exec.Command(filepath.Join(config.binariesPath, "bin/pg_ctl"), "stop", "-w", "-D", config.dataPath)
+	cmd.Stdout = logger.file
+	cmd.Stderr = logger.file
+
+	if err := config.faults.checkCommand(cmd); err != nil {
+		return fmt.Errorf("could not stop postgres using %s: %w", cmd.String(), err)
+	}
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("could not stop postgres using %s", cmd.String())
+	}
+
 	return nil
 }
 
@@ -235,6 +822,10 @@ func pgCtlStatus(config Config) (*pgStatus, error) {
 	cmd.Stdout = buf
 	cmd.Stderr = buf
 
+	if err := config.faults.checkCommand(cmd); err != nil {
+		return nil, fmt.Errorf("%s - %w", cmd.String(), err)
+	}
+
 	err := cmd.Start()
 
 	if err != nil {
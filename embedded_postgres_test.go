@@ -10,6 +10,7 @@ import (
 	"os/user"
 	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"testing"
@@ -101,7 +102,7 @@ func Test_ErrorWhenUnableToUnArchiveFile_WrongFormat(t *testing.T) {
 		}
 	}
 
-	assert.EqualError(t, err, fmt.Sprintf(`unable to extract postgres archive %s to %s, if running parallel tests, configure RuntimePath to isolate testing directories, xz: file format not recognized`, jarFile, filepath.Join(filepath.Dir(jarFile), "extracted")))
+	assert.EqualError(t, err, fmt.Sprintf(`unable to extract postgres archive %s to %s, if running parallel tests, configure RuntimePath to isolate testing directories, xz: file format not recognized`, jarFile, filepath.Join(filepath.Dir(jarFile), "extracted", strconv.Itoa(os.Getpid()))))
 }
 
 func Test_ErrorWhenUnableToInitDatabase(t *testing.T) {
@@ -124,7 +125,7 @@ func Test_ErrorWhenUnableToInitDatabase(t *testing.T) {
 		return jarFile, true
 	}
 
-	database.initDatabase = func(binaryExtractLocation, runtimePath, dataLocation, username, password, locale string, logger *os.File) error {
+	database.initDatabase = func(binaryExtractLocation, runtimePath, dataLocation, username, password, locale string, extraArgs []string, logger *os.File) error {
 		return errors.New("ah it did not work")
 	}
 
@@ -157,7 +158,7 @@ func Test_ErrorWhenUnableToCreateDatabase(t *testing.T) {
 		RuntimePath(extractPath).
 		StartTimeout(10 * time.Second))
 
-	database.createDatabase = func(ctx context.Context, port uint32, username, password, database string) error {
+	database.createDatabase = func(ctx context.Context, port uint32, username, password, database string, options []string) error {
 		return errors.New("ah noes")
 	}
 
@@ -177,13 +178,14 @@ func Test_TimesOutWhenCannotStart(t *testing.T) {
 		Database("something-fancy").
 		StartTimeout(500 * time.Millisecond))
 
-	database.createDatabase = func(ctx context.Context, port uint32, username, password, database string) error {
+	database.createDatabase = func(ctx context.Context, port uint32, username, password, database string, options []string) error {
 		return nil
 	}
 
 	err := database.Start()
 
-	assert.EqualError(t, err, "timed out waiting for database to become available")
+	require.NotNil(t, err)
+	assert.True(t, strings.HasPrefix(err.Error(), "timed out waiting for database to become available"), err.Error())
 }
 
 func Test_ErrorWhenStopCalledBeforeStart(t *testing.T) {
@@ -227,7 +229,7 @@ func Test_ErrorWhenCannotStartPostgresProcess(t *testing.T) {
 		return jarFile, true
 	}
 
-	database.initDatabase = func(binaryExtractLocation, runtimePath, dataLocation, username, password, locale string, logger *os.File) error {
+	database.initDatabase = func(binaryExtractLocation, runtimePath, dataLocation, username, password, locale string, extraArgs []string, logger *os.File) error {
 		_, _ = logger.Write([]byte("ah it did not work"))
 		return nil
 	}
@@ -723,7 +725,7 @@ func Test_PrefetchedBinaries(t *testing.T) {
 	}
 
 	cacheLocation, _ := database.cacheLocator()
-	if err := decompressTarXz(defaultTarReader, cacheLocation, binTempDir); err != nil {
+	if err := decompressTarXz(DefaultTarReader, cacheLocation, binTempDir); err != nil {
 		panic(err)
 	}
 
@@ -784,3 +786,137 @@ func Test_RunningInParallel(t *testing.T) {
 
 	waitGroup.Wait()
 }
+
+func Test_Restart_ErrorWhenNotStarted(t *testing.T) {
+	database := NewDatabase()
+
+	assert.EqualError(t, database.Restart(), "data directory has not been initialised, call Start first")
+}
+
+func Test_Restart(t *testing.T) {
+	database := NewDatabase(DefaultConfig().RuntimePath(t.TempDir()))
+	require.NoError(t, database.Start())
+
+	defer func() {
+		assert.NoError(t, database.Stop())
+	}()
+
+	require.NoError(t, database.execStatements(context.Background(), "postgres", "CREATE TABLE beer (name text)"))
+	require.NoError(t, database.WriteConfSnippet("10-memory", map[string]string{"work_mem": "8MB"}))
+
+	require.NoError(t, database.Restart())
+
+	db, err := database.openDB("postgres")
+	require.NoError(t, err)
+
+	defer func() {
+		assert.NoError(t, db.Close())
+	}()
+
+	var workMem string
+	require.NoError(t, db.QueryRow("SELECT current_setting('work_mem')").Scan(&workMem))
+	assert.Equal(t, "8MB", workMem)
+
+	_, err = db.Exec("SELECT * FROM beer")
+	assert.NoError(t, err)
+
+	// restarting a stopped server starts it with the same data directory
+	require.NoError(t, database.Stop())
+	require.NoError(t, database.Restart())
+	require.NoError(t, database.execStatements(context.Background(), "postgres", "SELECT * FROM beer"))
+}
+
+func Test_ReloadConfig_ErrorWhenNotStarted(t *testing.T) {
+	assert.EqualError(t, NewDatabase().ReloadConfig(), "server has not been started")
+}
+
+func Test_ReloadConfig_RunsPgCtlReload(t *testing.T) {
+	binariesPath := t.TempDir()
+	argsFile := filepath.Join(t.TempDir(), "args")
+	require.NoError(t, os.MkdirAll(filepath.Join(binariesPath, "bin"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(binariesPath, "bin", "pg_ctl"),
+		[]byte("#!/bin/sh\necho \"$@\" > "+argsFile+"\n"), 0755))
+
+	database := NewDatabase(DefaultConfig().BinariesPath(binariesPath).DataPath("/data"))
+	database.started = true
+
+	require.NoError(t, database.ReloadConfig())
+
+	args, err := os.ReadFile(argsFile)
+	require.NoError(t, err)
+	assert.Equal(t, "reload -D /data\n", string(args))
+
+	require.NoError(t, os.WriteFile(filepath.Join(binariesPath, "bin", "pg_ctl"),
+		[]byte("#!/bin/sh\necho 'pg_ctl: could not send reload signal' >&2\nexit 1\n"), 0755))
+
+	err = database.ReloadConfig()
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "pg_ctl: could not send reload signal")
+}
+
+func Test_ReloadConfig(t *testing.T) {
+	database := NewDatabase(DefaultConfig().RuntimePath(t.TempDir()))
+	require.NoError(t, database.Start())
+
+	defer func() {
+		assert.NoError(t, database.Stop())
+	}()
+
+	require.NoError(t, database.WriteConfSnippet("10-memory", map[string]string{"work_mem": "8MB"}))
+	require.NoError(t, database.ReloadConfig())
+
+	db, err := database.openDB("postgres")
+	require.NoError(t, err)
+
+	defer func() {
+		assert.NoError(t, db.Close())
+	}()
+
+	assert.Eventually(t, func() bool {
+		var workMem string
+		return db.QueryRow("SELECT current_setting('work_mem')").Scan(&workMem) == nil && workMem == "8MB"
+	}, 5*time.Second, 50*time.Millisecond)
+}
+
+func Test_freePort(t *testing.T) {
+	port, err := freePort()
+	require.NoError(t, err)
+
+	assert.NotZero(t, port)
+	assert.NoError(t, ensurePortAvailable(port))
+}
+
+func Test_Port(t *testing.T) {
+	assert.Equal(t, uint32(5432), NewDatabase().Port())
+	assert.Equal(t, uint32(9876), NewDatabase(DefaultConfig().Port(9876)).Port())
+	assert.Zero(t, NewDatabase(DefaultConfig().Port(0)).Port())
+}
+
+func Test_DynamicPort(t *testing.T) {
+	first := NewDatabase(DefaultConfig().RuntimePath(t.TempDir()).Port(0))
+	require.NoError(t, first.Start())
+
+	defer func() {
+		assert.NoError(t, first.Stop())
+	}()
+
+	second := NewDatabase(DefaultConfig().RuntimePath(t.TempDir()).Port(0))
+	require.NoError(t, second.Start())
+
+	defer func() {
+		assert.NoError(t, second.Stop())
+	}()
+
+	assert.NotZero(t, first.Port())
+	assert.NotZero(t, second.Port())
+	assert.NotEqual(t, first.Port(), second.Port())
+
+	db, err := sql.Open("postgres", fmt.Sprintf("host=localhost port=%d user=postgres password=postgres dbname=postgres sslmode=disable", second.Port()))
+	require.NoError(t, err)
+
+	defer func() {
+		assert.NoError(t, db.Close())
+	}()
+
+	assert.NoError(t, db.Ping())
+}
@@ -0,0 +1,46 @@
+// Package embeddedatlas runs Atlas versioned migrations against an embedded Postgres server as it starts. Migrations
+// are applied with the atlas CLI, which must be installed on the PATH, so this module adds no dependencies beyond
+// embedded-postgres itself.
+package embeddedatlas
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	embeddedpostgres "github.com/fergusstrange/embedded-postgres"
+)
+
+// Apply returns a runner for Config.Migrations that runs "atlas migrate apply" for the migration directory dirURL,
+// such as "file://migrations", passing any further args, such as "--baseline", to the command.
+func Apply(dirURL string, args ...string) embeddedpostgres.MigrationRunner {
+	args = append([]string(nil), args...)
+
+	return embeddedpostgres.MigrationFunc(func(ctx context.Context, dsn string) error {
+		binary, err := exec.LookPath("atlas")
+		if err != nil {
+			return fmt.Errorf("unable to find the atlas CLI: %w", err)
+		}
+
+		cmd := exec.CommandContext(ctx, binary, applyArgs(dirURL, dsn, args)...)
+
+		var output bytes.Buffer
+		cmd.Stdout = &output
+		cmd.Stderr = &output
+
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("unable to apply migrations from %s: %w\n%s", dirURL, err, strings.TrimSpace(output.String()))
+		}
+
+		return nil
+	})
+}
+
+func applyArgs(dirURL, dsn string, args []string) []string {
+	// atlas identifies the driver by the postgres:// scheme
+	url := "postgres://" + strings.TrimPrefix(dsn, "postgresql://")
+
+	return append([]string{"migrate", "apply", "--url", url, "--dir", dirURL}, args...)
+}
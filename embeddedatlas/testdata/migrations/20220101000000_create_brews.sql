@@ -0,0 +1 @@
+CREATE TABLE brews (name text);
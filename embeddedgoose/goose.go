@@ -0,0 +1,41 @@
+// Package embeddedgoose runs goose migrations against an embedded Postgres server as it starts. It lives in its own
+// module so that the embedded-postgres module itself does not depend on goose.
+package embeddedgoose
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	embeddedpostgres "github.com/fergusstrange/embedded-postgres"
+	_ "github.com/lib/pq" // registers the postgres driver
+	"github.com/pressly/goose/v3"
+)
+
+// Up returns a runner for Config.Migrations that applies all pending goose migrations in dir.
+func Up(dir string) embeddedpostgres.MigrationRunner {
+	return embeddedpostgres.MigrationFunc(func(ctx context.Context, dsn string) error {
+		db, err := sql.Open("postgres", dsn)
+		if err != nil {
+			return err
+		}
+
+		defer func() {
+			_ = db.Close()
+		}()
+
+		if err := db.PingContext(ctx); err != nil {
+			return fmt.Errorf("unable to connect to run migrations from %s: %w", dir, err)
+		}
+
+		if err := goose.SetDialect("postgres"); err != nil {
+			return err
+		}
+
+		if err := goose.Up(db, dir); err != nil {
+			return fmt.Errorf("unable to apply migrations from %s: %w", dir, err)
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,5 @@
+-- +goose Up
+CREATE TABLE brews (name text);
+
+-- +goose Down
+DROP TABLE brews;
@@ -0,0 +1,22 @@
+module github.com/fergusstrange/embedded-postgres/embeddedmigrate
+
+go 1.18
+
+replace github.com/fergusstrange/embedded-postgres => ../
+
+require (
+	github.com/fergusstrange/embedded-postgres v0.0.0
+	github.com/golang-migrate/migrate/v4 v4.15.2
+	github.com/stretchr/testify v1.7.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/hashicorp/errwrap v1.1.0 // indirect
+	github.com/hashicorp/go-multierror v1.1.1 // indirect
+	github.com/lib/pq v1.10.4 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/xi2/xz v0.0.0-20171230120015-48954b6210f8 // indirect
+	go.uber.org/atomic v1.7.0 // indirect
+	gopkg.in/yaml.v3 v3.0.0 // indirect
+)
@@ -0,0 +1,46 @@
+// Package embeddedmigrate runs golang-migrate migrations against an embedded Postgres server as it starts. It lives in
+// its own module so that the embedded-postgres module itself does not depend on golang-migrate.
+package embeddedmigrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	embeddedpostgres "github.com/fergusstrange/embedded-postgres"
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres" // registers the postgres and postgresql schemes
+	_ "github.com/golang-migrate/migrate/v4/source/file"       // registers the file source
+)
+
+// Up returns a migration for Config.Migrations that applies all up migrations from sourceURL, such as
+// "file://migrations". Other sources can be used by importing their golang-migrate driver.
+func Up(sourceURL string) embeddedpostgres.MigrationFunc {
+	return func(ctx context.Context, connectionString string) error {
+		m, err := migrate.New(sourceURL, connectionString)
+		if err != nil {
+			return fmt.Errorf("unable to load migrations from %s: %w", sourceURL, err)
+		}
+
+		defer func() {
+			_, _ = m.Close()
+		}()
+
+		done := make(chan struct{})
+		defer close(done)
+
+		go func() {
+			select {
+			case <-ctx.Done():
+				m.GracefulStop <- true
+			case <-done:
+			}
+		}()
+
+		if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+			return fmt.Errorf("unable to apply migrations from %s: %w", sourceURL, err)
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,40 @@
+package embeddedmigrate
+
+import (
+	"testing"
+
+	embeddedpostgres "github.com/fergusstrange/embedded-postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Up(t *testing.T) {
+	database := embeddedpostgres.NewDatabase(embeddedpostgres.DefaultConfig().
+		RuntimePath(t.TempDir()).
+		Port(0).
+		Migrations(Up("file://testdata/migrations")))
+	require.NoError(t, database.Start())
+
+	defer func() {
+		assert.NoError(t, database.Stop())
+	}()
+
+	db, err := database.Open("postgres")
+	require.NoError(t, err)
+
+	var count int
+	require.NoError(t, db.QueryRow("SELECT count(*) FROM brews").Scan(&count))
+	assert.Equal(t, 0, count)
+}
+
+func Test_Up_ErrorWhenMigrationFails(t *testing.T) {
+	database := embeddedpostgres.NewDatabase(embeddedpostgres.DefaultConfig().
+		RuntimePath(t.TempDir()).
+		Port(0).
+		Migrations(Up("file://testdata/missing")))
+
+	err := database.Start()
+
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "unable to run migrations: unable to load migrations from file://testdata/missing")
+}
@@ -0,0 +1,103 @@
+// Package embeddedotel traces the Start and Stop of embedded Postgres servers with OpenTelemetry, so that the time
+// they take shows up in the traces of test and development environments. It lives in its own module so that the
+// embedded-postgres module itself does not depend on OpenTelemetry.
+package embeddedotel
+
+import (
+	"context"
+	"time"
+
+	embeddedpostgres "github.com/fergusstrange/embedded-postgres"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/fergusstrange/embedded-postgres/embeddedotel"
+
+// Option customises the tracing of Start and Stop.
+type Option func(*options)
+
+type options struct {
+	tracerProvider trace.TracerProvider
+}
+
+// WithTracerProvider traces with provider rather than the global TracerProvider.
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(o *options) {
+		o.tracerProvider = provider
+	}
+}
+
+func tracer(opts []Option) trace.Tracer {
+	o := &options{tracerProvider: otel.GetTracerProvider()}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o.tracerProvider.Tracer(instrumentationName)
+}
+
+// Start starts ep within an "embedded-postgres.Start" span, which has a child span for each phase that Start spent
+// time in, such as the download of the binaries and initdb. The phases are taken from ep.Timings once Start returns
+// and laid out in order from the start of the span, so their offsets are approximate whereas their durations are not.
+func Start(ctx context.Context, ep *embeddedpostgres.EmbeddedPostgres, opts ...Option) error {
+	t := tracer(opts)
+	ctx, span := t.Start(ctx, "embedded-postgres.Start")
+	startedAt := time.Now()
+
+	err := ep.Start()
+
+	recordPhases(ctx, t, startedAt, ep.Timings())
+	span.SetAttributes(attribute.Int("embedded_postgres.port", int(ep.Port())))
+	endSpan(span, err)
+
+	return err
+}
+
+// Stop stops ep within an "embedded-postgres.Stop" span.
+func Stop(ctx context.Context, ep *embeddedpostgres.EmbeddedPostgres, opts ...Option) error {
+	_, span := tracer(opts).Start(ctx, "embedded-postgres.Stop")
+
+	err := ep.Stop()
+	endSpan(span, err)
+
+	return err
+}
+
+// recordPhases adds a span for each phase with a duration, one after another from startedAt.
+func recordPhases(ctx context.Context, tracer trace.Tracer, startedAt time.Time, timings embeddedpostgres.Timings) {
+	phases := []struct {
+		name     string
+		duration time.Duration
+	}{
+		{"embedded-postgres.download", timings.Download},
+		{"embedded-postgres.extraction", timings.Extraction},
+		{"embedded-postgres.initdb", timings.InitDB},
+		{"embedded-postgres.server_start", timings.ServerStart},
+		{"embedded-postgres.provision", timings.Provision},
+		{"embedded-postgres.health_check", timings.HealthCheck},
+	}
+
+	at := startedAt
+
+	for _, phase := range phases {
+		if phase.duration <= 0 {
+			continue
+		}
+
+		_, span := tracer.Start(ctx, phase.name, trace.WithTimestamp(at))
+		at = at.Add(phase.duration)
+		span.End(trace.WithTimestamp(at))
+	}
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
@@ -0,0 +1,67 @@
+package embeddedotel
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	embeddedpostgres "github.com/fergusstrange/embedded-postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func Test_recordPhases(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	startedAt := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	recordPhases(context.Background(), provider.Tracer("test"), startedAt, embeddedpostgres.Timings{
+		Extraction:  2 * time.Second,
+		ServerStart: time.Second,
+	})
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+
+	assert.Equal(t, "embedded-postgres.extraction", spans[0].Name())
+	assert.Equal(t, startedAt, spans[0].StartTime())
+	assert.Equal(t, startedAt.Add(2*time.Second), spans[0].EndTime())
+
+	assert.Equal(t, "embedded-postgres.server_start", spans[1].Name())
+	assert.Equal(t, startedAt.Add(2*time.Second), spans[1].StartTime())
+	assert.Equal(t, startedAt.Add(3*time.Second), spans[1].EndTime())
+}
+
+func Test_StartStop(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	database := embeddedpostgres.NewDatabase(embeddedpostgres.DefaultConfig().
+		RuntimePath(t.TempDir()).
+		Port(0))
+	require.NoError(t, Start(context.Background(), database, WithTracerProvider(provider)))
+	require.NoError(t, Stop(context.Background(), database, WithTracerProvider(provider)))
+
+	names := map[string]bool{}
+	for _, span := range recorder.Ended() {
+		names[span.Name()] = true
+	}
+
+	assert.True(t, names["embedded-postgres.Start"])
+	assert.True(t, names["embedded-postgres.initdb"])
+	assert.True(t, names["embedded-postgres.server_start"])
+	assert.True(t, names["embedded-postgres.Stop"])
+}
+
+func Test_Stop_RecordsError(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	err := Stop(context.Background(), embeddedpostgres.NewDatabase(), WithTracerProvider(provider))
+
+	assert.EqualError(t, err, "server has not been started")
+	require.Len(t, recorder.Ended(), 1)
+	assert.Equal(t, "server has not been started", recorder.Ended()[0].Status().Description)
+}
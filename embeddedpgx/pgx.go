@@ -0,0 +1,50 @@
+// Package embeddedpgx connects pgx v5 connection pools to an embedded Postgres server. It lives in its own module so
+// that the embedded-postgres module itself does not depend on pgx.
+package embeddedpgx
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	embeddedpostgres "github.com/fergusstrange/embedded-postgres"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PgxConfig returns a pgxpool configuration for the running server, built from its connection string with opts. The
+// pool is sized for test workloads, keeping few connections open and closing idle ones quickly so that the server can
+// be stopped without waiting on them.
+func PgxConfig(ep *embeddedpostgres.EmbeddedPostgres, opts ...embeddedpostgres.ConnectionOption) (*pgxpool.Config, error) {
+	config, err := pgxpool.ParseConfig(ep.ConnectionString(opts...))
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse connection string: %w", err)
+	}
+
+	config.MinConns = 0
+	config.MaxConns = 10
+	config.MaxConnIdleTime = 30 * time.Second
+	config.HealthCheckPeriod = 5 * time.Second
+
+	return config, nil
+}
+
+// NewPool opens a pgxpool connection pool to the running server using PgxConfig, returning once a connection has been
+// established.
+func NewPool(ctx context.Context, ep *embeddedpostgres.EmbeddedPostgres, opts ...embeddedpostgres.ConnectionOption) (*pgxpool.Pool, error) {
+	config, err := PgxConfig(ep, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	pool, err := pgxpool.NewWithConfig(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open connection pool: %w", err)
+	}
+
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("unable to connect to postgres: %w", err)
+	}
+
+	return pool, nil
+}
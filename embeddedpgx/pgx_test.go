@@ -0,0 +1,50 @@
+package embeddedpgx
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	embeddedpostgres "github.com/fergusstrange/embedded-postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_PgxConfig(t *testing.T) {
+	database := embeddedpostgres.NewDatabase(embeddedpostgres.DefaultConfig().
+		Port(9876).
+		Username("gin").
+		Password("wine").
+		Database("beer"))
+
+	config, err := PgxConfig(database)
+	require.NoError(t, err)
+
+	assert.Equal(t, "localhost", config.ConnConfig.Host)
+	assert.Equal(t, uint16(9876), config.ConnConfig.Port)
+	assert.Equal(t, "gin", config.ConnConfig.User)
+	assert.Equal(t, "wine", config.ConnConfig.Password)
+	assert.Equal(t, "beer", config.ConnConfig.Database)
+	assert.Equal(t, int32(10), config.MaxConns)
+	assert.Equal(t, 30*time.Second, config.MaxConnIdleTime)
+}
+
+func Test_NewPool(t *testing.T) {
+	database := embeddedpostgres.NewDatabase(embeddedpostgres.DefaultConfig().RuntimePath(t.TempDir()).Port(0))
+	require.NoError(t, database.Start())
+
+	defer func() {
+		assert.NoError(t, database.Stop())
+	}()
+
+	ctx := context.Background()
+
+	pool, err := NewPool(ctx, database)
+	require.NoError(t, err)
+
+	defer pool.Close()
+
+	var one int
+	require.NoError(t, pool.QueryRow(ctx, "SELECT 1").Scan(&one))
+	assert.Equal(t, 1, one)
+}
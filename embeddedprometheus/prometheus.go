@@ -0,0 +1,142 @@
+// Package embeddedprometheus exports Prometheus metrics for the lifecycle of embedded Postgres servers, for services
+// that keep them running for a long time rather than for the duration of a test. It lives in its own module so that
+// the embedded-postgres module itself does not depend on the Prometheus client.
+package embeddedprometheus
+
+import (
+	"sync"
+
+	embeddedpostgres "github.com/fergusstrange/embedded-postgres"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Collector is a prometheus.Collector of metrics for the servers it watches.
+type Collector struct {
+	mu            sync.Mutex
+	watched       map[*embeddedpostgres.EmbeddedPostgres]chan struct{}
+	running       map[*embeddedpostgres.EmbeddedPostgres]bool
+	downloading   map[*embeddedpostgres.EmbeddedPostgres]bool
+	runningDesc   *prometheus.Desc
+	startDuration prometheus.Histogram
+	cacheHits     prometheus.Counter
+	cacheMisses   prometheus.Counter
+	crashes       prometheus.Counter
+}
+
+// NewCollector returns a Collector watching no servers, to be registered with a prometheus.Registerer.
+func NewCollector() *Collector {
+	return &Collector{
+		watched:     map[*embeddedpostgres.EmbeddedPostgres]chan struct{}{},
+		running:     map[*embeddedpostgres.EmbeddedPostgres]bool{},
+		downloading: map[*embeddedpostgres.EmbeddedPostgres]bool{},
+		runningDesc: prometheus.NewDesc(
+			"embedded_postgres_running_instances",
+			"Number of watched embedded Postgres servers that are running.",
+			nil, nil),
+		startDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "embedded_postgres_start_duration_seconds",
+			Help:    "Time taken by Start and Restart of embedded Postgres servers that started successfully.",
+			Buckets: prometheus.ExponentialBuckets(0.1, 2, 10),
+		}),
+		cacheHits: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "embedded_postgres_binary_cache_hits_total",
+			Help: "Number of times Postgres binaries were extracted from the cache without being downloaded.",
+		}),
+		cacheMisses: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "embedded_postgres_binary_cache_misses_total",
+			Help: "Number of times Postgres binaries were downloaded as they were not cached.",
+		}),
+		crashes: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "embedded_postgres_crashes_total",
+			Help: "Number of times an embedded Postgres server exited without being stopped.",
+		}),
+	}
+}
+
+// Watch collects metrics for ep by receiving from ep.Events, which should therefore not be received from elsewhere.
+// It should be called before Start so that the binary cache is accounted for.
+func (c *Collector) Watch(ep *embeddedpostgres.EmbeddedPostgres) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.watched[ep]; ok {
+		return
+	}
+
+	done := make(chan struct{})
+	c.watched[ep] = done
+
+	events := ep.Events()
+
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case event := <-events:
+				c.handle(ep, event)
+			}
+		}
+	}()
+}
+
+// Unwatch stops collecting metrics for ep, which is no longer counted as running.
+func (c *Collector) Unwatch(ep *embeddedpostgres.EmbeddedPostgres) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if done, ok := c.watched[ep]; ok {
+		close(done)
+	}
+
+	delete(c.watched, ep)
+	delete(c.running, ep)
+	delete(c.downloading, ep)
+}
+
+func (c *Collector) handle(ep *embeddedpostgres.EmbeddedPostgres, event embeddedpostgres.Event) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch event.Type {
+	case embeddedpostgres.DownloadStarted:
+		c.downloading[ep] = true
+		c.cacheMisses.Inc()
+	case embeddedpostgres.Extracted:
+		if !c.downloading[ep] {
+			c.cacheHits.Inc()
+		}
+
+		delete(c.downloading, ep)
+	case embeddedpostgres.ServerReady:
+		c.running[ep] = true
+		c.startDuration.Observe(ep.Timings().Total.Seconds())
+	case embeddedpostgres.Stopped:
+		delete(c.running, ep)
+	case embeddedpostgres.Crashed:
+		delete(c.running, ep)
+		c.crashes.Inc()
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(descs chan<- *prometheus.Desc) {
+	descs <- c.runningDesc
+	c.startDuration.Describe(descs)
+	c.cacheHits.Describe(descs)
+	c.cacheMisses.Describe(descs)
+	c.crashes.Describe(descs)
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(metrics chan<- prometheus.Metric) {
+	c.mu.Lock()
+	running := len(c.running)
+	c.mu.Unlock()
+
+	metrics <- prometheus.MustNewConstMetric(c.runningDesc, prometheus.GaugeValue, float64(running))
+	c.startDuration.Collect(metrics)
+	c.cacheHits.Collect(metrics)
+	c.cacheMisses.Collect(metrics)
+	c.crashes.Collect(metrics)
+}
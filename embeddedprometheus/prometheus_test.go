@@ -0,0 +1,72 @@
+package embeddedprometheus
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	embeddedpostgres "github.com/fergusstrange/embedded-postgres"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Collector_handle(t *testing.T) {
+	collector := NewCollector()
+	first := embeddedpostgres.NewDatabase()
+	second := embeddedpostgres.NewDatabase()
+
+	for _, event := range []embeddedpostgres.EventType{
+		embeddedpostgres.DownloadStarted,
+		embeddedpostgres.Extracted,
+		embeddedpostgres.ServerReady,
+	} {
+		collector.handle(first, embeddedpostgres.Event{Type: event})
+	}
+
+	for _, event := range []embeddedpostgres.EventType{
+		embeddedpostgres.Extracted,
+		embeddedpostgres.ServerReady,
+		embeddedpostgres.Crashed,
+	} {
+		collector.handle(second, embeddedpostgres.Event{Type: event})
+	}
+
+	assert.Equal(t, float64(1), testutil.ToFloat64(collector.cacheHits))
+	assert.Equal(t, float64(1), testutil.ToFloat64(collector.cacheMisses))
+	assert.Equal(t, float64(1), testutil.ToFloat64(collector.crashes))
+
+	assert.True(t, runningInstances(collector, 1))
+}
+
+func Test_Collector_Watch(t *testing.T) {
+	collector := NewCollector()
+	database := embeddedpostgres.NewDatabase(embeddedpostgres.DefaultConfig().
+		RuntimePath(t.TempDir()).
+		Port(0))
+	collector.Watch(database)
+
+	defer collector.Unwatch(database)
+
+	require.NoError(t, database.Start())
+
+	assert.Eventually(t, func() bool {
+		return runningInstances(collector, 1) &&
+			testutil.ToFloat64(collector.cacheHits)+testutil.ToFloat64(collector.cacheMisses) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, database.Stop())
+
+	assert.Eventually(t, func() bool {
+		return runningInstances(collector, 0)
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func runningInstances(collector *Collector, expected int) bool {
+	return testutil.CollectAndCompare(collector, strings.NewReader(fmt.Sprintf(`
+# HELP embedded_postgres_running_instances Number of watched embedded Postgres servers that are running.
+# TYPE embedded_postgres_running_instances gauge
+embedded_postgres_running_instances %d
+`, expected)), "embedded_postgres_running_instances") == nil
+}
@@ -0,0 +1,45 @@
+package embeddedpostgres
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// binaryStrategies returns the strategies locating the cached binaries archive for the configured version on this
+// platform and downloading it into the cache.
+func binaryStrategies(config Config) (CacheLocator, RemoteFetchStrategy) {
+	versionStrategy := defaultVersionStrategy(
+		config,
+		runtime.GOOS,
+		runtime.GOARCH,
+		linuxMachineName,
+		shouldUseAlpineLinuxBuild,
+	)
+	cacheLocator := defaultCacheLocator(config.cachePath, versionStrategy)
+
+	return cacheLocator, remoteFetchStrategyWithProgress(config.binaryRepositoryURL, versionStrategy, cacheLocator, config.downloadProgress)
+}
+
+// EnsureBinaries downloads the binaries archive of the configured version for this platform into the cache, verifying
+// its checksum where the repository publishes one, without extracting or starting anything, and returns its location.
+// An archive already in the cache is left as is unless it no longer matches the checksum recorded when it was
+// downloaded, in which case it is downloaded again. Build pipelines can call it in a step of their own so that tests
+// later start from the warm cache without network access.
+func EnsureBinaries(config Config) (string, error) {
+	cacheLocator, remoteFetchStrategy := binaryStrategies(config)
+
+	// lock to prevent collisions with downloads by instances starting in this process
+	mu.Lock()
+	defer mu.Unlock()
+
+	cacheLocation, cacheExists := cacheLocator()
+	if cacheExists && verifyCachedArchive(cacheLocation) == nil {
+		return cacheLocation, nil
+	}
+
+	if err := remoteFetchStrategy(); err != nil {
+		return "", fmt.Errorf("unable to download binaries for Postgres %s: %w", config.version, err)
+	}
+
+	return cacheLocation, nil
+}
@@ -0,0 +1,83 @@
+package embeddedpostgres
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_EnsureBinaries(t *testing.T) {
+	jarFile, cleanUp := createTempZipArchive()
+	defer cleanUp()
+
+	jar, err := os.ReadFile(jarFile)
+	require.NoError(t, err)
+
+	var requests int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+
+		if strings.HasSuffix(r.RequestURI, ".sha256") {
+			checksum := sha256.Sum256(jar)
+			_, _ = w.Write([]byte(hex.EncodeToString(checksum[:])))
+
+			return
+		}
+
+		_, _ = w.Write(jar)
+	}))
+	defer server.Close()
+
+	cacheDir := t.TempDir()
+	config := DefaultConfig().CachePath(cacheDir).BinaryRepositoryURL(server.URL + "/maven2")
+
+	location, err := EnsureBinaries(config)
+	require.NoError(t, err)
+	assert.Equal(t, cacheDir, filepath.Dir(location))
+	assert.FileExists(t, location)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+
+	// the cached archive is not downloaded again
+	cachedLocation, err := EnsureBinaries(config)
+	require.NoError(t, err)
+	assert.Equal(t, location, cachedLocation)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+
+	// a corrupted archive is downloaded again
+	archive, err := os.ReadFile(location)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(location, archive[:len(archive)/2], 0600))
+
+	_, err = EnsureBinaries(config)
+	require.NoError(t, err)
+	assert.Equal(t, int32(4), atomic.LoadInt32(&requests))
+
+	redownloaded, err := os.ReadFile(location)
+	require.NoError(t, err)
+	assert.Equal(t, archive, redownloaded)
+}
+
+func Test_EnsureBinaries_ErrorWhenNotFound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	location, err := EnsureBinaries(DefaultConfig().
+		Version(V14).
+		CachePath(t.TempDir()).
+		BinaryRepositoryURL(server.URL + "/maven2"))
+
+	assert.Empty(t, location)
+	assert.EqualError(t, err, "unable to download binaries for Postgres 14.8.0: no version found matching 14.8.0")
+}
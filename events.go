@@ -0,0 +1,79 @@
+package embeddedpostgres
+
+import "time"
+
+// EventType identifies the change in the state of a server an Event reports.
+type EventType string
+
+const (
+	// DownloadStarted is emitted when the Postgres binaries are not cached and are about to be downloaded.
+	DownloadStarted EventType = "DownloadStarted"
+	// Extracted is emitted once the Postgres binaries and any extension archives have been extracted.
+	Extracted EventType = "Extracted"
+	// InitDBCompleted is emitted once a new data directory has been initialised.
+	InitDBCompleted EventType = "InitDBCompleted"
+	// ServerReady is emitted once Start or Restart has finished and the server accepts connections.
+	ServerReady EventType = "ServerReady"
+	// Stopped is emitted once the server has been stopped, whether by Stop or by a watcher such as IdleTimeout.
+	Stopped EventType = "Stopped"
+	// Crashed is emitted when the server process exits without being stopped.
+	Crashed EventType = "Crashed"
+)
+
+// eventBufferSize is the number of events held for a receiver before further events are dropped.
+const eventBufferSize = 64
+
+// crashCheckInterval is how often a running server is checked for having exited.
+const crashCheckInterval = 100 * time.Millisecond
+
+// Event reports a change in the state of a server, as received from EmbeddedPostgres.Events.
+type Event struct {
+	Type EventType
+	Time time.Time
+	// Err is the error the server process exited with for Crashed events, where known.
+	Err error
+}
+
+// Events returns a channel receiving the lifecycle events of the server from the time of the first call, so it should
+// be called before Start to receive them all. Events are dropped rather than holding up the server when the channel is
+// full, and the channel is never closed.
+func (ep *EmbeddedPostgres) Events() <-chan Event {
+	ep.eventsMu.Lock()
+	defer ep.eventsMu.Unlock()
+
+	if ep.events == nil {
+		ep.events = make(chan Event, eventBufferSize)
+	}
+
+	return ep.events
+}
+
+// emit sends an event to the channel returned by Events, if it has been called.
+func (ep *instanceState) emit(eventType EventType, err error) {
+	ep.eventsMu.Lock()
+	defer ep.eventsMu.Unlock()
+
+	if ep.events == nil {
+		return
+	}
+
+	select {
+	case ep.events <- Event{Type: eventType, Time: time.Now(), Err: err}:
+	default:
+	}
+}
+
+// watchCrash emits Crashed and cleans up after the server should its process exit whilst it is running.
+func (ep *instanceState) watchCrash() {
+	process := ep.cmd
+
+	ep.watch(crashCheckInterval, func(now time.Time) bool {
+		select {
+		case <-process.exited():
+			ep.emit(Crashed, process.exitErr)
+			return true
+		default:
+			return false
+		}
+	})
+}
@@ -0,0 +1,51 @@
+package embeddedpostgres
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Events(t *testing.T) {
+	database := NewDatabase(DefaultConfig().RuntimePath(t.TempDir()).Port(0))
+	events := database.Events()
+
+	require.NoError(t, database.Start())
+	require.NoError(t, database.Stop())
+
+	var types []EventType
+
+	for len(events) > 0 {
+		event := <-events
+		assert.False(t, event.Time.IsZero())
+		types = append(types, event.Type)
+	}
+
+	assert.Subset(t, types, []EventType{Extracted, InitDBCompleted, ServerReady, Stopped})
+	assert.Equal(t, Stopped, types[len(types)-1])
+}
+
+func Test_emit_OnlyOnceEventsCalled(t *testing.T) {
+	database := NewDatabase()
+	database.emit(DownloadStarted, nil)
+
+	events := database.Events()
+	assert.True(t, events == database.Events())
+
+	database.emit(ServerReady, nil)
+
+	require.Len(t, events, 1)
+	assert.Equal(t, ServerReady, (<-events).Type)
+}
+
+func Test_emit_DropsWhenFull(t *testing.T) {
+	database := NewDatabase()
+	events := database.Events()
+
+	for i := 0; i < eventBufferSize+10; i++ {
+		database.emit(ServerReady, nil)
+	}
+
+	assert.Len(t, events, eventBufferSize)
+}
@@ -0,0 +1,17 @@
+package embeddedpostgres
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+)
+
+// noExecError explains a failure to execute a file within dir, which otherwise surfaces as an opaque permission denied
+// error from initdb.
+func noExecError(dir string, err error) error {
+	if !errors.Is(err, fs.ErrPermission) {
+		return nil
+	}
+
+	return fmt.Errorf("%s does not allow executing binaries, it may be mounted noexec or restricted by SELinux or AppArmor, configure ExecDir or BinariesPath with a location that does: %w", dir, err)
+}
@@ -0,0 +1,43 @@
+package embeddedpostgres
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_checkExecutable(t *testing.T) {
+	dir := t.TempDir()
+
+	assert.NoError(t, checkExecutable(dir))
+
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func Test_checkExecutable_NotWritable(t *testing.T) {
+	assert.NoError(t, checkExecutable(filepath.Join(t.TempDir(), "does-not-exist")))
+}
+
+func Test_noExecError(t *testing.T) {
+	err := noExecError("/tmp", &os.PathError{Op: "fork/exec", Path: "/tmp/exec_probe_1", Err: syscall.EACCES})
+
+	assert.EqualError(t, err, "/tmp does not allow executing binaries, it may be mounted noexec or restricted by SELinux or AppArmor, configure ExecDir or BinariesPath with a location that does: fork/exec /tmp/exec_probe_1: permission denied")
+	assert.True(t, errors.Is(err, syscall.EACCES))
+
+	assert.NoError(t, noExecError("/tmp", nil))
+	assert.NoError(t, noExecError("/tmp", errors.New("exit status 1")))
+}
+
+func Test_defaultRuntimePath_ExecDir(t *testing.T) {
+	pid := strconv.Itoa(os.Getpid())
+
+	assert.Equal(t, filepath.Join("/cache", "extracted", pid), defaultRuntimePath(DefaultConfig(), "/cache/postgres.txz"))
+	assert.Equal(t, filepath.Join("/opt/embedded-postgres", "extracted", pid), defaultRuntimePath(DefaultConfig().ExecDir("/opt/embedded-postgres"), "/cache/postgres.txz"))
+}
@@ -0,0 +1,37 @@
+//go:build !windows
+// +build !windows
+
+package embeddedpostgres
+
+import (
+	"os"
+	"os/exec"
+)
+
+// checkExecutable checks that files within dir can be executed by running a script written to it.
+func checkExecutable(dir string) error {
+	probe, err := os.CreateTemp(dir, "exec_probe_")
+	if err != nil {
+		// dir is not writable either, which extraction reports in more detail
+		return nil
+	}
+
+	defer func() {
+		_ = os.Remove(probe.Name())
+	}()
+
+	_, err = probe.WriteString("#!/bin/sh\nexit 0\n")
+	if closeErr := probe.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Chmod(probe.Name(), 0700)
+	}
+
+	if err != nil {
+		return nil
+	}
+
+	return noExecError(dir, exec.Command(probe.Name()).Run())
+}
@@ -0,0 +1,9 @@
+//go:build windows
+// +build windows
+
+package embeddedpostgres
+
+// checkExecutable does nothing on Windows, which has no equivalent of noexec mounts.
+func checkExecutable(_ string) error {
+	return nil
+}
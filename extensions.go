@@ -0,0 +1,189 @@
+package embeddedpostgres
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+const preloadConfSnippet = "00-preload"
+
+var commonExtensions = []string{"uuid-ossp", "pgcrypto", "citext", "pg_trgm", "btree_gin"}
+
+// preloadedExtension is an extension with a library of the same name that must be loaded by the server on start.
+type preloadedExtension struct {
+	name string
+	// builder is the Config builder that enables the extension, used to point users in the right direction.
+	builder  string
+	settings map[string]string
+}
+
+func preloadedExtensions(config Config) []preloadedExtension {
+	var extensions []preloadedExtension
+
+	if config.timescaleDB {
+		extensions = append(extensions, preloadedExtension{
+			name:     "timescaledb",
+			builder:  "Config.TimescaleDB",
+			settings: map[string]string{"timescaledb.telemetry_level": "off"},
+		})
+	}
+
+	if config.pgCron {
+		extensions = append(extensions, preloadedExtension{
+			name:    "pg_cron",
+			builder: "Config.PgCron",
+			settings: map[string]string{
+				"cron.database_name": config.database,
+				// background workers avoid pg_cron having to authenticate over a connection to run jobs
+				"cron.use_background_workers": "on",
+			},
+		})
+	}
+
+	return extensions
+}
+
+// writePreloadConf preloads the libraries of the enabled extensions, first checking they are present as otherwise
+// Postgres fails to start with an error that is easy to miss in the logs, followed by those configured with
+// Config.SharedPreloadLibraries.
+func (ep *EmbeddedPostgres) writePreloadConf() error {
+	extensions := preloadedExtensions(ep.config)
+	if len(extensions) == 0 && len(ep.config.preloadLibraries) == 0 {
+		return ep.writeManagedConfSnippet(preloadConfSnippet, nil)
+	}
+
+	settings := map[string]string{}
+	libraries := make([]string, 0, len(extensions)+len(ep.config.preloadLibraries))
+
+	for _, extension := range extensions {
+		controlFile := filepath.Join(ep.config.binariesPath, "share", "extension", extension.name+".control")
+		if _, err := os.Stat(controlFile); err != nil {
+			return fmt.Errorf("%s is not included in the Postgres binaries at %s, configure an archive containing it with %s: %w",
+				extension.name, ep.config.binariesPath, extension.builder, err)
+		}
+
+		libraries = append(libraries, extension.name)
+
+		for name, value := range extension.settings {
+			settings[name] = value
+		}
+	}
+
+	seen := map[string]bool{}
+	for _, library := range libraries {
+		seen[library] = true
+	}
+
+	for _, library := range ep.config.preloadLibraries {
+		if !seen[library] {
+			seen[library] = true
+			libraries = append(libraries, library)
+		}
+	}
+
+	settings["shared_preload_libraries"] = strings.Join(libraries, ",")
+
+	return ep.writeManagedConfSnippet(preloadConfSnippet, settings)
+}
+
+// createPreloadedExtensions creates the enabled extensions whose libraries are preloaded within the configured database.
+func (ep *EmbeddedPostgres) createPreloadedExtensions(ctx context.Context) error {
+	extensions := preloadedExtensions(ep.config)
+	if len(extensions) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(extensions))
+	for _, extension := range extensions {
+		names = append(names, extension.name)
+	}
+
+	unavailable, err := ep.createAvailableExtensions(ctx, ep.config.database, names)
+	if err != nil {
+		return err
+	}
+
+	if len(unavailable) > 0 {
+		return fmt.Errorf("extensions %s are not available in the Postgres binaries at %s",
+			strings.Join(unavailable, ", "), ep.config.binariesPath)
+	}
+
+	return nil
+}
+
+// createExtensions creates the extensions configured with Config.Extensions within the configured database.
+func (ep *EmbeddedPostgres) createExtensions(ctx context.Context) error {
+	if len(ep.config.extensions) == 0 {
+		return nil
+	}
+
+	unavailable, err := ep.createAvailableExtensions(ctx, ep.config.database, ep.config.extensions)
+	if err != nil {
+		return err
+	}
+
+	if len(unavailable) > 0 {
+		return fmt.Errorf("extensions %s are not available in the Postgres binaries at %s, configure an archive containing them with Config.ExtensionArchives",
+			strings.Join(unavailable, ", "), ep.config.binariesPath)
+	}
+
+	return nil
+}
+
+// UnavailableExtensions returns the extensions requested with Config.CommonExtensions that are not shipped with the
+// Postgres binaries and were therefore not created.
+func (ep *EmbeddedPostgres) UnavailableExtensions() []string {
+	return ep.unavailableExtensions
+}
+
+// createAvailableExtensions creates each of the extensions available in the binaries within the database and returns
+// the names of those that are not available.
+func (ep *EmbeddedPostgres) createAvailableExtensions(ctx context.Context, database string, extensions []string) (unavailable []string, err error) {
+	db, err := ep.openDB(database)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		err = connectionClose(db, err)
+	}()
+
+	rows, err := db.QueryContext(ctx, "SELECT name FROM pg_available_extensions WHERE name = ANY($1)", pq.Array(extensions))
+	if err != nil {
+		return nil, fmt.Errorf("unable to list available extensions: %w", err)
+	}
+
+	available := map[string]bool{}
+
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			_ = rows.Close()
+			return nil, fmt.Errorf("unable to list available extensions: %w", err)
+		}
+
+		available[name] = true
+	}
+
+	if err := rows.Close(); err != nil {
+		return nil, fmt.Errorf("unable to list available extensions: %w", err)
+	}
+
+	for _, extension := range extensions {
+		if !available[extension] {
+			unavailable = append(unavailable, extension)
+			continue
+		}
+
+		if _, err := db.ExecContext(ctx, fmt.Sprintf("CREATE EXTENSION IF NOT EXISTS %s", pq.QuoteIdentifier(extension))); err != nil {
+			return nil, fmt.Errorf("unable to create extension %s: %w", extension, err)
+		}
+	}
+
+	return unavailable, nil
+}
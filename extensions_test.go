@@ -0,0 +1,171 @@
+package embeddedpostgres
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_CommonExtensions(t *testing.T) {
+	database := NewDatabase(DefaultConfig().
+		Database("app").
+		CommonExtensions(true))
+	if err := database.Start(); err != nil {
+		shutdownDBAndFail(t, err, database)
+	}
+
+	db, err := sql.Open("postgres", "host=localhost port=5432 user=postgres password=postgres dbname=app sslmode=disable")
+	if err != nil {
+		shutdownDBAndFail(t, err, database)
+	}
+
+	var installed []string
+	if err := db.QueryRow("SELECT array_agg(extname::text) FROM pg_extension").Scan(pq.Array(&installed)); err != nil {
+		shutdownDBAndFail(t, err, database)
+	}
+
+	for _, extension := range commonExtensions {
+		if !contains(database.UnavailableExtensions(), extension) {
+			assert.Contains(t, installed, extension)
+		}
+	}
+
+	if err := db.Close(); err != nil {
+		shutdownDBAndFail(t, err, database)
+	}
+
+	if err := database.Stop(); err != nil {
+		shutdownDBAndFail(t, err, database)
+	}
+}
+
+func Test_Extensions(t *testing.T) {
+	database := NewDatabase(DefaultConfig().
+		RuntimePath(t.TempDir()).
+		Port(0).
+		Database("app").
+		Extensions("pgcrypto", "hstore"))
+	require.NoError(t, database.Start())
+
+	defer func() {
+		assert.NoError(t, database.Stop())
+	}()
+
+	db, err := database.Open("postgres")
+	require.NoError(t, err)
+
+	var installed []string
+	require.NoError(t, db.QueryRow("SELECT array_agg(extname::text) FROM pg_extension").Scan(pq.Array(&installed)))
+	assert.Contains(t, installed, "pgcrypto")
+	assert.Contains(t, installed, "hstore")
+}
+
+func Test_Extensions_ErrorWhenUnavailable(t *testing.T) {
+	database := NewDatabase(DefaultConfig().
+		RuntimePath(t.TempDir()).
+		Port(0).
+		Extensions("not_an_extension"))
+
+	err := database.Start()
+
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "extensions not_an_extension are not available in the Postgres binaries at ")
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func Test_TimescaleDBConfig(t *testing.T) {
+	config := DefaultConfig().ExtensionArchives("first.txz").TimescaleDB("timescaledb.txz")
+
+	assert.True(t, config.timescaleDB)
+	assert.Equal(t, []string{"first.txz", "timescaledb.txz"}, config.extensionArchives)
+	assert.Equal(t, []string{"first.txz"}, DefaultConfig().ExtensionArchives("first.txz").TimescaleDB("").extensionArchives)
+}
+
+func Test_writePreloadConf(t *testing.T) {
+	binariesPath := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(binariesPath, "share", "extension"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(binariesPath, "share", "extension", "timescaledb.control"), nil, 0600))
+
+	database := NewDatabase(DefaultConfig().DataPath(t.TempDir()).BinariesPath(binariesPath).TimescaleDB(""))
+
+	require.NoError(t, database.writeConfDir())
+
+	snippet, err := os.ReadFile(filepath.Join(database.ConfDir(), preloadConfSnippet+".conf"))
+	require.NoError(t, err)
+	assert.Equal(t, "shared_preload_libraries = 'timescaledb'\ntimescaledb.telemetry_level = 'off'\n", string(snippet))
+}
+
+func Test_writePreloadConf_SharedPreloadLibraries(t *testing.T) {
+	binariesPath := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(binariesPath, "share", "extension"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(binariesPath, "share", "extension", "timescaledb.control"), nil, 0600))
+
+	database := NewDatabase(DefaultConfig().
+		DataPath(t.TempDir()).
+		BinariesPath(binariesPath).
+		TimescaleDB("").
+		SharedPreloadLibraries("pg_stat_statements", "timescaledb", "auto_explain"))
+
+	require.NoError(t, database.writeConfDir())
+
+	snippet, err := os.ReadFile(filepath.Join(database.ConfDir(), preloadConfSnippet+".conf"))
+	require.NoError(t, err)
+	assert.Equal(t, "shared_preload_libraries = 'timescaledb,pg_stat_statements,auto_explain'\ntimescaledb.telemetry_level = 'off'\n", string(snippet))
+}
+
+func Test_SharedPreloadLibraries(t *testing.T) {
+	database := NewDatabase(DefaultConfig().
+		RuntimePath(t.TempDir()).
+		Port(0).
+		SharedPreloadLibraries("pg_stat_statements", "auto_explain"))
+	require.NoError(t, database.Start())
+
+	defer func() {
+		assert.NoError(t, database.Stop())
+	}()
+
+	db, err := database.Open("postgres")
+	require.NoError(t, err)
+
+	var libraries string
+	require.NoError(t, db.QueryRow("SHOW shared_preload_libraries").Scan(&libraries))
+	assert.Equal(t, "pg_stat_statements, auto_explain", libraries)
+}
+
+func Test_writePreloadConf_ErrorWhenNotBundled(t *testing.T) {
+	binariesPath := t.TempDir()
+	database := NewDatabase(DefaultConfig().DataPath(t.TempDir()).BinariesPath(binariesPath).TimescaleDB(""))
+
+	err := database.writeConfDir()
+
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "timescaledb is not included in the Postgres binaries at "+binariesPath)
+}
+
+func Test_downloadAndExtractBinary_ExtensionArchives(t *testing.T) {
+	binariesPath := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(binariesPath, "bin"), 0755))
+
+	archive, cleanUp := createTempXzArchive()
+	defer cleanUp()
+
+	database := NewDatabase(DefaultConfig().BinariesPath(binariesPath).ExtensionArchives(archive))
+
+	require.NoError(t, database.downloadAndExtractBinary(true, ""))
+
+	assert.FileExists(t, filepath.Join(binariesPath, "dir1", "dir2", "some_content"))
+}
@@ -0,0 +1,70 @@
+package embeddedpostgres
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// extractionOwner is the user and group extracted files are changed to with Config.ExtractionOwner.
+type extractionOwner struct {
+	uid int
+	gid int
+}
+
+func hasExtractionPermissions(config Config) bool {
+	return config.extractFileMask != 0 || config.extractDirMode != 0 || config.extractOwner != nil
+}
+
+// applyExtractionPermissions applies the configured modes and owner to everything extracted to extractPath, leaving
+// any data or WAL directory within it alone.
+func applyExtractionPermissions(config Config, extractPath string) error {
+	if !hasExtractionPermissions(config) {
+		return nil
+	}
+
+	if config.extractOwner != nil && os.Geteuid() != 0 {
+		return errors.New("changing the owner of extracted files requires running as root")
+	}
+
+	return filepath.WalkDir(extractPath, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() && (path == config.dataPath || path == config.walPath) {
+			return filepath.SkipDir
+		}
+
+		if err := applyExtractionMode(config, path, entry); err != nil {
+			return fmt.Errorf("unable to set permissions of %s: %w", path, err)
+		}
+
+		if config.extractOwner != nil {
+			if err := os.Lchown(path, config.extractOwner.uid, config.extractOwner.gid); err != nil {
+				return fmt.Errorf("unable to change owner of %s: %w", path, err)
+			}
+		}
+
+		return nil
+	})
+}
+
+func applyExtractionMode(config Config, path string, entry fs.DirEntry) error {
+	switch {
+	case entry.IsDir() && config.extractDirMode != 0:
+		return os.Chmod(path, config.extractDirMode)
+	case entry.Type().IsRegular() && config.extractFileMask != 0:
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		return os.Chmod(path, info.Mode().Perm()&config.extractFileMask)
+	default:
+		// the mode of a symlink is that of its target
+		return nil
+	}
+}
@@ -0,0 +1,75 @@
+package embeddedpostgres
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_applyExtractionPermissions(t *testing.T) {
+	archive, cleanUp := createTempXzArchive()
+	defer cleanUp()
+
+	extractPath := filepath.Join(t.TempDir(), "binaries")
+	require.NoError(t, decompressTarXz(DefaultTarReader, archive, extractPath))
+
+	dataPath := filepath.Join(extractPath, "data")
+	require.NoError(t, os.Mkdir(dataPath, 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(dataPath, "PG_VERSION"), []byte("15"), 0600))
+
+	config := DefaultConfig().
+		DataPath(dataPath).
+		ExtractionPermissions(0640, 0750)
+
+	require.NoError(t, applyExtractionPermissions(config, extractPath))
+
+	info, err := os.Stat(filepath.Join(extractPath, "dir1", "dir2"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0750), info.Mode().Perm())
+
+	info, err = os.Stat(filepath.Join(extractPath, "dir1", "dir2", "some_content"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0), info.Mode().Perm()&^0640)
+
+	info, err = os.Stat(dataPath)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0700), info.Mode().Perm())
+}
+
+func Test_applyExtractionPermissions_Unset(t *testing.T) {
+	assert.NoError(t, applyExtractionPermissions(DefaultConfig(), "path_not_exists"))
+}
+
+func Test_applyExtractionPermissions_Owner(t *testing.T) {
+	extractPath := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(extractPath, "postgres"), []byte("beer"), 0755))
+
+	err := applyExtractionPermissions(DefaultConfig().ExtractionOwner(os.Getuid(), os.Getgid()), extractPath)
+
+	if os.Geteuid() != 0 {
+		assert.EqualError(t, err, "changing the owner of extracted files requires running as root")
+		return
+	}
+
+	assert.NoError(t, err)
+}
+
+func Test_ExtractionPermissions(t *testing.T) {
+	jarFile, cleanUp := createTempXzArchive()
+	defer cleanUp()
+
+	binariesPath := filepath.Join(t.TempDir(), "binaries")
+
+	database := NewDatabase(DefaultConfig().
+		BinariesPath(binariesPath).
+		ExtractionPermissions(0600, 0700))
+
+	require.NoError(t, database.downloadAndExtractBinary(true, jarFile))
+
+	info, err := os.Stat(filepath.Join(binariesPath, "dir1"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0700), info.Mode().Perm())
+}
@@ -0,0 +1,68 @@
+package embeddedpostgres
+
+import (
+	"archive/tar"
+	"io"
+	"os/exec"
+	"path/filepath"
+)
+
+// FileFault is called with the path of each file or directory the library is about to create or write, such as those
+// extracted from the binaries archive, the password file passed to initdb and the generated configuration. Returning an
+// error fails the write with it, simulating storage errors such as a full disk.
+type FileFault func(path string) error
+
+// CommandFault is called with each external command, such as initdb, postgres, pg_ctl and pg_controldata, before it is
+// run. Returning an error fails running the command with it, whereas changing cmd, for example to run a command that
+// never exits, simulates a misbehaving binary.
+type CommandFault func(cmd *exec.Cmd) error
+
+// faultHooks holds the fault injection hooks of a Config, either of which may be nil.
+type faultHooks struct {
+	file    FileFault
+	command CommandFault
+}
+
+func (f faultHooks) checkFile(path string) error {
+	if f.file == nil {
+		return nil
+	}
+
+	return f.file(path)
+}
+
+func (f faultHooks) checkCommand(cmd *exec.Cmd) error {
+	if f.command == nil {
+		return nil
+	}
+
+	return f.command(cmd)
+}
+
+// tarReader wraps tarReader so that each entry is checked with the file hook, relative to extractPath, before it is
+// extracted.
+func (f faultHooks) tarReader(tarReader TarReader, extractPath string) TarReader {
+	if f.file == nil {
+		return tarReader
+	}
+
+	return func(archive io.Reader) (func() (*tar.Header, error), func() io.Reader, error) {
+		readNext, reader, err := tarReader(archive)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return func() (*tar.Header, error) {
+			header, err := readNext()
+			if err != nil {
+				return header, err
+			}
+
+			if err := f.file(filepath.Join(extractPath, header.Name)); err != nil {
+				return nil, err
+			}
+
+			return header, nil
+		}, reader, nil
+	}
+}
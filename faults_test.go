@@ -0,0 +1,145 @@
+package embeddedpostgres
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errDiskFull = errors.New("no space left on device")
+
+func Test_FileFault_ConfDir(t *testing.T) {
+	tempDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "postgresql.conf"), []byte("max_connections = 100\n"), 0600))
+
+	database := NewDatabase(DefaultConfig().
+		DataPath(tempDir).
+		ConfSnippet("10-connections", map[string]string{"max_connections": "33"}).
+		FileFault(func(path string) error {
+			if filepath.Base(path) == "10-connections.conf" {
+				return errDiskFull
+			}
+
+			return nil
+		}))
+
+	err := database.writeConfDir()
+
+	assert.True(t, errors.Is(err, errDiskFull))
+	assert.NoFileExists(t, filepath.Join(database.ConfDir(), "10-connections.conf"))
+}
+
+func Test_FileFault_Extraction(t *testing.T) {
+	jarFile, cleanUp := createTempXzArchive()
+	defer cleanUp()
+
+	var paths []string
+
+	database := NewDatabase(DefaultConfig().
+		RuntimePath(filepath.Join(t.TempDir(), "runtime")).
+		FileFault(func(path string) error {
+			paths = append(paths, path)
+
+			if filepath.Base(path) == "some_content" {
+				return errDiskFull
+			}
+
+			return nil
+		}))
+
+	database.cacheLocator = func() (string, bool) {
+		return jarFile, true
+	}
+
+	err := database.Start()
+
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), errDiskFull.Error())
+	assert.Contains(t, paths, filepath.Join(database.config.runtimePath, "dir1", "dir2", "some_content"))
+}
+
+func Test_FileFault_PasswordFile(t *testing.T) {
+	runtimePath := t.TempDir()
+
+	initDB := initDatabaseWithFaults(faultHooks{file: func(path string) error {
+		if path == filepath.Join(runtimePath, "pwfile") {
+			return errDiskFull
+		}
+
+		return nil
+	}})
+
+	err := initDB("path_not_exists", runtimePath, filepath.Join(runtimePath, "data"), "Tom", "Beer", "", nil, os.Stderr)
+
+	assert.EqualError(t, err, "unable to write password file to "+filepath.Join(runtimePath, "pwfile"))
+	assert.NoFileExists(t, filepath.Join(runtimePath, "pwfile"))
+}
+
+func Test_CommandFault_InitDB(t *testing.T) {
+	runtimePath := t.TempDir()
+
+	var commands []string
+
+	initDB := initDatabaseWithFaults(faultHooks{command: func(cmd *exec.Cmd) error {
+		commands = append(commands, filepath.Base(cmd.Path))
+		return errDiskFull
+	}})
+
+	logFile, err := os.CreateTemp(runtimePath, "log")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = logFile.Close()
+	}()
+
+	err = initDB("path_not_exists", runtimePath, filepath.Join(runtimePath, "data"), "Tom", "Beer", "", nil, logFile)
+
+	assert.True(t, errors.Is(err, errDiskFull))
+	assert.Equal(t, []string{"initdb"}, commands)
+}
+
+func Test_CommandFault_Postgres(t *testing.T) {
+	logger, err := newSyncedLogger(t.TempDir(), nil)
+	require.NoError(t, err)
+
+	process := &postgresProcess{
+		Logger: logger,
+		Config: DefaultConfig().
+			BinariesPath(t.TempDir()).
+			DataPath(t.TempDir()).
+			CommandFault(func(cmd *exec.Cmd) error {
+				return errDiskFull
+			}),
+	}
+
+	err = process.Start(context.Background())
+
+	assert.True(t, errors.Is(err, errDiskFull))
+}
+
+func Test_CommandFault_ControlData(t *testing.T) {
+	database := NewDatabase(DefaultConfig().
+		BinariesPath(t.TempDir()).
+		DataPath(t.TempDir()).
+		CommandFault(func(cmd *exec.Cmd) error {
+			assert.Equal(t, "pg_controldata", filepath.Base(cmd.Path))
+			return errDiskFull
+		}))
+
+	_, err := database.ControlData()
+
+	assert.True(t, errors.Is(err, errDiskFull))
+}
+
+func Test_faultHooks_Unset(t *testing.T) {
+	var faults faultHooks
+
+	assert.NoError(t, faults.checkFile("any"))
+	assert.NoError(t, faults.checkCommand(exec.Command("any")))
+}
@@ -0,0 +1,253 @@
+// Package embeddedgrpc serves the Control gRPC service defined in control.proto, starting, stopping and restarting
+// embedded Postgres instances and creating databases within them. It lives in its own module so that the
+// embedded-postgres module itself does not depend on gRPC.
+package embeddedgrpc
+
+//go:generate protoc --go_out=controlpb --go_opt=paths=source_relative --go-grpc_out=controlpb --go-grpc_opt=paths=source_relative control.proto
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	embeddedpostgres "github.com/fergusstrange/embedded-postgres"
+	"github.com/fergusstrange/embedded-postgres/grpc/controlpb"
+	"github.com/lib/pq"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Server implements controlpb.ControlServer, register it with controlpb.RegisterControlServer. Instances it starts
+// keep running until stopped through the service or with StopAll.
+type Server struct {
+	controlpb.UnimplementedControlServer
+
+	mu        sync.Mutex
+	instances map[string]*instance
+}
+
+type instance struct {
+	database *embeddedpostgres.EmbeddedPostgres
+	// runtimePath is the temporary runtime directory created for the instance, removed once it is stopped, or empty
+	// when the request gave one.
+	runtimePath string
+}
+
+// NewServer returns a Server without any instances.
+func NewServer() *Server {
+	return &Server{instances: map[string]*instance{}}
+}
+
+// StartInstance starts an instance configured by the request. Instances listen on a free port unless the request
+// gives one, and are given a runtime directory of their own unless the request gives one.
+func (s *Server) StartInstance(ctx context.Context, req *controlpb.StartInstanceRequest) (*controlpb.StartInstanceResponse, error) {
+	id, err := instanceID()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "unable to generate instance id: %s", err)
+	}
+
+	started := &instance{}
+
+	runtimePath := req.GetRuntimePath()
+	if runtimePath == "" {
+		if runtimePath, err = os.MkdirTemp("", "embedded-postgres-"+id+"-"); err != nil {
+			return nil, status.Errorf(codes.Internal, "unable to create runtime directory: %s", err)
+		}
+
+		started.runtimePath = runtimePath
+	}
+
+	started.database = embeddedpostgres.NewDatabase(startConfig(req, runtimePath))
+
+	if err := started.database.Start(); err != nil {
+		if started.runtimePath != "" {
+			_ = os.RemoveAll(started.runtimePath)
+		}
+
+		return nil, status.Errorf(codes.FailedPrecondition, "unable to start instance: %s", err)
+	}
+
+	s.mu.Lock()
+	s.instances[id] = started
+	s.mu.Unlock()
+
+	return &controlpb.StartInstanceResponse{
+		InstanceId:    id,
+		ConnectionUrl: started.database.ConnectionString(),
+	}, nil
+}
+
+// StopInstance stops an instance and forgets it, removing the runtime directory created for it. An instance that could
+// not be stopped is kept, so that stopping it can be retried.
+func (s *Server) StopInstance(ctx context.Context, req *controlpb.StopInstanceRequest) (*controlpb.StopInstanceResponse, error) {
+	s.mu.Lock()
+	stopped, ok := s.instances[req.GetInstanceId()]
+	delete(s.instances, req.GetInstanceId())
+	s.mu.Unlock()
+
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "unknown instance %q", req.GetInstanceId())
+	}
+
+	if err := stopped.stop(); err != nil {
+		s.keep(req.GetInstanceId(), stopped)
+		return nil, status.Errorf(codes.Internal, "unable to stop instance: %s", err)
+	}
+
+	return &controlpb.StopInstanceResponse{}, nil
+}
+
+// RestartInstance stops and starts an instance, keeping its data.
+func (s *Server) RestartInstance(ctx context.Context, req *controlpb.RestartInstanceRequest) (*controlpb.RestartInstanceResponse, error) {
+	restarted, err := s.instance(req.GetInstanceId())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := restarted.database.Restart(); err != nil {
+		return nil, status.Errorf(codes.Internal, "unable to restart instance: %s", err)
+	}
+
+	return &controlpb.RestartInstanceResponse{ConnectionUrl: restarted.database.ConnectionString()}, nil
+}
+
+// CreateDatabase creates an empty database within an instance.
+func (s *Server) CreateDatabase(ctx context.Context, req *controlpb.CreateDatabaseRequest) (resp *controlpb.CreateDatabaseResponse, err error) {
+	if req.GetDatabase() == "" {
+		return nil, status.Error(codes.InvalidArgument, "database is required")
+	}
+
+	target, err := s.instance(req.GetInstanceId())
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := target.database.Open("postgres")
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "unable to connect to instance: %s", err)
+	}
+
+	defer func() {
+		if closeErr := db.Close(); closeErr != nil && err == nil {
+			resp, err = nil, status.Errorf(codes.Internal, "unable to close connection: %s", closeErr)
+		}
+	}()
+
+	if _, err := db.ExecContext(ctx, "CREATE DATABASE "+pq.QuoteIdentifier(req.GetDatabase())); err != nil {
+		return nil, status.Errorf(codes.Internal, "unable to create database %s: %s", req.GetDatabase(), err)
+	}
+
+	return &controlpb.CreateDatabaseResponse{
+		ConnectionUrl: target.database.ConnectionString(embeddedpostgres.WithDatabase(req.GetDatabase())),
+	}, nil
+}
+
+// StopAll stops every instance started through the service, for use when shutting down the gRPC server. Instances that
+// could not be stopped are kept, as with StopInstance.
+func (s *Server) StopAll() error {
+	s.mu.Lock()
+	instances := s.instances
+	s.instances = map[string]*instance{}
+	s.mu.Unlock()
+
+	var failures []string
+
+	for id, stopped := range instances {
+		if err := stopped.stop(); err != nil {
+			s.keep(id, stopped)
+			failures = append(failures, fmt.Sprintf("instance %s: %s", id, err))
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("unable to stop instances: %s", strings.Join(failures, ", "))
+	}
+
+	return nil
+}
+
+func (s *Server) instance(id string) (*instance, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	found, ok := s.instances[id]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "unknown instance %q", id)
+	}
+
+	return found, nil
+}
+
+// keep puts back an instance taken out of the server to be stopped.
+func (s *Server) keep(id string, kept *instance) {
+	s.mu.Lock()
+	s.instances[id] = kept
+	s.mu.Unlock()
+}
+
+func (i *instance) stop() error {
+	if err := i.database.Stop(); err != nil {
+		return err
+	}
+
+	if i.runtimePath != "" {
+		if err := os.RemoveAll(i.runtimePath); err != nil {
+			return fmt.Errorf("unable to remove runtime directory %s: %w", i.runtimePath, err)
+		}
+	}
+
+	return nil
+}
+
+// startConfig returns the configuration of an instance, keeping the library defaults for the fields the request
+// leaves empty.
+func startConfig(req *controlpb.StartInstanceRequest, runtimePath string) embeddedpostgres.Config {
+	config := embeddedpostgres.DefaultConfig().
+		RuntimePath(runtimePath).
+		Port(req.GetPort()).
+		DataPath(req.GetDataPath())
+
+	if req.GetVersion() != "" {
+		config = config.Version(embeddedpostgres.PostgresVersion(req.GetVersion()))
+	}
+
+	if req.GetDatabase() != "" {
+		config = config.Database(req.GetDatabase())
+	}
+
+	if req.GetUsername() != "" {
+		config = config.Username(req.GetUsername())
+	}
+
+	if req.GetPassword() != "" {
+		config = config.Password(req.GetPassword())
+	}
+
+	if req.GetLocale() != "" {
+		config = config.Locale(req.GetLocale())
+	}
+
+	if len(req.GetStartParameters()) > 0 {
+		config = config.StartParameters(req.GetStartParameters())
+	}
+
+	if req.GetStartTimeoutSeconds() > 0 {
+		config = config.StartTimeout(time.Duration(req.GetStartTimeoutSeconds()) * time.Second)
+	}
+
+	return config
+}
+
+func instanceID() (string, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(id), nil
+}
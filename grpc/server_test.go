@@ -0,0 +1,157 @@
+package embeddedgrpc
+
+import (
+	"context"
+	"database/sql"
+	"net"
+	"testing"
+	"time"
+
+	embeddedpostgres "github.com/fergusstrange/embedded-postgres"
+	"github.com/fergusstrange/embedded-postgres/grpc/controlpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func startControlServer(t *testing.T) (*Server, controlpb.ControlClient) {
+	t.Helper()
+
+	listener := bufconn.Listen(1024 * 1024)
+	server := NewServer()
+
+	grpcServer := grpc.NewServer()
+	controlpb.RegisterControlServer(grpcServer, server)
+
+	go func() {
+		_ = grpcServer.Serve(listener)
+	}()
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		assert.NoError(t, conn.Close())
+		grpcServer.Stop()
+		assert.NoError(t, server.StopAll())
+	})
+
+	return server, controlpb.NewControlClient(conn)
+}
+
+func Test_Control(t *testing.T) {
+	_, client := startControlServer(t)
+	ctx := context.Background()
+
+	started, err := client.StartInstance(ctx, &controlpb.StartInstanceRequest{Database: "beer", StartTimeoutSeconds: 60})
+	require.NoError(t, err)
+	assert.NotEmpty(t, started.InstanceId)
+
+	created, err := client.CreateDatabase(ctx, &controlpb.CreateDatabaseRequest{InstanceId: started.InstanceId, Database: "wine"})
+	require.NoError(t, err)
+	assertCurrentDatabase(t, created.ConnectionUrl, "wine")
+
+	restarted, err := client.RestartInstance(ctx, &controlpb.RestartInstanceRequest{InstanceId: started.InstanceId})
+	require.NoError(t, err)
+	assertCurrentDatabase(t, restarted.ConnectionUrl, "beer")
+
+	_, err = client.StopInstance(ctx, &controlpb.StopInstanceRequest{InstanceId: started.InstanceId})
+	require.NoError(t, err)
+
+	_, err = client.StopInstance(ctx, &controlpb.StopInstanceRequest{InstanceId: started.InstanceId})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func assertCurrentDatabase(t *testing.T, connectionURL, expected string) {
+	t.Helper()
+
+	db, err := sql.Open("postgres", connectionURL)
+	require.NoError(t, err)
+
+	defer func() {
+		assert.NoError(t, db.Close())
+	}()
+
+	var name string
+	require.NoError(t, db.QueryRow("SELECT current_database()").Scan(&name))
+	assert.Equal(t, expected, name)
+}
+
+func Test_Control_ErrorWhenUnknownInstance(t *testing.T) {
+	_, client := startControlServer(t)
+	ctx := context.Background()
+
+	_, err := client.StopInstance(ctx, &controlpb.StopInstanceRequest{InstanceId: "missing"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = client.RestartInstance(ctx, &controlpb.RestartInstanceRequest{InstanceId: "missing"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = client.CreateDatabase(ctx, &controlpb.CreateDatabaseRequest{InstanceId: "missing", Database: "beer"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func Test_Control_KeepsInstanceWhenStopFails(t *testing.T) {
+	server, client := startControlServer(t)
+	ctx := context.Background()
+
+	runtimePath := t.TempDir()
+	// an instance that has not been started cannot be stopped
+	server.instances["beer"] = &instance{database: embeddedpostgres.NewDatabase(), runtimePath: runtimePath}
+
+	_, err := client.StopInstance(ctx, &controlpb.StopInstanceRequest{InstanceId: "beer"})
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.Contains(t, server.instances, "beer")
+	assert.DirExists(t, runtimePath)
+
+	assert.Error(t, server.StopAll())
+	assert.Contains(t, server.instances, "beer")
+
+	delete(server.instances, "beer")
+}
+
+func Test_Control_ErrorWhenDatabaseMissing(t *testing.T) {
+	_, client := startControlServer(t)
+
+	_, err := client.CreateDatabase(context.Background(), &controlpb.CreateDatabaseRequest{InstanceId: "missing"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func Test_startConfig(t *testing.T) {
+	assert.Equal(t,
+		embeddedpostgres.DefaultConfig().RuntimePath("/tmp/runtime").Port(0).DataPath(""),
+		startConfig(&controlpb.StartInstanceRequest{}, "/tmp/runtime"))
+
+	assert.Equal(t,
+		embeddedpostgres.DefaultConfig().
+			RuntimePath("/tmp/runtime").
+			Port(9876).
+			DataPath("/tmp/data").
+			Version(embeddedpostgres.V14).
+			Database("beer").
+			Username("gin").
+			Password("wine").
+			Locale("C").
+			StartParameters(map[string]string{"max_connections": "200"}).
+			StartTimeout(time.Minute),
+		startConfig(&controlpb.StartInstanceRequest{
+			Version:             string(embeddedpostgres.V14),
+			Port:                9876,
+			Database:            "beer",
+			Username:            "gin",
+			Password:            "wine",
+			RuntimePath:         "/tmp/runtime",
+			DataPath:            "/tmp/data",
+			Locale:              "C",
+			StartParameters:     map[string]string{"max_connections": "200"},
+			StartTimeoutSeconds: 60,
+		}, "/tmp/runtime"))
+}
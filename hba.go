@@ -0,0 +1,165 @@
+package embeddedpostgres
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// HBAConnectionType is the type of connection a pg_hba.conf entry matches.
+type HBAConnectionType string
+
+// Predefined pg_hba.conf connection types.
+const (
+	HBALocal     = HBAConnectionType("local")
+	HBAHost      = HBAConnectionType("host")
+	HBAHostSSL   = HBAConnectionType("hostssl")
+	HBAHostNoSSL = HBAConnectionType("hostnossl")
+)
+
+// HBAEntry is a client authentication rule of pg_hba.conf. An empty Database or User matches all.
+type HBAEntry struct {
+	Type     HBAConnectionType
+	Database string
+	User     string
+	// Address is the client address or CIDR range matched by connections other than local ones, such as
+	// "127.0.0.1/32" or "all".
+	Address string
+	Method  AuthMethod
+	// Options are the authentication options of the entry, such as clientcert.
+	Options map[string]string
+}
+
+func (e HBAEntry) render(version PostgresVersion) (string, error) {
+	switch e.Type {
+	case HBALocal:
+		if e.Address != "" {
+			return "", errors.New("local pg_hba.conf entries do not match an address")
+		}
+	case HBAHost, HBAHostSSL, HBAHostNoSSL:
+		if e.Address == "" {
+			return "", fmt.Errorf("%s pg_hba.conf entries require an address", e.Type)
+		}
+	default:
+		return "", fmt.Errorf("unsupported pg_hba.conf connection type %q", e.Type)
+	}
+
+	if e.Method == "" {
+		return "", errors.New("pg_hba.conf entries require an authentication method")
+	}
+
+	if e.Method == AuthScramSHA256 && majorVersion(version) < 10 {
+		return "", errors.New("scram-sha-256 authentication requires Postgres 10 or later")
+	}
+
+	fields := []string{string(e.Type), allIfEmpty(e.Database), allIfEmpty(e.User)}
+	if e.Address != "" {
+		fields = append(fields, e.Address)
+	}
+
+	fields = append(fields, string(e.Method))
+
+	names := make([]string, 0, len(e.Options))
+	for name := range e.Options {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		fields = append(fields, name+"="+e.Options[name])
+	}
+
+	return strings.Join(fields, " "), nil
+}
+
+func allIfEmpty(value string) string {
+	if value == "" {
+		return "all"
+	}
+
+	return value
+}
+
+const (
+	hbaBeginMarker = "# BEGIN embedded-postgres managed entries"
+	hbaEndMarker   = "# END embedded-postgres managed entries"
+)
+
+// hbaEntries returns the pg_hba.conf entries managed by the library, which are matched before those written by initdb.
+// Entries configured with Config.HBAEntries come first, in the order given.
+func hbaEntries(config Config) ([]string, error) {
+	var entries []string
+
+	for _, entry := range config.hbaEntries {
+		line, err := entry.render(config.version)
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, line)
+	}
+
+	if config.mutualTLS {
+		// clientcert=verify-full was introduced in Postgres 12, before which cert authentication alone checked the
+		// certificate was issued to the user
+		if majorVersion(config.version) < 12 {
+			entries = append(entries, "hostssl all all all cert")
+		} else {
+			entries = append(entries, "hostssl all all all cert clientcert=verify-full")
+		}
+	}
+
+	if tlsRequired(config) {
+		// all does not match replication connections, such as those of pg_basebackup
+		entries = append(entries, "hostnossl all all all reject", "hostnossl replication all all reject")
+	}
+
+	return entries, nil
+}
+
+// writeHBAConf writes the managed entries at the top of pg_hba.conf, replacing those written by a previous start.
+func (ep *EmbeddedPostgres) writeHBAConf() error {
+	hbaPath := filepath.Join(ep.config.dataPath, "pg_hba.conf")
+
+	entries, err := hbaEntries(ep.config)
+	if err != nil {
+		return err
+	}
+
+	content, err := os.ReadFile(hbaPath)
+	if os.IsNotExist(err) && len(entries) == 0 {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("unable to read %s: %w", hbaPath, err)
+	}
+
+	updated := removeManagedHBAEntries(string(content))
+
+	if len(entries) > 0 {
+		block := append(append([]string{hbaBeginMarker}, entries...), hbaEndMarker)
+		updated = strings.Join(block, "\n") + "\n" + updated
+	}
+
+	if updated == string(content) {
+		return nil
+	}
+
+	return writeConfFile(ep.config.faults, hbaPath, []byte(updated), dataFileMode(ep.config))
+}
+
+func removeManagedHBAEntries(content string) string {
+	begin := strings.Index(content, hbaBeginMarker)
+	end := strings.Index(content, hbaEndMarker)
+
+	if begin < 0 || end < begin {
+		return content
+	}
+
+	return content[:begin] + strings.TrimPrefix(content[end+len(hbaEndMarker):], "\n")
+}
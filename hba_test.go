@@ -0,0 +1,108 @@
+package embeddedpostgres
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_hbaEntries(t *testing.T) {
+	entries, err := hbaEntries(DefaultConfig())
+	assert.NoError(t, err)
+	assert.Nil(t, entries)
+
+	entries, err = hbaEntries(DefaultConfig().MutualTLS())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"hostssl all all all cert clientcert=verify-full"}, entries)
+
+	entries, err = hbaEntries(DefaultConfig().Version(V11).MutualTLS())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"hostssl all all all cert"}, entries)
+
+	entries, err = hbaEntries(DefaultConfig().Preset(ManagedCloud))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"hostnossl all all all reject", "hostnossl replication all all reject"}, entries)
+
+	entries, err = hbaEntries(DefaultConfig().
+		HBAEntries(
+			HBAEntry{Type: HBAHost, User: "blocked", Address: "all", Method: AuthReject},
+			HBAEntry{Type: HBALocal, Database: "beer", Method: AuthPeer, Options: map[string]string{"map": "brewers"}},
+			HBAEntry{Type: HBAHostNoSSL, Address: "10.0.0.0/8", Method: AuthScramSHA256}).
+		MutualTLS())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"host all blocked all reject",
+		"local beer all peer map=brewers",
+		"hostnossl all all 10.0.0.0/8 scram-sha-256",
+		"hostssl all all all cert clientcert=verify-full",
+	}, entries)
+}
+
+func Test_hbaEntries_Errors(t *testing.T) {
+	for _, test := range []struct {
+		entry    HBAEntry
+		expected string
+	}{
+		{HBAEntry{Type: HBALocal, Address: "all", Method: AuthTrust}, "local pg_hba.conf entries do not match an address"},
+		{HBAEntry{Type: HBAHostSSL, Method: AuthTrust}, "hostssl pg_hba.conf entries require an address"},
+		{HBAEntry{Type: "hostgssenc", Address: "all", Method: AuthTrust}, `unsupported pg_hba.conf connection type "hostgssenc"`},
+		{HBAEntry{Type: HBAHost, Address: "all"}, "pg_hba.conf entries require an authentication method"},
+		{HBAEntry{Type: HBAHost, Address: "all", Method: AuthScramSHA256}, "scram-sha-256 authentication requires Postgres 10 or later"},
+	} {
+		_, err := hbaEntries(DefaultConfig().Version(V9).HBAEntries(test.entry))
+		assert.EqualError(t, err, test.expected)
+	}
+}
+
+func Test_writeHBAConf(t *testing.T) {
+	dataPath := t.TempDir()
+	hbaPath := filepath.Join(dataPath, "pg_hba.conf")
+	initial := "local all all trust\nhost all all 127.0.0.1/32 trust\n"
+	require.NoError(t, os.WriteFile(hbaPath, []byte(initial), 0600))
+
+	require.NoError(t, NewDatabase(DefaultConfig().DataPath(dataPath).MutualTLS()).writeHBAConf())
+
+	content, err := os.ReadFile(hbaPath)
+	require.NoError(t, err)
+	assert.Equal(t, hbaBeginMarker+"\nhostssl all all all cert clientcert=verify-full\n"+hbaEndMarker+"\n"+initial, string(content))
+
+	// entries from a previous start are replaced rather than repeated
+	require.NoError(t, NewDatabase(DefaultConfig().DataPath(dataPath).MutualTLS()).writeHBAConf())
+
+	content, err = os.ReadFile(hbaPath)
+	require.NoError(t, err)
+	assert.Equal(t, hbaBeginMarker+"\nhostssl all all all cert clientcert=verify-full\n"+hbaEndMarker+"\n"+initial, string(content))
+
+	require.NoError(t, NewDatabase(DefaultConfig().DataPath(dataPath)).writeHBAConf())
+
+	content, err = os.ReadFile(hbaPath)
+	require.NoError(t, err)
+	assert.Equal(t, initial, string(content))
+}
+
+func Test_HBAEntries(t *testing.T) {
+	database := NewDatabase(DefaultConfig().
+		RuntimePath(t.TempDir()).
+		Port(0).
+		HBAEntries(HBAEntry{Type: HBAHost, User: "blocked", Address: "all", Method: AuthReject}))
+	require.NoError(t, database.Start())
+
+	defer func() {
+		assert.NoError(t, database.Stop())
+	}()
+
+	db, err := sql.Open("postgres", database.ConnectionString(WithUser("blocked", "secret")))
+	require.NoError(t, err)
+
+	defer func() {
+		assert.NoError(t, db.Close())
+	}()
+
+	err = db.Ping()
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "pg_hba.conf rejects connection")
+}
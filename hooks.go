@@ -0,0 +1,39 @@
+package embeddedpostgres
+
+import (
+	"context"
+	"fmt"
+)
+
+// Hook is called with the server at a point in its lifecycle, as configured with Config.Hooks.
+type Hook func(ctx context.Context, ep *EmbeddedPostgres) error
+
+// Hooks are called as a server is initialised, started and stopped. Any hook may be nil.
+type Hooks struct {
+	// BeforeInit is called before initdb creates a new data directory, which is skipped when one is reused.
+	BeforeInit Hook
+	// AfterInit is called once initdb has created a new data directory, before any configuration is written to it.
+	AfterInit Hook
+	// BeforeStart is called once the configuration has been written, before the server process starts, so that
+	// WriteConfSnippet can be used to add to it. It is also called on Restart.
+	BeforeStart Hook
+	// AfterStart is called once the server accepts connections and has been provisioned, migrated and seeded.
+	AfterStart Hook
+	// BeforeStop is called by Stop whilst the server is still running. The server is stopped even if it fails.
+	BeforeStop Hook
+	// AfterStop is called by Stop once the server has stopped.
+	AfterStop Hook
+}
+
+// runHook calls hook when it is set, naming it in any error it returns.
+func (ep *EmbeddedPostgres) runHook(ctx context.Context, name string, hook Hook) error {
+	if hook == nil {
+		return nil
+	}
+
+	if err := hook(ctx, ep); err != nil {
+		return fmt.Errorf("unable to run %s hook: %w", name, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,103 @@
+package embeddedpostgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Hooks(t *testing.T) {
+	var called []string
+
+	hook := func(name string) Hook {
+		return func(ctx context.Context, ep *EmbeddedPostgres) error {
+			called = append(called, name)
+			return nil
+		}
+	}
+
+	database := NewDatabase(DefaultConfig().
+		RuntimePath(t.TempDir()).
+		Port(0).
+		Hooks(Hooks{
+			BeforeInit: hook("BeforeInit"),
+			AfterInit:  hook("AfterInit"),
+			BeforeStart: func(ctx context.Context, ep *EmbeddedPostgres) error {
+				called = append(called, "BeforeStart")
+				return ep.WriteConfSnippet("50-hooks", map[string]string{"work_mem": "8MB"})
+			},
+			AfterStart: hook("AfterStart"),
+			BeforeStop: hook("BeforeStop"),
+			AfterStop:  hook("AfterStop"),
+		}))
+	require.NoError(t, database.Start())
+
+	db, err := database.Open("postgres")
+	require.NoError(t, err)
+
+	var workMem string
+	require.NoError(t, db.QueryRow("SHOW work_mem").Scan(&workMem))
+	assert.Equal(t, "8MB", workMem)
+
+	require.NoError(t, database.Restart())
+	require.NoError(t, database.Stop())
+
+	assert.Equal(t, []string{
+		"BeforeInit", "AfterInit", "BeforeStart", "AfterStart",
+		"BeforeStop", "AfterStop", "BeforeStart", "AfterStart",
+		"BeforeStop", "AfterStop",
+	}, called)
+}
+
+func Test_Hooks_AfterStartErrorStopsServer(t *testing.T) {
+	stopped := false
+
+	database := NewDatabase(DefaultConfig().
+		RuntimePath(t.TempDir()).
+		Port(0).
+		Hooks(Hooks{
+			AfterStart: func(ctx context.Context, ep *EmbeddedPostgres) error {
+				return errors.New("fixtures missing")
+			},
+			AfterStop: func(ctx context.Context, ep *EmbeddedPostgres) error {
+				stopped = true
+				return nil
+			},
+		}))
+
+	assert.EqualError(t, database.Start(), "unable to run AfterStart hook: fixtures missing")
+	assert.False(t, database.isStarted())
+	assert.False(t, stopped)
+}
+
+func Test_Hooks_BeforeStopErrorStillStops(t *testing.T) {
+	database := NewDatabase(DefaultConfig().
+		RuntimePath(t.TempDir()).
+		Port(0).
+		Hooks(Hooks{
+			BeforeStop: func(ctx context.Context, ep *EmbeddedPostgres) error {
+				return errors.New("teardown failed")
+			},
+		}))
+	require.NoError(t, database.Start())
+
+	assert.EqualError(t, database.Stop(), "unable to run BeforeStop hook: teardown failed")
+	assert.False(t, database.isStarted())
+}
+
+func Test_Stop_NotStartedSkipsHooks(t *testing.T) {
+	called := false
+
+	database := NewDatabase(DefaultConfig().Hooks(Hooks{
+		BeforeStop: func(ctx context.Context, ep *EmbeddedPostgres) error {
+			called = true
+			return nil
+		},
+	}))
+
+	assert.EqualError(t, database.Stop(), "server has not been started")
+	assert.False(t, called)
+}
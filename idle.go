@@ -0,0 +1,62 @@
+package embeddedpostgres
+
+import (
+	"context"
+	"time"
+)
+
+// EnsureStarted starts the server unless it is already running, such as when it has stopped itself after being idle
+// for longer than Config.IdleTimeout.
+func (ep *EmbeddedPostgres) EnsureStarted() error {
+	if ep.isStarted() {
+		return nil
+	}
+
+	return ep.Start()
+}
+
+// watchIdle stops the server once no client has been connected for the configured idle timeout.
+func (ep *EmbeddedPostgres) watchIdle() {
+	if ep.config.idleTimeout <= 0 {
+		return
+	}
+
+	lastActive := time.Now()
+	background := ep.background()
+
+	ep.watch(watchInterval(ep.config.idleTimeout), func(now time.Time) bool {
+		connections, err := background.clientConnections()
+		// the server is assumed to be in use when it cannot be checked, so it is never stopped in error
+		if err != nil || connections > 0 {
+			lastActive = now
+			return false
+		}
+
+		return now.Sub(lastActive) >= background.config.idleTimeout
+	})
+}
+
+// clientConnections counts the clients connected to the server, excluding the connection used to count them.
+func (ep *EmbeddedPostgres) clientConnections() (connections int, err error) {
+	db, err := ep.openDB("postgres")
+	if err != nil {
+		return 0, err
+	}
+
+	defer func() {
+		err = connectionClose(db, err)
+	}()
+
+	// background processes are only listed from Postgres 10, alongside the backend type to exclude them by
+	query := "SELECT count(*) FROM pg_stat_activity WHERE pid <> pg_backend_pid()"
+	if majorVersion(ep.config.version) >= 10 {
+		query += " AND backend_type = 'client backend'"
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err = db.QueryRowContext(ctx, query).Scan(&connections)
+
+	return connections, err
+}
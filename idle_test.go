@@ -0,0 +1,37 @@
+package embeddedpostgres
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_IdleTimeout(t *testing.T) {
+	tempDir := t.TempDir()
+	database := NewDatabase(DefaultConfig().
+		RuntimePath(tempDir).
+		DataPath(tempDir + "/data").
+		BinariesPath(tempDir + "/binaries").
+		IdleTimeout(time.Second))
+
+	require.NoError(t, database.Start())
+
+	db, err := database.openDB("postgres")
+	require.NoError(t, err)
+	require.NoError(t, db.Ping())
+
+	time.Sleep(2 * time.Second)
+	assert.True(t, database.isStarted(), "server stopped whilst a client was connected")
+
+	require.NoError(t, db.Close())
+
+	assert.Eventually(t, func() bool {
+		return !database.isStarted()
+	}, 10*time.Second, 100*time.Millisecond)
+
+	require.NoError(t, database.EnsureStarted())
+	require.NoError(t, database.EnsureStarted())
+	assert.NoError(t, database.Stop())
+}
@@ -0,0 +1,74 @@
+package embeddedpostgres
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// initScriptFiles expands the paths configured with Config.InitScripts, replacing directories with the .sql files
+// they contain in lexical order.
+func initScriptFiles(paths []string) ([]string, error) {
+	var files []string
+
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read init script %s: %w", path, err)
+		}
+
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read init scripts in %s: %w", path, err)
+		}
+
+		var scripts []string
+
+		for _, entry := range entries {
+			if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".sql") {
+				scripts = append(scripts, filepath.Join(path, entry.Name()))
+			}
+		}
+
+		sort.Strings(scripts)
+		files = append(files, scripts...)
+	}
+
+	return files, nil
+}
+
+// runInitScripts runs the scripts and SQL configured with Config.InitScripts and Config.InitSQL within the configured
+// database.
+func (ep *EmbeddedPostgres) runInitScripts(ctx context.Context) error {
+	files, err := initScriptFiles(ep.config.initScripts)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		script, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("unable to read init script %s: %w", file, err)
+		}
+
+		if err := ep.execStatements(ctx, ep.config.database, string(script)); err != nil {
+			return fmt.Errorf("unable to run init script %s: %w", file, err)
+		}
+	}
+
+	if len(ep.config.initSQL) > 0 {
+		if err := ep.execStatements(ctx, ep.config.database, ep.config.initSQL...); err != nil {
+			return fmt.Errorf("unable to run init SQL: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package embeddedpostgres
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_initScriptFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"02-seed.sql", "01-schema.sql", "README.md"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), nil, 0600))
+	}
+
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "nested.sql"), 0700))
+
+	single := filepath.Join(t.TempDir(), "extra.sql")
+	require.NoError(t, os.WriteFile(single, nil, 0600))
+
+	files, err := initScriptFiles([]string{dir, single})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "01-schema.sql"),
+		filepath.Join(dir, "02-seed.sql"),
+		single,
+	}, files)
+
+	_, err = initScriptFiles([]string{filepath.Join(dir, "missing.sql")})
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "unable to read init script "+filepath.Join(dir, "missing.sql"))
+}
+
+func Test_InitScripts(t *testing.T) {
+	scripts := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(scripts, "01-schema.sql"), []byte("CREATE TABLE brews (name text);"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(scripts, "02-seed.sql"), []byte("INSERT INTO brews VALUES ('stout');\nINSERT INTO brews VALUES ('lager');"), 0600))
+
+	config := DefaultConfig().
+		RuntimePath(t.TempDir()).
+		DataPath(filepath.Join(t.TempDir(), "data")).
+		Port(0).
+		InitScripts(scripts).
+		InitSQL("INSERT INTO brews VALUES ('porter')")
+
+	database := NewDatabase(config)
+	require.NoError(t, database.Start())
+	assert.Equal(t, []string{"lager", "porter", "stout"}, brews(t, database))
+	require.NoError(t, database.Stop())
+
+	// the scripts are not run again against the reused data directory
+	database = NewDatabase(config)
+	require.NoError(t, database.Start())
+
+	defer func() {
+		assert.NoError(t, database.Stop())
+	}()
+
+	assert.Equal(t, []string{"lager", "porter", "stout"}, brews(t, database))
+}
@@ -0,0 +1,93 @@
+package embeddedpostgres
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// initDBTemplatePath returns where the data directory initdb produces for the configuration is cached alongside the
+// binaries cached at cacheLocation, named after everything that shapes it, or "" when it is not to be cached.
+func initDBTemplatePath(config Config, cacheLocation string) (string, error) {
+	// a custom strategy or a standby's base backup may produce anything
+	if !config.cacheInitDB || config.initDBStrategy != nil || config.standbyOf != "" {
+		return "", nil
+	}
+
+	// where the WAL is kept and whether initdb syncs make no difference to the data directory produced
+	keyed := config
+	keyed.walPath = ""
+	keyed.initDBNoSync = false
+
+	args, err := initDBArgs(keyed)
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.New()
+	for _, part := range append([]string{string(config.version), config.username, config.password, config.locale}, args...) {
+		_, _ = hash.Write([]byte(part))
+		_, _ = hash.Write([]byte{0})
+	}
+
+	binaries := strings.TrimSuffix(filepath.Base(cacheLocation), filepath.Ext(cacheLocation))
+
+	return filepath.Join(filepath.Dir(cacheLocation),
+		fmt.Sprintf("%s-initdb-%s.tar.gz", binaries, hex.EncodeToString(hash.Sum(nil))[:16])), nil
+}
+
+// restoreInitDBTemplate restores the cached data directory at template, reporting whether it was, so that initdb
+// need not run. A template that cannot be restored is reported as a diagnostic and initdb runs instead.
+func (ep *EmbeddedPostgres) restoreInitDBTemplate(template string) bool {
+	if _, err := os.Stat(template); err != nil {
+		return false
+	}
+
+	err := extractDataArchive(template, ep.config.dataPath, ep.config.walPath, walDirName(ep.config.version))
+	if err == nil && dataDirIsValid(ep.config.dataPath, ep.config.version) {
+		return true
+	}
+
+	if err == nil {
+		err = fmt.Errorf("no data directory for Postgres %s", ep.config.version)
+	}
+
+	ep.reportDiagnostic(fmt.Sprintf("unable to restore cached initdb data directory %s, running initdb: %s", template, err))
+
+	_ = os.RemoveAll(ep.config.dataPath)
+	if ep.config.walPath != "" {
+		_ = os.RemoveAll(ep.config.walPath)
+	}
+
+	return false
+}
+
+// saveInitDBTemplate caches the data directory initdb has just produced at template, writing it alongside and renaming
+// it into place so that concurrent starts never see a partial archive. Failing to is reported as a diagnostic.
+func (ep *EmbeddedPostgres) saveInitDBTemplate(template string) {
+	if err := os.MkdirAll(filepath.Dir(template), os.ModePerm); err != nil {
+		ep.reportDiagnostic(fmt.Sprintf("unable to cache initdb data directory %s: %s", template, err))
+		return
+	}
+
+	temp, err := os.CreateTemp(filepath.Dir(template), filepath.Base(template)+".*")
+	if err != nil {
+		ep.reportDiagnostic(fmt.Sprintf("unable to cache initdb data directory %s: %s", template, err))
+		return
+	}
+
+	_ = temp.Close()
+
+	err = writeDataArchive(temp.Name(), ep.config.dataPath, ep.config.walPath, walDirName(ep.config.version))
+	if err == nil {
+		err = os.Rename(temp.Name(), template)
+	}
+
+	if err != nil {
+		_ = os.Remove(temp.Name())
+		ep.reportDiagnostic(fmt.Sprintf("unable to cache initdb data directory %s: %s", template, err))
+	}
+}
@@ -0,0 +1,100 @@
+package embeddedpostgres
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_initDBTemplatePath(t *testing.T) {
+	cacheLocation := filepath.Join("cache", "embedded-postgres-binaries-linux-amd64-15.3.0.txz")
+	config := DefaultConfig().Version(V15).CacheInitDB(true)
+
+	path, err := initDBTemplatePath(config, cacheLocation)
+	require.NoError(t, err)
+
+	assert.Equal(t, "cache", filepath.Dir(path))
+	assert.True(t, strings.HasPrefix(filepath.Base(path), "embedded-postgres-binaries-linux-amd64-15.3.0-initdb-"))
+	assert.True(t, strings.HasSuffix(path, ".tar.gz"))
+
+	same, err := initDBTemplatePath(config.InitDBNoSync(true).WALPath("wal"), cacheLocation)
+	require.NoError(t, err)
+	assert.Equal(t, path, same)
+
+	for _, different := range []Config{
+		config.Version(V14),
+		config.Username("brewer"),
+		config.Password("hops"),
+		config.Locale("C"),
+		config.InitDBArgs("--data-checksums"),
+	} {
+		differentPath, err := initDBTemplatePath(different, cacheLocation)
+		require.NoError(t, err)
+		assert.NotEqual(t, path, differentPath)
+	}
+
+	for _, uncached := range []Config{
+		DefaultConfig(),
+		config.StandbyOf("host=localhost"),
+		config.InitDatabaseStrategy(func(binaryExtractLocation, runtimePath, pgDataDir, username, password, locale string, extraArgs []string, logger *os.File) error {
+			return nil
+		}),
+	} {
+		uncachedPath, err := initDBTemplatePath(uncached, cacheLocation)
+		require.NoError(t, err)
+		assert.Empty(t, uncachedPath)
+	}
+}
+
+func Test_CacheInitDB(t *testing.T) {
+	dir := t.TempDir()
+	cacheLocation := filepath.Join(dir, "cache", "embedded-postgres-binaries-linux-amd64-15.3.0.txz")
+
+	database := NewDatabase(DefaultConfig().
+		Version(V15).
+		CacheInitDB(true).
+		RuntimePath(filepath.Join(dir, "runtime")).
+		DataPath(filepath.Join(dir, "data")))
+
+	logger, err := newSyncedLogger(dir, nil)
+	require.NoError(t, err)
+	database.syncedLogger = logger
+
+	initialised := 0
+	database.initDatabase = func(binaryExtractLocation, runtimePath, pgDataDir, username, password, locale string, extraArgs []string, logger *os.File) error {
+		initialised++
+
+		if err := os.MkdirAll(pgDataDir, 0700); err != nil {
+			return err
+		}
+
+		return os.WriteFile(filepath.Join(pgDataDir, "PG_VERSION"), []byte("15\n"), 0600)
+	}
+
+	require.NoError(t, database.cleanDataDirectoryAndInit(cacheLocation))
+	assert.Equal(t, 1, initialised)
+
+	template, err := initDBTemplatePath(database.config, cacheLocation)
+	require.NoError(t, err)
+	assert.FileExists(t, template)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "data", "stale"), []byte("stale"), 0600))
+
+	require.NoError(t, database.cleanDataDirectoryAndInit(cacheLocation))
+	assert.Equal(t, 1, initialised)
+	assert.True(t, dataDirIsValid(filepath.Join(dir, "data"), V15))
+	assert.NoFileExists(t, filepath.Join(dir, "data", "stale"))
+
+	require.NoError(t, os.WriteFile(template, []byte("not an archive"), 0600))
+
+	require.NoError(t, database.cleanDataDirectoryAndInit(cacheLocation))
+	assert.Equal(t, 2, initialised)
+
+	log, err := os.ReadFile(logger.file.Name())
+	require.NoError(t, err)
+	assert.Contains(t, string(log), "unable to restore cached initdb data directory "+template+", running initdb")
+}
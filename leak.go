@@ -0,0 +1,26 @@
+package embeddedpostgres
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/debug"
+)
+
+// reportLeakToStderr is where warnings about instances that were never stopped are sent.
+func reportLeakToStderr(warning string) {
+	_, _ = fmt.Fprint(os.Stderr, warning)
+}
+
+// trackLeaks records where ep was created and reports a warning should it be garbage collected whilst still started,
+// which happens when Stop is forgotten and leaves the postgres process running until the tests finish.
+func trackLeaks(ep *EmbeddedPostgres, reportLeak func(warning string)) {
+	ep.createdAt = debug.Stack()
+
+	runtime.SetFinalizer(ep, func(ep *EmbeddedPostgres) {
+		if ep.started {
+			reportLeak(fmt.Sprintf("WARNING: embedded-postgres on port %d was garbage collected without being stopped, "+
+				"leaving the postgres process running. It was created by:\n%s\n", ep.config.port, ep.createdAt))
+		}
+	})
+}
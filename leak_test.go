@@ -0,0 +1,100 @@
+package embeddedpostgres
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_trackLeaks(t *testing.T) {
+	warnings := make(chan string, 2)
+	reportLeak := func(warning string) {
+		warnings <- warning
+	}
+
+	func() {
+		leaked := &EmbeddedPostgres{instanceState: &instanceState{config: DefaultConfig().Port(9871), started: true}}
+		trackLeaks(leaked, reportLeak)
+
+		stopped := &EmbeddedPostgres{instanceState: &instanceState{config: DefaultConfig().Port(9872)}}
+		trackLeaks(stopped, reportLeak)
+	}()
+
+	var warning string
+
+	assert.Eventually(t, func() bool {
+		runtime.GC()
+
+		select {
+		case warning = <-warnings:
+			return true
+		default:
+			return false
+		}
+	}, 5*time.Second, 10*time.Millisecond)
+
+	assert.Contains(t, warning, "WARNING: embedded-postgres on port 9871 was garbage collected without being stopped")
+	assert.Contains(t, warning, "Test_trackLeaks")
+	assert.Empty(t, warnings)
+}
+
+func Test_NewDatabase_TracksLeaks(t *testing.T) {
+	assert.Contains(t, string(NewDatabase().createdAt), "Test_NewDatabase_TracksLeaks")
+}
+
+func Test_trackLeaks_Started(t *testing.T) {
+	warnings := warningWriter(make(chan string, 1))
+
+	var state *instanceState
+
+	func() {
+		database := NewDatabase(DefaultConfig().
+			RuntimePath(t.TempDir()).
+			Port(0).
+			IdleTimeout(time.Hour).
+			StatsPollInterval(time.Hour).
+			LibraryLogger(warnings))
+		require.NoError(t, database.Start())
+
+		state = database.instanceState
+	}()
+
+	defer func() {
+		assert.NoError(t, state.background().Stop())
+	}()
+
+	assertLeakReported(t, warnings)
+}
+
+// warningWriter passes on the leak warnings written to it.
+type warningWriter chan string
+
+func (w warningWriter) Write(p []byte) (int, error) {
+	if strings.HasPrefix(string(p), "WARNING") {
+		select {
+		case w <- string(p):
+		default:
+		}
+	}
+
+	return len(p), nil
+}
+
+func assertLeakReported(t *testing.T, warnings <-chan string) {
+	t.Helper()
+
+	assert.Eventually(t, func() bool {
+		runtime.GC()
+
+		select {
+		case warning := <-warnings:
+			return strings.Contains(warning, "was garbage collected without being stopped")
+		default:
+			return false
+		}
+	}, 5*time.Second, 10*time.Millisecond)
+}
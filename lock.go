@@ -0,0 +1,106 @@
+package embeddedpostgres
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// lockDirectories locks the runtime directory, along with the data directory when it lies elsewhere, so that no other
+// process can clean or initialise them whilst this server uses them.
+func (ep *EmbeddedPostgres) lockDirectories() error {
+	dirs := []string{ep.config.runtimePath}
+	if !isWithin(ep.config.dataPath, ep.config.runtimePath) {
+		dirs = append(dirs, ep.config.dataPath)
+	}
+
+	for _, dir := range dirs {
+		lockFile, err := lockDirectory(dir)
+		if err != nil {
+			ep.unlockDirectories()
+			return err
+		}
+
+		resources.addPath(lockFile)
+		ep.locks = append(ep.locks, lockFile)
+	}
+
+	return nil
+}
+
+func (ep *instanceState) unlockDirectories() {
+	for _, lockFile := range ep.locks {
+		unlockDirectory(lockFile)
+	}
+
+	ep.locks = nil
+}
+
+// lockDirectory marks dir as in use by this process with a lock file alongside it, failing when the lock is held by
+// another running server. Locks left behind by processes that have since exited are taken over. The lock file lives
+// beside rather than within dir as dir is cleaned on start.
+func lockDirectory(dir string) (string, error) {
+	lockFile := filepath.Clean(dir) + ".lock"
+
+	if err := os.MkdirAll(filepath.Dir(lockFile), os.ModePerm); err != nil {
+		return "", fmt.Errorf("unable to create lock file %s: %w", lockFile, err)
+	}
+
+	for attempt := 0; attempt < 2; attempt++ {
+		file, err := os.OpenFile(lockFile, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			_, err = file.WriteString(strconv.Itoa(os.Getpid()))
+			if closeErr := file.Close(); err == nil {
+				err = closeErr
+			}
+
+			if err != nil {
+				_ = os.Remove(lockFile)
+				return "", fmt.Errorf("unable to write lock file %s: %w", lockFile, err)
+			}
+
+			return lockFile, nil
+		}
+
+		if !errors.Is(err, os.ErrExist) {
+			return "", fmt.Errorf("unable to create lock file %s: %w", lockFile, err)
+		}
+
+		pid, err := lockHolder(lockFile)
+		if err == nil && (pid == os.Getpid() || processExists(pid)) {
+			return "", fmt.Errorf("%s is in use by PID %d", dir, pid)
+		}
+
+		// the lock is stale or was released in the meantime
+		if err := os.Remove(lockFile); err != nil && !os.IsNotExist(err) {
+			return "", fmt.Errorf("unable to remove stale lock file %s: %w", lockFile, err)
+		}
+	}
+
+	return "", fmt.Errorf("unable to lock %s as its lock file %s keeps changing", dir, lockFile)
+}
+
+// unlockDirectory removes a lock file created by lockDirectory, provided it is still held by this process.
+func unlockDirectory(lockFile string) {
+	if pid, err := lockHolder(lockFile); err == nil && pid == os.Getpid() {
+		_ = os.Remove(lockFile)
+	}
+}
+
+func lockHolder(lockFile string) (int, error) {
+	content, err := os.ReadFile(lockFile)
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.Atoi(strings.TrimSpace(string(content)))
+}
+
+// isWithin returns true when path is dir or is found beneath it.
+func isWithin(path, dir string) bool {
+	relative, err := filepath.Rel(dir, path)
+	return err == nil && relative != ".." && !strings.HasPrefix(relative, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,78 @@
+package embeddedpostgres
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_lockDirectory(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "runtime")
+
+	lockFile, err := lockDirectory(dir)
+	require.NoError(t, err)
+	assert.Equal(t, dir+".lock", lockFile)
+
+	_, err = lockDirectory(dir)
+	assert.EqualError(t, err, fmt.Sprintf("%s is in use by PID %d", dir, os.Getpid()))
+
+	unlockDirectory(lockFile)
+	assert.NoFileExists(t, lockFile)
+
+	_, err = lockDirectory(dir)
+	assert.NoError(t, err)
+}
+
+func Test_lockDirectory_TakesOverStaleLock(t *testing.T) {
+	cmd := exec.Command("go", "version")
+	require.NoError(t, cmd.Run())
+
+	dir := filepath.Join(t.TempDir(), "runtime")
+	require.NoError(t, os.WriteFile(dir+".lock", []byte(strconv.Itoa(cmd.Process.Pid)), 0600))
+
+	_, err := lockDirectory(dir)
+	require.NoError(t, err)
+
+	pid, err := lockHolder(dir + ".lock")
+	require.NoError(t, err)
+	assert.Equal(t, os.Getpid(), pid)
+}
+
+func Test_unlockDirectory_LeavesOtherHolder(t *testing.T) {
+	lockFile := filepath.Join(t.TempDir(), "runtime.lock")
+	require.NoError(t, os.WriteFile(lockFile, []byte("1"), 0600))
+
+	unlockDirectory(lockFile)
+
+	assert.FileExists(t, lockFile)
+}
+
+func Test_isWithin(t *testing.T) {
+	root := filepath.Join(string(filepath.Separator), "runtime")
+
+	assert.True(t, isWithin(root, root))
+	assert.True(t, isWithin(filepath.Join(root, "data"), root))
+	assert.False(t, isWithin(filepath.Join(string(filepath.Separator), "data"), root))
+	assert.False(t, isWithin(root+"-other", root))
+}
+
+func Test_Start_ErrorWhenRuntimeInUse(t *testing.T) {
+	runtimePath := filepath.Join(t.TempDir(), "runtime")
+	dataPath := filepath.Join(t.TempDir(), "data")
+
+	lockFile, err := lockDirectory(runtimePath)
+	require.NoError(t, err)
+
+	defer unlockDirectory(lockFile)
+
+	database := NewDatabase(DefaultConfig().RuntimePath(runtimePath).DataPath(dataPath))
+
+	assert.EqualError(t, database.Start(), fmt.Sprintf("%s is in use by PID %d", runtimePath, os.Getpid()))
+	assert.NoFileExists(t, dataPath+".lock")
+}
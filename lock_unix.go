@@ -0,0 +1,14 @@
+//go:build !windows
+// +build !windows
+
+package embeddedpostgres
+
+import (
+	"errors"
+	"syscall"
+)
+
+func processExists(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
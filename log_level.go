@@ -0,0 +1,110 @@
+package embeddedpostgres
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"sync"
+)
+
+// LogSeverity is the minimum severity of the server log lines sent to the Logger, as set with Config.LogLevel.
+type LogSeverity int
+
+// The severities of server log lines in increasing order. LOG, which Postgres itself ranks above ERROR in the server
+// log, ranks alongside INFO so that routine messages can be left out.
+const (
+	LogSeverityDebug LogSeverity = iota
+	LogSeverityInfo
+	LogSeverityNotice
+	LogSeverityWarning
+	LogSeverityError
+	LogSeverityFatal
+	LogSeverityPanic
+)
+
+// serverLogLine matches a line logged by the server after its log_line_prefix, such as
+// "2022-03-01 12:00:00.000 UTC [42] LOG:  database system is ready to accept connections".
+var serverLogLine = regexp.MustCompile(`^(?:.*?\s)?(DEBUG[1-5]|LOG|INFO|NOTICE|WARNING|ERROR|FATAL|PANIC|DETAIL|HINT|CONTEXT|STATEMENT|QUERY|LOCATION):\s+(.*)$`)
+
+// parseServerLogLine returns the severity and message of a server log line, or false for other output.
+func parseServerLogLine(line string) (severity, message string, ok bool) {
+	match := serverLogLine.FindStringSubmatch(line)
+	if match == nil {
+		return "", "", false
+	}
+
+	return match[1], match[2], true
+}
+
+// logSeverity returns the LogSeverity of a server log severity, or false for the severities of lines that add to the
+// one before, such as DETAIL.
+func logSeverity(severity string) (LogSeverity, bool) {
+	switch severity {
+	case "LOG", "INFO":
+		return LogSeverityInfo, true
+	case "NOTICE":
+		return LogSeverityNotice, true
+	case "WARNING":
+		return LogSeverityWarning, true
+	case "ERROR":
+		return LogSeverityError, true
+	case "FATAL":
+		return LogSeverityFatal, true
+	case "PANIC":
+		return LogSeverityPanic, true
+	case "DETAIL", "HINT", "CONTEXT", "STATEMENT", "QUERY", "LOCATION":
+		return 0, false
+	default:
+		return LogSeverityDebug, true
+	}
+}
+
+// severityFilter passes on the lines written to it whose severity is at least min. Lines without a severity of their
+// own, such as DETAIL lines and the continuation of multi-line messages, are passed on along with the line before.
+type severityFilter struct {
+	mu      sync.Mutex
+	writer  io.Writer
+	min     LogSeverity
+	partial []byte
+	passing bool
+}
+
+// filterLogSeverity returns writer when every line is to be passed on, and otherwise a filter in front of it.
+func filterLogSeverity(writer io.Writer, min LogSeverity) io.Writer {
+	if writer == nil || min <= LogSeverityDebug {
+		return writer
+	}
+
+	return &severityFilter{writer: writer, min: min, passing: true}
+}
+
+func (f *severityFilter) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.partial = append(f.partial, p...)
+
+	for {
+		end := bytes.IndexByte(f.partial, '\n')
+		if end < 0 {
+			break
+		}
+
+		line := f.partial[:end+1]
+		f.partial = f.partial[end+1:]
+
+		if severity, _, ok := parseServerLogLine(string(bytes.TrimRight(line, "\r\n"))); ok {
+			if level, ok := logSeverity(severity); ok {
+				f.passing = level >= f.min
+			}
+		}
+
+		if f.passing {
+			if _, err := f.writer.Write(line); err != nil {
+				return len(p), err
+			}
+		}
+	}
+
+	return len(p), nil
+}
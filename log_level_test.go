@@ -0,0 +1,70 @@
+package embeddedpostgres
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_filterLogSeverity(t *testing.T) {
+	var out bytes.Buffer
+
+	assert.Equal(t, &out, filterLogSeverity(&out, LogSeverityDebug))
+	assert.Nil(t, filterLogSeverity(nil, LogSeverityWarning))
+
+	filter := filterLogSeverity(&out, LogSeverityWarning)
+
+	for _, chunk := range []string{
+		"creating configuration files ... ok\n",
+		"2022-03-01 12:00:00.000 UTC [42] LOG:  statement: SELECT *\n\tFROM beer\n",
+		"2022-03-01 12:00:00.000 UTC [42] ERROR:  relation \"beer\" does not exist\n2022-03-01 12:00:00.000 UTC [42] STATEMENT:  SELECT *\n",
+		"\tFROM beer\n2022-03-01 12:00:00.000 UTC [42] DEBUG1:  checkpoint",
+		"er starting\n2022-03-01 12:00:00.000 UTC [42] WARNING:  there is no transaction in progress\n",
+	} {
+		n, err := filter.Write([]byte(chunk))
+		require.NoError(t, err)
+		assert.Equal(t, len(chunk), n)
+	}
+
+	assert.Equal(t, "creating configuration files ... ok\n"+
+		"2022-03-01 12:00:00.000 UTC [42] ERROR:  relation \"beer\" does not exist\n"+
+		"2022-03-01 12:00:00.000 UTC [42] STATEMENT:  SELECT *\n"+
+		"\tFROM beer\n"+
+		"2022-03-01 12:00:00.000 UTC [42] WARNING:  there is no transaction in progress\n", out.String())
+}
+
+func Test_logSeverity(t *testing.T) {
+	for severity, expected := range map[string]LogSeverity{
+		"DEBUG3":  LogSeverityDebug,
+		"LOG":     LogSeverityInfo,
+		"INFO":    LogSeverityInfo,
+		"NOTICE":  LogSeverityNotice,
+		"WARNING": LogSeverityWarning,
+		"ERROR":   LogSeverityError,
+		"FATAL":   LogSeverityFatal,
+		"PANIC":   LogSeverityPanic,
+	} {
+		actual, ok := logSeverity(severity)
+		assert.True(t, ok, severity)
+		assert.Equal(t, expected, actual, severity)
+	}
+
+	_, ok := logSeverity("DETAIL")
+	assert.False(t, ok)
+}
+
+func Test_LogLevel(t *testing.T) {
+	logger := &lockedBuffer{}
+
+	database := NewDatabase(DefaultConfig().
+		RuntimePath(t.TempDir()).
+		Port(0).
+		Logger(logger).
+		LogLevel(LogSeverityWarning))
+	require.NoError(t, database.Start())
+	require.NoError(t, database.Stop())
+
+	assert.NotContains(t, logger.String(), "LOG:  database system is ready to accept connections")
+}
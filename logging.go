@@ -5,13 +5,28 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"time"
 )
 
+// logStreamInterval is how often postgres output is copied to the logger whilst it is being streamed.
+const logStreamInterval = 100 * time.Millisecond
+
+// syncedLogger holds the output of postgres and its tools in a file, which the processes write to directly, and copies
+// it to the configured logger as it is written whilst streaming and whenever it is flushed. The standard output of the
+// server can be split into a file of its own, copied to a separate logger.
 type syncedLogger struct {
-	offset int64
-	logger io.Writer
-	file   *os.File
+	mu             sync.Mutex
+	offset         int64
+	logger         io.Writer
+	file           *os.File
+	stdout         *os.File
+	stdoutOffset   int64
+	stdoutLogger   io.Writer
+	streaming      chan struct{}
+	streamFinished chan struct{}
 }
 
 func newSyncedLogger(dir string, logger io.Writer) (*syncedLogger, error) {
@@ -28,34 +43,193 @@ func newSyncedLogger(dir string, logger io.Writer) (*syncedLogger, error) {
 	return &s, nil
 }
 
-func (s *syncedLogger) flush() error {
-	if s.logger != nil {
-		file, err := os.Open(s.file.Name())
-		if err != nil {
-			return fmt.Errorf("unable to process postgres logs: %s", err)
-		}
+// splitStdout holds the standard output of the server in a file of its own, copied to logger rather than to the
+// logger for the rest of the output.
+func (s *syncedLogger) splitStdout(logger io.Writer) error {
+	file, err := os.CreateTemp(filepath.Dir(s.file.Name()), "embedded_postgres_stdout")
+	if err != nil {
+		return err
+	}
 
-		defer func() {
-			if err := file.Close(); err != nil {
-				panic(err)
-			}
-		}()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stdout = file
+	s.stdoutLogger = logger
+
+	return nil
+}
+
+// stdoutFile returns the file the server writes its standard output to.
+func (s *syncedLogger) stdoutFile() *os.File {
+	if s.stdout != nil {
+		return s.stdout
+	}
+
+	return s.file
+}
+
+// stream copies the output to the logger every interval in the background until stopStreaming is called, so that it
+// is seen as it happens, such as whilst Start hangs, rather than only once flushed.
+func (s *syncedLogger) stream(interval time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if (s.logger == nil && s.stdoutLogger == nil) || s.streaming != nil {
+		return
+	}
+
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	s.streaming = done
+	s.streamFinished = finished
+
+	go func() {
+		defer close(finished)
 
-		if _, err = file.Seek(s.offset, io.SeekStart); err != nil {
-			return fmt.Errorf("unable to process postgres logs: %s", err)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				_ = s.flush()
+			}
 		}
+	}()
+}
+
+// stopStreaming stops copying the output in the background, waiting for any copy in progress to finish.
+func (s *syncedLogger) stopStreaming() {
+	s.mu.Lock()
+	done, finished := s.streaming, s.streamFinished
+	s.streaming, s.streamFinished = nil, nil
+	s.mu.Unlock()
 
-		readBytes, err := io.Copy(s.logger, file)
-		if err != nil {
-			return fmt.Errorf("unable to process postgres logs: %s", err)
+	if done == nil {
+		return
+	}
+
+	close(done)
+	<-finished
+}
+
+func (s *syncedLogger) flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := copyNewOutput(s.file, &s.offset, s.logger); err != nil {
+		return err
+	}
+
+	if s.stdout != nil {
+		return copyNewOutput(s.stdout, &s.stdoutOffset, s.stdoutLogger)
+	}
+
+	return nil
+}
+
+// copyNewOutput copies what has been written to output since offset to logger, advancing offset.
+func copyNewOutput(output *os.File, offset *int64, logger io.Writer) error {
+	if logger == nil {
+		return nil
+	}
+
+	file, err := os.Open(output.Name())
+	if err != nil {
+		return fmt.Errorf("unable to process postgres logs: %s", err)
+	}
+
+	defer func() {
+		if err := file.Close(); err != nil {
+			panic(err)
 		}
+	}()
 
-		s.offset += readBytes
+	if _, err = file.Seek(*offset, io.SeekStart); err != nil {
+		return fmt.Errorf("unable to process postgres logs: %s", err)
 	}
 
+	readBytes, err := io.Copy(logger, file)
+	if err != nil {
+		return fmt.Errorf("unable to process postgres logs: %s", err)
+	}
+
+	*offset += readBytes
+
 	return nil
 }
 
+// reportDiagnostic reports a problem the library ran into that cannot be returned as an error, such as whilst stopping
+// the server from a watcher, to the configured diagnostic logger or otherwise to the postgres log.
+func (ep *instanceState) reportDiagnostic(message string) {
+	if ep.config.diagnostic != nil {
+		ep.config.diagnostic(message)
+		return
+	}
+
+	_, _ = ep.syncedLogger.file.WriteString(message + "\n")
+}
+
+// logTailReadSize bounds how much of the end of the postgres log is read to find its last lines.
+const logTailReadSize = 64 * 1024
+
+// withLogTail adds the last lines of the postgres log to err, unless they are already part of it, so that failures to
+// start or stop the server can be diagnosed from the error alone.
+func (ep *EmbeddedPostgres) withLogTail(err error) error {
+	if err == nil || ep.syncedLogger == nil || ep.config.logTailLines <= 0 {
+		return err
+	}
+
+	tail, readErr := readLogTail(ep.syncedLogger.file.Name(), ep.config.logTailLines)
+	if readErr != nil || tail == "" || strings.Contains(err.Error(), tail) {
+		return err
+	}
+
+	return fmt.Errorf("%w\nlast lines of the postgres log:\n%s", err, tail)
+}
+
+// readLogTail returns the last lines of the log at path.
+func readLogTail(path string, lines int) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+
+	defer func() {
+		_ = file.Close()
+	}()
+
+	info, err := file.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	offset := info.Size() - logTailReadSize
+	if offset < 0 {
+		offset = 0
+	}
+
+	content := make([]byte, info.Size()-offset)
+	if _, err := file.ReadAt(content, offset); err != nil && err != io.EOF {
+		return "", err
+	}
+
+	all := strings.Split(strings.TrimRight(string(content), "\n"), "\n")
+	if offset > 0 {
+		// the first line read is likely to be partial
+		all = all[1:]
+	}
+
+	if len(all) > lines {
+		all = all[len(all)-lines:]
+	}
+
+	return strings.Join(all, "\n"), nil
+}
+
 func readLogsOrTimeout(logger *os.File) (logContent []byte, err error) {
 	logContent = []byte("logs could not be read")
 
@@ -1,10 +1,16 @@
 package embeddedpostgres
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -81,3 +87,167 @@ func Test_readLogsOrTimeout(t *testing.T) {
 	assert.Equal(t, []byte("logs could not be read"), logContent)
 	assert.EqualError(t, err, fmt.Sprintf("open %s: no such file or directory", logFile.Name()))
 }
+
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.String()
+}
+
+func Test_SyncedLogger_Stream(t *testing.T) {
+	logger := &lockedBuffer{}
+
+	sl, err := newSyncedLogger(t.TempDir(), logger)
+	require.NoError(t, err)
+
+	sl.stream(10 * time.Millisecond)
+
+	_, err = sl.file.WriteString("waiting for server to start\n")
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		return logger.String() == "waiting for server to start\n"
+	}, 5*time.Second, 10*time.Millisecond)
+
+	sl.stopStreaming()
+	sl.stopStreaming()
+
+	_, err = sl.file.WriteString("server started\n")
+	require.NoError(t, err)
+
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, "waiting for server to start\n", logger.String())
+
+	require.NoError(t, sl.flush())
+	assert.Equal(t, "waiting for server to start\nserver started\n", logger.String())
+}
+
+func Test_SyncedLogger_StreamWithoutLogger(t *testing.T) {
+	sl, err := newSyncedLogger(t.TempDir(), nil)
+	require.NoError(t, err)
+
+	sl.stream(10 * time.Millisecond)
+
+	assert.Nil(t, sl.streaming)
+	sl.stopStreaming()
+}
+
+func Test_readLogTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log")
+	require.NoError(t, os.WriteFile(path, []byte("one\ntwo\nthree\nfour\n"), 0600))
+
+	tail, err := readLogTail(path, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "three\nfour", tail)
+
+	tail, err = readLogTail(path, 10)
+	require.NoError(t, err)
+	assert.Equal(t, "one\ntwo\nthree\nfour", tail)
+
+	long := strings.Repeat("x", logTailReadSize) + "\nlast\n"
+	require.NoError(t, os.WriteFile(path, []byte(long), 0600))
+
+	tail, err = readLogTail(path, 10)
+	require.NoError(t, err)
+	assert.Equal(t, "last", tail)
+}
+
+func Test_withLogTail(t *testing.T) {
+	logger, err := newSyncedLogger(t.TempDir(), nil)
+	require.NoError(t, err)
+
+	database := NewDatabase(DefaultConfig().LogTailLines(2))
+	assert.EqualError(t, database.withLogTail(errDiskFull), errDiskFull.Error())
+
+	database.syncedLogger = logger
+	assert.EqualError(t, database.withLogTail(errDiskFull), errDiskFull.Error())
+
+	_, err = logger.file.WriteString("LOG:  starting\nFATAL:  could not create shared memory segment\nLOG:  database system is shut down\n")
+	require.NoError(t, err)
+
+	err = database.withLogTail(errDiskFull)
+	assert.True(t, errors.Is(err, errDiskFull))
+	assert.Equal(t, errDiskFull.Error()+"\nlast lines of the postgres log:\n"+
+		"FATAL:  could not create shared memory segment\nLOG:  database system is shut down", err.Error())
+
+	assert.Nil(t, database.withLogTail(nil))
+
+	already := errors.New("could not start postgres:\nFATAL:  could not create shared memory segment\nLOG:  database system is shut down")
+	assert.Equal(t, already, database.withLogTail(already))
+
+	database.config = database.config.LogTailLines(0)
+	assert.Equal(t, errDiskFull, database.withLogTail(errDiskFull))
+}
+
+func Test_Start_ErrorIncludesLogTail(t *testing.T) {
+	database := NewDatabase(DefaultConfig().
+		RuntimePath(t.TempDir()).
+		Port(0).
+		StartTimeout(3*time.Second).
+		StartParameter("maintenance_work_mem", "plenty"))
+
+	err := database.Start()
+
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "last lines of the postgres log:\n")
+	assert.Contains(t, err.Error(), `invalid value for parameter "maintenance_work_mem": "plenty"`)
+}
+
+func Test_SyncedLogger_SplitStdout(t *testing.T) {
+	stderr := customLogger{}
+	stdout := customLogger{}
+
+	sl, err := newSyncedLogger(t.TempDir(), &stderr)
+	require.NoError(t, err)
+	assert.Equal(t, sl.file, sl.stdoutFile())
+
+	require.NoError(t, sl.splitStdout(&stdout))
+	assert.Equal(t, sl.stdout, sl.stdoutFile())
+
+	_, err = sl.file.WriteString("LOG:  database system is ready to accept connections\n")
+	require.NoError(t, err)
+	_, err = sl.stdout.WriteString("ready\n")
+	require.NoError(t, err)
+
+	require.NoError(t, sl.flush())
+
+	assert.Equal(t, "LOG:  database system is ready to accept connections\n", string(stderr.logLines))
+	assert.Equal(t, "ready\n", string(stdout.logLines))
+}
+
+func Test_LibraryLogger(t *testing.T) {
+	logger := customLogger{}
+	database := NewDatabase(DefaultConfig().LibraryLogger(&logger))
+
+	database.reportDiagnostic("unable to stop postgres: exit status 1\n")
+
+	assert.Equal(t, "unable to stop postgres: exit status 1\n", string(logger.logLines))
+}
+
+func Test_ServerOutput(t *testing.T) {
+	stdout := &lockedBuffer{}
+	stderr := &lockedBuffer{}
+
+	database := NewDatabase(DefaultConfig().
+		RuntimePath(t.TempDir()).
+		Port(0).
+		ServerOutput(stdout, stderr))
+	require.NoError(t, database.Start())
+	require.NoError(t, database.Stop())
+
+	assert.Contains(t, stderr.String(), "database system is ready to accept connections")
+	assert.NotContains(t, stdout.String(), "database system is ready to accept connections")
+}
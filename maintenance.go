@@ -0,0 +1,37 @@
+package embeddedpostgres
+
+import (
+	"context"
+	"fmt"
+)
+
+// Checkpoint forces an immediate checkpoint, flushing all dirty buffers to disk.
+func (ep *EmbeddedPostgres) Checkpoint() error {
+	return ep.execStatements(context.Background(), ep.config.database, "CHECKPOINT")
+}
+
+// Vacuum runs VACUUM against the given tables of the configured database.
+// When no tables are provided every table the configured user has access to is vacuumed.
+func (ep *EmbeddedPostgres) Vacuum(tables ...string) error {
+	return ep.execStatements(context.Background(), ep.config.database, maintenanceStatements("VACUUM", tables)...)
+}
+
+// Analyze runs ANALYZE against the given tables of the configured database, populating planner statistics.
+// When no tables are provided every table the configured user has access to is analyzed.
+func (ep *EmbeddedPostgres) Analyze(tables ...string) error {
+	return ep.execStatements(context.Background(), ep.config.database, maintenanceStatements("ANALYZE", tables)...)
+}
+
+func maintenanceStatements(command string, tables []string) []string {
+	if len(tables) == 0 {
+		return []string{command}
+	}
+
+	// one statement per table as multiple tables in a single VACUUM or ANALYZE is only supported from Postgres 11
+	statements := make([]string, 0, len(tables))
+	for _, table := range tables {
+		statements = append(statements, fmt.Sprintf("%s %s", command, quoteQualifiedIdentifier(table)))
+	}
+
+	return statements
+}
@@ -0,0 +1,70 @@
+package embeddedpostgres
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_maintenanceStatements(t *testing.T) {
+	assert.Equal(t, []string{"VACUUM"}, maintenanceStatements("VACUUM", nil))
+	assert.Equal(t,
+		[]string{`ANALYZE "users"`, `ANALYZE "audit"."events"`},
+		maintenanceStatements("ANALYZE", []string{"users", "audit.events"}))
+}
+
+func Test_Checkpoint_ErrorWhenNotStarted(t *testing.T) {
+	database := NewDatabase()
+
+	assert.EqualError(t, database.Checkpoint(), "server has not been started")
+	assert.EqualError(t, database.Vacuum(), "server has not been started")
+	assert.EqualError(t, database.Analyze(), "server has not been started")
+}
+
+func Test_MaintenanceHelpers(t *testing.T) {
+	database := NewDatabase()
+	if err := database.Start(); err != nil {
+		shutdownDBAndFail(t, err, database)
+	}
+
+	db, err := sql.Open("postgres", "host=localhost port=5432 user=postgres password=postgres dbname=postgres sslmode=disable")
+	if err != nil {
+		shutdownDBAndFail(t, err, database)
+	}
+
+	if _, err := db.Exec("CREATE TABLE measurements(id serial PRIMARY KEY, value int)"); err != nil {
+		shutdownDBAndFail(t, err, database)
+	}
+
+	if _, err := db.Exec("INSERT INTO measurements (value) SELECT generate_series(1, 1000)"); err != nil {
+		shutdownDBAndFail(t, err, database)
+	}
+
+	if err := database.Vacuum("measurements"); err != nil {
+		shutdownDBAndFail(t, err, database)
+	}
+
+	if err := database.Analyze("public.measurements"); err != nil {
+		shutdownDBAndFail(t, err, database)
+	}
+
+	if err := database.Checkpoint(); err != nil {
+		shutdownDBAndFail(t, err, database)
+	}
+
+	var tuples float64
+	if err := db.QueryRow("SELECT reltuples FROM pg_class WHERE relname = 'measurements'").Scan(&tuples); err != nil {
+		shutdownDBAndFail(t, err, database)
+	}
+
+	assert.Equal(t, float64(1000), tuples)
+
+	if err := db.Close(); err != nil {
+		shutdownDBAndFail(t, err, database)
+	}
+
+	if err := database.Stop(); err != nil {
+		shutdownDBAndFail(t, err, database)
+	}
+}
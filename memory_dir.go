@@ -0,0 +1,34 @@
+package embeddedpostgres
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// placeDataInMemory places the data directory in memory for Config.DataInMemory, falling back to the runtime directory
+// with a diagnostic where memory cannot be used. The memory is held until CleanupAll, so that the data directory
+// survives Stop for Restart and snapshots, and the data directory is emptied on every start as it would be within the
+// runtime directory.
+func (ep *EmbeddedPostgres) placeDataInMemory() error {
+	if ep.memoryDataDir {
+		if err := os.RemoveAll(ep.config.dataPath); err != nil {
+			return fmt.Errorf("unable to clean up data directory %s with error: %s", ep.config.dataPath, err)
+		}
+
+		return nil
+	}
+
+	dir, release, err := memoryDir(ep.config.dataInMemorySize)
+	if err != nil {
+		ep.reportDiagnostic(fmt.Sprintf("unable to place the data directory in memory, using the runtime directory: %s", err))
+		return nil
+	}
+
+	resources.addRelease(dir, release)
+
+	ep.config.dataPath = filepath.Join(dir, "data")
+	ep.memoryDataDir = true
+
+	return nil
+}
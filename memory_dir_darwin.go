@@ -0,0 +1,47 @@
+package embeddedpostgres
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+)
+
+// defaultRAMDiskSize is the size of the RAM disk created when DataInMemory is given no size limit.
+const defaultRAMDiskSize = 1 << 30
+
+var ramDiskSequence uint64
+
+// memoryDir creates and mounts a RAM disk of sizeLimit bytes, returning a directory on it along with a function
+// detaching it.
+func memoryDir(sizeLimit int64) (string, func() error, error) {
+	if sizeLimit <= 0 {
+		sizeLimit = defaultRAMDiskSize
+	}
+
+	// RAM disks are sized in 512 byte sectors
+	out, err := exec.Command("hdiutil", "attach", "-nomount", fmt.Sprintf("ram://%d", (sizeLimit+511)/512)).Output()
+	if err != nil {
+		return "", nil, fmt.Errorf("unable to create RAM disk: %w", err)
+	}
+
+	device := strings.TrimSpace(string(out))
+	detach := func() error {
+		if out, err := exec.Command("hdiutil", "detach", device, "-force").CombinedOutput(); err != nil {
+			return fmt.Errorf("unable to detach RAM disk %s: %s", device, strings.TrimSpace(string(out)))
+		}
+
+		return nil
+	}
+
+	name := fmt.Sprintf("embedded-postgres-%d-%d", os.Getpid(), atomic.AddUint64(&ramDiskSequence, 1))
+
+	if out, err := exec.Command("diskutil", "erasevolume", "HFS+", name, device).CombinedOutput(); err != nil {
+		_ = detach()
+		return "", nil, fmt.Errorf("unable to format RAM disk %s: %s", device, strings.TrimSpace(string(out)))
+	}
+
+	return filepath.Join("/Volumes", name), detach, nil
+}
@@ -0,0 +1,56 @@
+package embeddedpostgres
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+)
+
+const tmpfsMagic = 0x01021994
+
+// memoryDir creates a directory on a tmpfs mount with at least sizeLimit bytes free, such as /dev/shm, returning it
+// along with a function removing it.
+func memoryDir(sizeLimit int64) (string, func() error, error) {
+	var reasons []string
+
+	for _, mount := range []string{"/dev/shm", os.Getenv("XDG_RUNTIME_DIR")} {
+		if mount == "" {
+			continue
+		}
+
+		if err := checkTmpfs(mount, sizeLimit); err != nil {
+			reasons = append(reasons, err.Error())
+			continue
+		}
+
+		dir, err := os.MkdirTemp(mount, "embedded-postgres-")
+		if err != nil {
+			reasons = append(reasons, err.Error())
+			continue
+		}
+
+		return dir, func() error {
+			return os.RemoveAll(dir)
+		}, nil
+	}
+
+	return "", nil, fmt.Errorf("no tmpfs mount is available: %s", strings.Join(reasons, ", "))
+}
+
+func checkTmpfs(mount string, sizeLimit int64) error {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(mount, &stat); err != nil {
+		return fmt.Errorf("unable to inspect %s: %w", mount, err)
+	}
+
+	if int64(stat.Type) != tmpfsMagic {
+		return fmt.Errorf("%s is not tmpfs", mount)
+	}
+
+	if free := int64(stat.Bavail) * int64(stat.Bsize); free < sizeLimit {
+		return fmt.Errorf("%s has %d bytes free of the %d required", mount, free, sizeLimit)
+	}
+
+	return nil
+}
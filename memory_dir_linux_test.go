@@ -0,0 +1,28 @@
+package embeddedpostgres
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_memoryDir(t *testing.T) {
+	dir, release, err := memoryDir(0)
+	if err != nil {
+		t.Skipf("no tmpfs mount on this host: %s", err)
+	}
+
+	assert.DirExists(t, dir)
+	require.NoError(t, checkTmpfs(dir, 0))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "beer"), []byte("stout"), 0600))
+	require.NoError(t, release())
+	assert.NoDirExists(t, dir)
+}
+
+func Test_checkTmpfs_NotTmpfs(t *testing.T) {
+	assert.EqualError(t, checkTmpfs("/proc", 0), "/proc is not tmpfs")
+}
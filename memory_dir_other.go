@@ -0,0 +1,11 @@
+//go:build !linux && !darwin
+// +build !linux,!darwin
+
+package embeddedpostgres
+
+import "errors"
+
+// memoryDir is unsupported on this platform.
+func memoryDir(sizeLimit int64) (string, func() error, error) {
+	return "", nil, errors.New("placing the data directory in memory is not supported on this platform")
+}
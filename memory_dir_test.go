@@ -0,0 +1,41 @@
+package embeddedpostgres
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_memoryDir_TooLarge(t *testing.T) {
+	_, _, err := memoryDir(1 << 62)
+
+	assert.NotNil(t, err)
+}
+
+func Test_placeDataInMemory_FallsBack(t *testing.T) {
+	var diagnostics bytes.Buffer
+
+	database := NewDatabase(DefaultConfig().DataInMemory(1 << 62).LibraryLogger(&diagnostics))
+
+	require.NoError(t, database.placeDataInMemory())
+
+	assert.Empty(t, database.config.dataPath)
+	assert.False(t, database.memoryDataDir)
+	assert.Contains(t, diagnostics.String(), "unable to place the data directory in memory, using the runtime directory: ")
+}
+
+func Test_DataInMemory(t *testing.T) {
+	database := StartForTest(t, DefaultConfig().DataInMemory(0))
+
+	if !database.memoryDataDir {
+		t.Skip("memory cannot be used for the data directory on this host")
+	}
+
+	assert.False(t, isWithin(database.config.dataPath, database.config.runtimePath))
+
+	db, err := database.Open("postgres")
+	require.NoError(t, err)
+	assert.NoError(t, db.Ping())
+}
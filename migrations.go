@@ -0,0 +1,32 @@
+package embeddedpostgres
+
+import (
+	"context"
+	"fmt"
+)
+
+// MigrationRunner migrates the schema of the database at dsn, as returned by EmbeddedPostgres.ConnectionString, when
+// configured with Config.Migrations. The embeddedmigrate, embeddedgoose and embeddedatlas modules provide runners for
+// golang-migrate, goose and Atlas.
+type MigrationRunner interface {
+	Run(ctx context.Context, dsn string) error
+}
+
+// MigrationFunc adapts a function to a MigrationRunner.
+type MigrationFunc func(ctx context.Context, dsn string) error
+
+// Run calls f(ctx, dsn).
+func (f MigrationFunc) Run(ctx context.Context, dsn string) error {
+	return f(ctx, dsn)
+}
+
+// runMigrations runs the configured migration runners in order.
+func (ep *EmbeddedPostgres) runMigrations(ctx context.Context) error {
+	for _, runner := range ep.config.migrations {
+		if err := runner.Run(ctx, ep.ConnectionString()); err != nil {
+			return fmt.Errorf("unable to run migrations: %w", err)
+		}
+	}
+
+	return nil
+}
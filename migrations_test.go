@@ -0,0 +1,74 @@
+package embeddedpostgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Migrations(t *testing.T) {
+	var migratedWith string
+
+	database := NewDatabase(DefaultConfig().
+		RuntimePath(t.TempDir()).
+		Port(0).
+		Migrations(MigrationFunc(func(ctx context.Context, connectionString string) error {
+			migratedWith = connectionString
+
+			db, err := sql.Open("postgres", connectionString)
+			if err != nil {
+				return err
+			}
+
+			defer func() {
+				_ = db.Close()
+			}()
+
+			_, err = db.ExecContext(ctx, "CREATE TABLE brews (name text)")
+
+			return err
+		})))
+	require.NoError(t, database.Start())
+
+	defer func() {
+		assert.NoError(t, database.Stop())
+	}()
+
+	assert.Equal(t, database.ConnectionString(), migratedWith)
+	assert.Empty(t, brews(t, database))
+}
+
+func Test_Migrations_ErrorStopsStart(t *testing.T) {
+	database := NewDatabase(DefaultConfig().
+		RuntimePath(t.TempDir()).
+		Port(0).
+		Migrations(MigrationFunc(func(ctx context.Context, connectionString string) error {
+			return errors.New("dirty database version 3")
+		})))
+
+	assert.EqualError(t, database.Start(), "unable to run migrations: dirty database version 3")
+	assert.False(t, database.isStarted())
+}
+
+func Test_runMigrations_RunsInOrderUntilError(t *testing.T) {
+	var ran []string
+
+	runner := func(name string, err error) MigrationRunner {
+		return MigrationFunc(func(ctx context.Context, connectionString string) error {
+			ran = append(ran, name)
+			return err
+		})
+	}
+
+	database := NewDatabase(DefaultConfig().Migrations(
+		runner("schema", nil),
+		runner("data", errors.New("no such table")),
+		runner("never", nil)))
+
+	assert.EqualError(t, database.runMigrations(context.Background()), "unable to run migrations: no such table")
+	assert.Equal(t, []string{"schema", "data"}, ran)
+}
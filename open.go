@@ -0,0 +1,70 @@
+package embeddedpostgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Open opens a connection pool to the running server with the named database/sql driver, such as "postgres" for
+// lib/pq, using a connection string built by ConnectionString with opts. It waits for the server to accept
+// connections, retrying for up to the configured start timeout, and the pool is closed when the server is stopped.
+func (ep *EmbeddedPostgres) Open(driverName string, opts ...ConnectionOption) (*sql.DB, error) {
+	if !ep.isStarted() {
+		return nil, errors.New("server has not been started")
+	}
+
+	db, err := sql.Open(driverName, ep.ConnectionString(opts...))
+	if err != nil {
+		return nil, fmt.Errorf("unable to open %s connection pool: %w", driverName, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), ep.config.startTimeout)
+	defer cancel()
+
+	if err := pingUntilHealthy(ctx, db); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	ep.poolsMu.Lock()
+	ep.pools = append(ep.pools, db)
+	ep.poolsMu.Unlock()
+
+	return db, nil
+}
+
+// OpenPgx opens a connection pool with the pgx database/sql driver as Open does. The driver is registered by importing
+// github.com/jackc/pgx/v5/stdlib, which this package leaves to the caller so as not to depend on pgx itself.
+func (ep *EmbeddedPostgres) OpenPgx(opts ...ConnectionOption) (*sql.DB, error) {
+	return ep.Open("pgx", opts...)
+}
+
+func pingUntilHealthy(ctx context.Context, db *sql.DB) error {
+	for {
+		err := db.PingContext(ctx)
+		if err == nil {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("unable to connect to postgres: %w", err)
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// closePools closes the connection pools handed out by Open, so that they do not outlive the server.
+func (ep *instanceState) closePools() {
+	ep.poolsMu.Lock()
+	defer ep.poolsMu.Unlock()
+
+	for _, db := range ep.pools {
+		_ = db.Close()
+	}
+
+	ep.pools = nil
+}
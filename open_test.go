@@ -0,0 +1,31 @@
+package embeddedpostgres
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Open_ErrorWhenNotStarted(t *testing.T) {
+	db, err := NewDatabase().Open("postgres")
+
+	assert.Nil(t, db)
+	assert.EqualError(t, err, "server has not been started")
+}
+
+func Test_Open(t *testing.T) {
+	database := NewDatabase(DefaultConfig().RuntimePath(t.TempDir()).Port(0))
+	require.NoError(t, database.Start())
+
+	db, err := database.Open("postgres", WithDatabase("postgres"))
+	require.NoError(t, err)
+
+	var one int
+	require.NoError(t, db.QueryRow("SELECT 1").Scan(&one))
+	assert.Equal(t, 1, one)
+
+	require.NoError(t, database.Stop())
+
+	assert.EqualError(t, db.Ping(), "sql: database is closed")
+}
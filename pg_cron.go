@@ -0,0 +1,98 @@
+package embeddedpostgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const cronJobPollInterval = 100 * time.Millisecond
+
+// RunCronJob runs the command of the pg_cron job named jobName immediately, within the database the job is scheduled
+// for, so tests need not wait for its schedule. Config.PgCron must be enabled.
+func (ep *EmbeddedPostgres) RunCronJob(ctx context.Context, jobName string) (err error) {
+	if err := ep.checkPgCron(); err != nil {
+		return err
+	}
+
+	db, err := ep.openDB(ep.config.database)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		err = connectionClose(db, err)
+	}()
+
+	var command, database string
+
+	err = db.QueryRowContext(ctx, "SELECT command, database FROM cron.job WHERE jobname = $1", jobName).Scan(&command, &database)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("cron job %q does not exist", jobName)
+	}
+
+	if err != nil {
+		return fmt.Errorf("unable to find cron job %q: %w", jobName, err)
+	}
+
+	return ep.execStatements(ctx, database, command)
+}
+
+// WaitForCronJob waits until a run of the pg_cron job named jobName that started at or after since has completed,
+// returning an error if the run failed or ctx is done first. Config.PgCron must be enabled.
+func (ep *EmbeddedPostgres) WaitForCronJob(ctx context.Context, jobName string, since time.Time) (err error) {
+	if err := ep.checkPgCron(); err != nil {
+		return err
+	}
+
+	db, err := ep.openDB(ep.config.database)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		err = connectionClose(db, err)
+	}()
+
+	ticker := time.NewTicker(cronJobPollInterval)
+	defer ticker.Stop()
+
+	for {
+		var status, message string
+
+		err := db.QueryRowContext(ctx, `SELECT d.status, coalesce(d.return_message, '')
+FROM cron.job_run_details d JOIN cron.job j ON j.jobid = d.jobid
+WHERE j.jobname = $1 AND d.start_time >= $2 AND d.status IN ('succeeded', 'failed')
+ORDER BY d.start_time
+LIMIT 1`, jobName, since).Scan(&status, &message)
+
+		switch {
+		case err == nil && status == "failed":
+			return fmt.Errorf("cron job %q failed: %s", jobName, message)
+		case err == nil:
+			return nil
+		case !errors.Is(err, sql.ErrNoRows) && ctx.Err() == nil:
+			return fmt.Errorf("unable to check runs of cron job %q: %w", jobName, err)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for cron job %q to run: %w", jobName, ctx.Err())
+		}
+	}
+}
+
+func (ep *EmbeddedPostgres) checkPgCron() error {
+	if !ep.config.pgCron {
+		return errors.New("pg_cron is not enabled")
+	}
+
+	if !ep.started {
+		return errors.New("server has not been started")
+	}
+
+	return nil
+}
@@ -0,0 +1,38 @@
+package embeddedpostgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_PgCronConfig(t *testing.T) {
+	config := DefaultConfig().Database("app").PgCron("pg_cron.txz")
+
+	assert.True(t, config.pgCron)
+	assert.Equal(t, []string{"pg_cron.txz"}, config.extensionArchives)
+	assert.Equal(t, []preloadedExtension{{
+		name:    "pg_cron",
+		builder: "Config.PgCron",
+		settings: map[string]string{
+			"cron.database_name":          "app",
+			"cron.use_background_workers": "on",
+		},
+	}}, preloadedExtensions(config))
+}
+
+func Test_RunCronJob_ErrorWhenNotEnabled(t *testing.T) {
+	database := NewDatabase()
+
+	assert.EqualError(t, database.RunCronJob(context.Background(), "job"), "pg_cron is not enabled")
+	assert.EqualError(t, database.WaitForCronJob(context.Background(), "job", time.Now()), "pg_cron is not enabled")
+}
+
+func Test_RunCronJob_ErrorWhenNotStarted(t *testing.T) {
+	database := NewDatabase(DefaultConfig().PgCron(""))
+
+	assert.EqualError(t, database.RunCronJob(context.Background(), "job"), "server has not been started")
+	assert.EqualError(t, database.WaitForCronJob(context.Background(), "job", time.Now()), "server has not been started")
+}
@@ -0,0 +1,234 @@
+package embeddedpostgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// ErrPgTAPUnavailable is returned by InstallPgTAP when the pgtap extension is not shipped with the Postgres binaries.
+var ErrPgTAPUnavailable = errors.New("pgtap extension is not available in the Postgres binaries")
+
+var tapResultPattern = regexp.MustCompile(`^(not )?ok (\d+)(?: -)? ?(.*)$`)
+
+// TAPResult is a single test point reported by a TAP test script.
+type TAPResult struct {
+	Number      int
+	Passed      bool
+	Description string
+	// Directive is the SKIP or TODO directive of the test point, if any, including its explanation.
+	Directive string
+	// Diagnostics are the comment lines reported after the test point, such as the reason for a failure.
+	Diagnostics []string
+}
+
+// Skipped returns true when the test point was skipped.
+func (r TAPResult) Skipped() bool {
+	return strings.HasPrefix(strings.ToUpper(r.Directive), "SKIP")
+}
+
+// Todo returns true when the test point is expected to fail.
+func (r TAPResult) Todo() bool {
+	return strings.HasPrefix(strings.ToUpper(r.Directive), "TODO")
+}
+
+// TAPReport is the parsed output of a TAP test script.
+type TAPReport struct {
+	// Planned is the number of tests the script planned to run, or -1 if it declared no plan.
+	Planned int
+	Results []TAPResult
+}
+
+// InstallPgTAP creates the pgtap extension within the configured database, returning ErrPgTAPUnavailable when it is not
+// shipped with the Postgres binaries.
+func (ep *EmbeddedPostgres) InstallPgTAP() error {
+	if !ep.started {
+		return errors.New("server has not been started")
+	}
+
+	unavailable, err := ep.createAvailableExtensions(context.Background(), ep.config.database, []string{"pgtap"})
+	if err != nil {
+		return err
+	}
+
+	if len(unavailable) > 0 {
+		return ErrPgTAPUnavailable
+	}
+
+	return nil
+}
+
+// RunTAPFile runs the SQL script at path against the configured database and parses the TAP output produced by its
+// queries, as written by pgTAP functions such as plan, ok and finish.
+func (ep *EmbeddedPostgres) RunTAPFile(ctx context.Context, path string) (report TAPReport, err error) {
+	if !ep.started {
+		return TAPReport{}, errors.New("server has not been started")
+	}
+
+	script, err := os.ReadFile(path)
+	if err != nil {
+		return TAPReport{}, fmt.Errorf("unable to read TAP test %s: %w", path, err)
+	}
+
+	db, err := ep.openDB(ep.config.database)
+	if err != nil {
+		return TAPReport{}, err
+	}
+
+	defer func() {
+		err = connectionClose(db, err)
+	}()
+
+	output, err := queryTextOutput(ctx, db, string(script))
+	if err != nil {
+		return TAPReport{}, fmt.Errorf("unable to run TAP test %s: %w", path, err)
+	}
+
+	return parseTAP(output), nil
+}
+
+// TAPTests runs each of the TAP test scripts with RunTAPFile, reporting every script as a subtest and each of its test
+// points as a nested subtest.
+func (ep *EmbeddedPostgres) TAPTests(t *testing.T, paths ...string) {
+	t.Helper()
+
+	for _, path := range paths {
+		path := path
+
+		t.Run(strings.TrimSuffix(filepath.Base(path), ".sql"), func(t *testing.T) {
+			report, err := ep.RunTAPFile(context.Background(), path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, result := range report.Results {
+				result := result
+
+				name := result.Description
+				if name == "" {
+					name = strconv.Itoa(result.Number)
+				}
+
+				t.Run(name, func(t *testing.T) {
+					for _, diagnostic := range result.Diagnostics {
+						t.Log(diagnostic)
+					}
+
+					switch {
+					case result.Skipped():
+						t.Skip(result.Directive)
+					case !result.Passed && !result.Todo():
+						t.Errorf("test %d failed", result.Number)
+					}
+				})
+			}
+
+			if report.Planned >= 0 && report.Planned != len(report.Results) {
+				t.Errorf("planned %d tests but ran %d", report.Planned, len(report.Results))
+			}
+		})
+	}
+}
+
+// queryTextOutput runs a script that may contain several statements and returns every value of every row it returns,
+// split into lines.
+func queryTextOutput(ctx context.Context, db *sql.DB, script string) ([]string, error) {
+	rows, err := db.QueryContext(ctx, script)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var output []string
+
+	for {
+		columns, err := rows.Columns()
+		if err != nil {
+			return nil, err
+		}
+
+		for rows.Next() {
+			values := make([]sql.NullString, len(columns))
+			pointers := make([]interface{}, len(columns))
+
+			for i := range values {
+				pointers[i] = &values[i]
+			}
+
+			if err := rows.Scan(pointers...); err != nil {
+				return nil, err
+			}
+
+			for _, value := range values {
+				if value.Valid {
+					output = append(output, strings.Split(value.String, "\n")...)
+				}
+			}
+		}
+
+		if !rows.NextResultSet() {
+			break
+		}
+	}
+
+	return output, rows.Err()
+}
+
+func parseTAP(lines []string) TAPReport {
+	report := TAPReport{Planned: -1}
+
+	for _, line := range lines {
+		line = strings.TrimRight(line, "\r")
+
+		if strings.HasPrefix(line, "1..") {
+			plan := strings.Fields(strings.TrimPrefix(line, "1.."))
+			if len(plan) > 0 {
+				if planned, err := strconv.Atoi(plan[0]); err == nil {
+					report.Planned = planned
+				}
+			}
+
+			continue
+		}
+
+		if strings.HasPrefix(line, "#") {
+			if len(report.Results) > 0 {
+				last := &report.Results[len(report.Results)-1]
+				last.Diagnostics = append(last.Diagnostics, line)
+			}
+
+			continue
+		}
+
+		matches := tapResultPattern.FindStringSubmatch(line)
+		if matches == nil {
+			continue
+		}
+
+		number, _ := strconv.Atoi(matches[2])
+		description, directive, _ := strings.Cut(matches[3], " # ")
+
+		if strings.HasPrefix(description, "# ") {
+			description, directive = "", strings.TrimPrefix(description, "# ")
+		}
+
+		report.Results = append(report.Results, TAPResult{
+			Number:      number,
+			Passed:      matches[1] == "",
+			Description: strings.TrimSpace(description),
+			Directive:   strings.TrimSpace(directive),
+		})
+	}
+
+	return report
+}
@@ -0,0 +1,85 @@
+package embeddedpostgres
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseTAP(t *testing.T) {
+	report := parseTAP([]string{
+		"1..4",
+		"ok 1 - table exists",
+		"not ok 2 - column has a default",
+		"#   Failed test 2: \"column has a default\"",
+		"#         have: NULL",
+		"ok 3 # SKIP not supported",
+		"not ok 4 - eventually # TODO not implemented",
+		"unrelated output",
+	})
+
+	assert.Equal(t, TAPReport{
+		Planned: 4,
+		Results: []TAPResult{
+			{Number: 1, Passed: true, Description: "table exists"},
+			{Number: 2, Passed: false, Description: "column has a default", Diagnostics: []string{
+				"#   Failed test 2: \"column has a default\"",
+				"#         have: NULL",
+			}},
+			{Number: 3, Passed: true, Directive: "SKIP not supported"},
+			{Number: 4, Passed: false, Description: "eventually", Directive: "TODO not implemented"},
+		},
+	}, report)
+	assert.True(t, report.Results[2].Skipped())
+	assert.True(t, report.Results[3].Todo())
+}
+
+func Test_parseTAP_NoPlan(t *testing.T) {
+	assert.Equal(t, -1, parseTAP([]string{"ok 1"}).Planned)
+}
+
+func Test_RunTAPFile_ErrorWhenNotStarted(t *testing.T) {
+	database := NewDatabase()
+
+	_, err := database.RunTAPFile(context.Background(), "test.sql")
+
+	assert.EqualError(t, err, "server has not been started")
+	assert.EqualError(t, database.InstallPgTAP(), "server has not been started")
+}
+
+func Test_RunTAPFile(t *testing.T) {
+	database := NewDatabase(DefaultConfig().RuntimePath(t.TempDir()))
+	require.NoError(t, database.Start())
+
+	defer func() {
+		assert.NoError(t, database.Stop())
+	}()
+
+	if err := database.InstallPgTAP(); err != nil {
+		assert.Equal(t, ErrPgTAPUnavailable, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "test.sql")
+	require.NoError(t, os.WriteFile(path, []byte(`
+BEGIN;
+SELECT '1..2';
+SELECT 'ok 1 - first';
+SELECT 'not ok 2 - second' UNION ALL SELECT '# failed';
+ROLLBACK;
+`), 0600))
+
+	report, err := database.RunTAPFile(context.Background(), path)
+	require.NoError(t, err)
+
+	assert.Equal(t, TAPReport{
+		Planned: 2,
+		Results: []TAPResult{
+			{Number: 1, Passed: true, Description: "first"},
+			{Number: 2, Passed: false, Description: "second", Diagnostics: []string{"# failed"}},
+		},
+	}, report)
+}
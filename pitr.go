@@ -0,0 +1,336 @@
+package embeddedpostgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"time"
+)
+
+const (
+	walArchiveConfSnippet = "00-wal-archive"
+	recoveryConfSnippet   = "00-recovery"
+)
+
+func walArchiveDir(config Config) string {
+	return filepath.Join(config.walArchive, "wal")
+}
+
+func baseBackupDir(config Config) string {
+	return filepath.Join(config.walArchive, "base")
+}
+
+// walArchiveSettings archives completed WAL segments into the directory configured with Config.WALArchive.
+func walArchiveSettings(config Config) map[string]string {
+	if config.walArchive == "" {
+		return nil
+	}
+
+	walDir := walArchiveDir(config)
+
+	settings := map[string]string{
+		"archive_mode":    "on",
+		"archive_command": fmt.Sprintf(`test ! -f %s && cp "%%p" %s`, shellQuote(walDir+"/%f"), shellQuote(walDir+"/%f")),
+	}
+
+	if runtime.GOOS == "windows" {
+		settings["archive_command"] = fmt.Sprintf(`copy "%%p" "%s"`, filepath.Join(walDir, "%f"))
+	}
+
+	// archiving needs more WAL than the minimal level that was the default before Postgres 10
+	if majorVersion(config.version) < 10 {
+		settings["wal_level"] = "replica"
+	}
+
+	return settings
+}
+
+func restoreCommand(config Config) string {
+	walDir := walArchiveDir(config)
+
+	if runtime.GOOS == "windows" {
+		return fmt.Sprintf(`copy "%s" "%%p"`, filepath.Join(walDir, "%f"))
+	}
+
+	return fmt.Sprintf(`cp %s "%%p"`, shellQuote(walDir+"/%f"))
+}
+
+// writeWALArchiveConf creates the WAL archive and writes the settings archiving into it.
+func (ep *EmbeddedPostgres) writeWALArchiveConf() error {
+	if ep.config.walArchive != "" {
+		if err := os.MkdirAll(walArchiveDir(ep.config), 0700); err != nil {
+			return fmt.Errorf("unable to create WAL archive %s: %w", walArchiveDir(ep.config), err)
+		}
+	}
+
+	return ep.writeManagedConfSnippet(walArchiveConfSnippet, walArchiveSettings(ep.config))
+}
+
+func checkPITR(config Config) error {
+	if config.walArchive == "" {
+		return errors.New("point-in-time recovery requires a WAL archive, configure one with Config.WALArchive")
+	}
+
+	if majorVersion(config.version) < 10 {
+		return errors.New("point-in-time recovery requires Postgres 10 or later")
+	}
+
+	return nil
+}
+
+// BaseBackup takes a base backup of the running server into the WAL archive configured with Config.WALArchive,
+// replacing any previous one. The server can then be restored to any point after the backup was taken with
+// RestoreToTime or RestoreToLSN.
+func (ep *EmbeddedPostgres) BaseBackup(ctx context.Context) error {
+	if err := checkPITR(ep.config); err != nil {
+		return err
+	}
+
+	if !ep.isStarted() {
+		return errors.New("server has not been started")
+	}
+
+	backupDir := baseBackupDir(ep.config)
+	tempDir := backupDir + ".tmp"
+
+	if err := os.RemoveAll(tempDir); err != nil {
+		return fmt.Errorf("unable to clean up %s: %w", tempDir, err)
+	}
+
+	cmd := exec.CommandContext(ctx, filepath.Join(ep.config.binariesPath, "bin/pg_basebackup"),
+		"-D", tempDir,
+		"-h", connectionHost(ep.config),
+		"-p", strconv.Itoa(int(ep.config.port)),
+		"-U", ep.config.username,
+		"-X", "stream",
+		"--checkpoint=fast")
+	cmd.Env = append(os.Environ(), "PGPASSWORD="+ep.config.password, "PGSSLMODE="+internalSSLMode(ep.config))
+
+	err := ep.config.faults.checkCommand(cmd)
+
+	var output []byte
+	if err == nil {
+		output, err = cmd.CombinedOutput()
+	}
+
+	if err != nil {
+		return fmt.Errorf("unable to take base backup using %s: %w\n%s", cmd.String(), err, string(output))
+	}
+
+	if err := os.RemoveAll(backupDir); err != nil {
+		return fmt.Errorf("unable to remove previous base backup %s: %w", backupDir, err)
+	}
+
+	if err := os.Rename(tempDir, backupDir); err != nil {
+		return fmt.Errorf("unable to move base backup to %s: %w", backupDir, err)
+	}
+
+	return nil
+}
+
+// CurrentLSN returns the current write-ahead log location of the server, which can later be restored to with
+// RestoreToLSN.
+func (ep *EmbeddedPostgres) CurrentLSN(ctx context.Context) (lsn string, err error) {
+	if !ep.isStarted() {
+		return "", errors.New("server has not been started")
+	}
+
+	db, err := ep.openDB("postgres")
+	if err != nil {
+		return "", err
+	}
+
+	defer func() {
+		err = connectionClose(db, err)
+	}()
+
+	if err := db.QueryRowContext(ctx, "SELECT pg_current_wal_lsn()::text").Scan(&lsn); err != nil {
+		return "", fmt.Errorf("unable to read current LSN: %w", err)
+	}
+
+	return lsn, nil
+}
+
+// RestoreToTime stops the server, restores the data directory from the last BaseBackup and restarts the server,
+// replaying the archived WAL up to and including the transactions committed at t.
+func (ep *EmbeddedPostgres) RestoreToTime(t time.Time) error {
+	return ep.restoreTo("recovery_target_time", t.UTC().Format("2006-01-02 15:04:05.999999-07"))
+}
+
+// RestoreToLSN stops the server, restores the data directory from the last BaseBackup and restarts the server,
+// replaying the archived WAL up to the write-ahead log location lsn, as returned by CurrentLSN.
+func (ep *EmbeddedPostgres) RestoreToLSN(lsn string) error {
+	return ep.restoreTo("recovery_target_lsn", lsn)
+}
+
+func (ep *EmbeddedPostgres) restoreTo(target, value string) error {
+	if err := checkPITR(ep.config); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(baseBackupDir(ep.config)); err != nil {
+		return fmt.Errorf("no base backup found in %s, take one with BaseBackup: %w", baseBackupDir(ep.config), err)
+	}
+
+	// the data directory is only known once Start has run, and without it the base backup would be restored elsewhere
+	if ep.syncedLogger == nil || !dataDirIsValid(ep.config.dataPath, ep.config.version) {
+		return errors.New("data directory has not been initialised, call Start first")
+	}
+
+	if ep.isStarted() {
+		// the WAL being written is only archived once complete
+		if err := ep.execStatements(context.Background(), "postgres", "SELECT pg_switch_wal()"); err != nil {
+			return err
+		}
+
+		if err := ep.Stop(); err != nil {
+			return err
+		}
+	}
+
+	if err := os.RemoveAll(ep.config.dataPath); err != nil {
+		return fmt.Errorf("unable to clean up data directory %s with error: %s", ep.config.dataPath, err)
+	}
+
+	if err := copyDir(baseBackupDir(ep.config), ep.config.dataPath); err != nil {
+		return fmt.Errorf("unable to restore base backup to %s: %w", ep.config.dataPath, err)
+	}
+
+	if err := ep.writeRecoveryConf(map[string]string{
+		"restore_command":        restoreCommand(ep.config),
+		target:                   value,
+		"recovery_target_action": "promote",
+	}); err != nil {
+		return err
+	}
+
+	ep.recovering = true
+	defer func() {
+		ep.recovering = false
+	}()
+
+	// Start would clean up the runtime directory holding the restored data directory
+	return ep.Restart()
+}
+
+// writeRecoveryConf configures the server to recover on its next start, through recovery.conf before Postgres 12 and
+// a recovery.signal file alongside regular settings afterwards.
+func (ep *EmbeddedPostgres) writeRecoveryConf(settings map[string]string) error {
+	if majorVersion(ep.config.version) < 12 {
+		return writeConfFile(ep.config.faults, filepath.Join(ep.config.dataPath, "recovery.conf"), renderConfSettings(settings), dataFileMode(ep.config))
+	}
+
+	if err := writeConfFile(ep.config.faults, filepath.Join(ep.config.dataPath, "recovery.signal"), nil, dataFileMode(ep.config)); err != nil {
+		return err
+	}
+
+	return ep.writeManagedConfSnippet(recoveryConfSnippet, settings)
+}
+
+// finishRecovery waits for a server restored with RestoreToTime or RestoreToLSN to be promoted, then removes the
+// recovery settings so that they do not apply to later recoveries.
+func (ep *EmbeddedPostgres) finishRecovery(ctx context.Context) error {
+	for {
+		inRecovery, err := ep.inRecovery(ctx)
+		if err == nil && !inRecovery {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for recovery to complete: %w", err)
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+
+	return ep.writeManagedConfSnippet(recoveryConfSnippet, nil)
+}
+
+func (ep *EmbeddedPostgres) inRecovery(ctx context.Context) (inRecovery bool, err error) {
+	db, err := ep.openDB("postgres")
+	if err != nil {
+		return false, err
+	}
+
+	defer func() {
+		err = connectionClose(db, err)
+	}()
+
+	err = db.QueryRowContext(ctx, "SELECT pg_is_in_recovery()").Scan(&inRecovery)
+
+	return inRecovery, err
+}
+
+// copyDir copies the directory tree at src to dst, preserving permissions and symlinks.
+func copyDir(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relative, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(dst, relative)
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case entry.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm())
+		case entry.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+
+			return os.Symlink(link, target)
+		default:
+			return copyFile(path, target, info.Mode().Perm())
+		}
+	})
+}
+
+// copyFile copies src to dst, cloning it instead where the filesystem supports copy-on-write clones, which is nearly
+// instantaneous however large the file.
+func copyFile(src, dst string, mode os.FileMode) (err error) {
+	if cloneFile(src, dst, mode) == nil {
+		return nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = in.Close()
+	}()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if closeErr := out.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	_, err = io.Copy(out, in)
+
+	return err
+}
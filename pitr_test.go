@@ -0,0 +1,191 @@
+package embeddedpostgres
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_walArchiveSettings(t *testing.T) {
+	assert.Nil(t, walArchiveSettings(DefaultConfig()))
+
+	assert.Equal(t, map[string]string{
+		"archive_mode":    "on",
+		"archive_command": `test ! -f '/archive/wal/%f' && cp "%p" '/archive/wal/%f'`,
+	}, walArchiveSettings(DefaultConfig().WALArchive("/archive")))
+
+	assert.Equal(t, "replica", walArchiveSettings(DefaultConfig().Version(V9).WALArchive("/archive"))["wal_level"])
+}
+
+func Test_restoreCommand(t *testing.T) {
+	assert.Equal(t, `cp '/archive/wal/%f' "%p"`, restoreCommand(DefaultConfig().WALArchive("/archive")))
+}
+
+func Test_checkPITR(t *testing.T) {
+	assert.EqualError(t, checkPITR(DefaultConfig()), "point-in-time recovery requires a WAL archive, configure one with Config.WALArchive")
+	assert.EqualError(t, checkPITR(DefaultConfig().Version(V9).WALArchive("/archive")), "point-in-time recovery requires Postgres 10 or later")
+	assert.NoError(t, checkPITR(DefaultConfig().WALArchive("/archive")))
+}
+
+func Test_RestoreToTime_ErrorWhenNoBaseBackup(t *testing.T) {
+	archive := t.TempDir()
+
+	err := NewDatabase(DefaultConfig().WALArchive(archive)).RestoreToTime(time.Now())
+
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "no base backup found in "+filepath.Join(archive, "base")+", take one with BaseBackup")
+}
+
+func Test_RestoreToLSN_ErrorWhenNeverStarted(t *testing.T) {
+	archive := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(archive, "base"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(archive, "base", "PG_VERSION"), []byte("15"), 0600))
+
+	workingDir := t.TempDir()
+
+	previousDir, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(workingDir))
+
+	t.Cleanup(func() {
+		_ = os.Chdir(previousDir)
+	})
+
+	err = NewDatabase(DefaultConfig().WALArchive(archive)).RestoreToLSN("0/3000000")
+
+	assert.EqualError(t, err, "data directory has not been initialised, call Start first")
+
+	// nothing is restored into the working directory in place of the unknown data directory
+	entries, err := os.ReadDir(workingDir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func Test_BaseBackup_ErrorWhenNotStarted(t *testing.T) {
+	err := NewDatabase(DefaultConfig().WALArchive(t.TempDir())).BaseBackup(context.Background())
+
+	assert.EqualError(t, err, "server has not been started")
+}
+
+func Test_writeRecoveryConf(t *testing.T) {
+	settings := map[string]string{"recovery_target_lsn": "0/3000060"}
+
+	dataPath := t.TempDir()
+	require.NoError(t, NewDatabase(DefaultConfig().Version(V11).DataPath(dataPath)).writeRecoveryConf(settings))
+
+	content, err := os.ReadFile(filepath.Join(dataPath, "recovery.conf"))
+	require.NoError(t, err)
+	assert.Equal(t, "recovery_target_lsn = '0/3000060'\n", string(content))
+
+	dataPath = t.TempDir()
+	database := NewDatabase(DefaultConfig().DataPath(dataPath))
+	require.NoError(t, database.writeRecoveryConf(settings))
+
+	assert.FileExists(t, filepath.Join(dataPath, "recovery.signal"))
+	content, err = os.ReadFile(filepath.Join(database.ConfDir(), recoveryConfSnippet+".conf"))
+	require.NoError(t, err)
+	assert.Equal(t, "recovery_target_lsn = '0/3000060'\n", string(content))
+}
+
+func Test_copyDir(t *testing.T) {
+	src := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(src, "base", "1"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "base", "1", "1259"), []byte("pages"), 0600))
+	require.NoError(t, os.Symlink("/mnt/tablespace", filepath.Join(src, "16384")))
+
+	dst := filepath.Join(t.TempDir(), "copy")
+	require.NoError(t, copyDir(src, dst))
+
+	content, err := os.ReadFile(filepath.Join(dst, "base", "1", "1259"))
+	require.NoError(t, err)
+	assert.Equal(t, "pages", string(content))
+
+	info, err := os.Stat(filepath.Join(dst, "base", "1"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0700), info.Mode().Perm())
+
+	link, err := os.Readlink(filepath.Join(dst, "16384"))
+	require.NoError(t, err)
+	assert.Equal(t, "/mnt/tablespace", link)
+}
+
+func Test_RestoreToTime(t *testing.T) {
+	database := NewDatabase(DefaultConfig().
+		RuntimePath(t.TempDir()).
+		WALArchive(t.TempDir()))
+	require.NoError(t, database.Start())
+
+	defer func() {
+		assert.NoError(t, database.Stop())
+	}()
+
+	ctx := context.Background()
+
+	require.NoError(t, database.execStatements(ctx, "postgres", "CREATE TABLE brews (name text)"))
+	require.NoError(t, database.BaseBackup(ctx))
+	require.NoError(t, database.execStatements(ctx, "postgres", "INSERT INTO brews VALUES ('stout')"))
+
+	time.Sleep(100 * time.Millisecond)
+	target := time.Now()
+	time.Sleep(100 * time.Millisecond)
+
+	require.NoError(t, database.execStatements(ctx, "postgres", "INSERT INTO brews VALUES ('lager')"))
+	require.NoError(t, database.RestoreToTime(target))
+
+	assert.Equal(t, []string{"stout"}, brews(t, database))
+}
+
+func Test_RestoreToLSN(t *testing.T) {
+	database := NewDatabase(DefaultConfig().
+		RuntimePath(t.TempDir()).
+		WALArchive(t.TempDir()))
+	require.NoError(t, database.Start())
+
+	defer func() {
+		assert.NoError(t, database.Stop())
+	}()
+
+	ctx := context.Background()
+
+	require.NoError(t, database.execStatements(ctx, "postgres", "CREATE TABLE brews (name text)"))
+	require.NoError(t, database.BaseBackup(ctx))
+	require.NoError(t, database.execStatements(ctx, "postgres", "INSERT INTO brews VALUES ('stout')"))
+
+	lsn, err := database.CurrentLSN(ctx)
+	require.NoError(t, err)
+
+	require.NoError(t, database.execStatements(ctx, "postgres", "INSERT INTO brews VALUES ('lager')"))
+	require.NoError(t, database.RestoreToLSN(lsn))
+
+	assert.Equal(t, []string{"stout"}, brews(t, database))
+	assert.NoFileExists(t, filepath.Join(database.ConfDir(), recoveryConfSnippet+".conf"))
+}
+
+func brews(t *testing.T, database *EmbeddedPostgres) []string {
+	db, err := database.openDB("postgres")
+	require.NoError(t, err)
+
+	defer func() {
+		assert.NoError(t, db.Close())
+	}()
+
+	rows, err := db.Query("SELECT name FROM brews ORDER BY name")
+	require.NoError(t, err)
+
+	var names []string
+
+	for rows.Next() {
+		var name string
+		require.NoError(t, rows.Scan(&name))
+		names = append(names, name)
+	}
+
+	require.NoError(t, rows.Close())
+
+	return names
+}
@@ -0,0 +1,120 @@
+package embeddedpostgres
+
+import (
+	"fmt"
+	"strconv"
+)
+
+const plannerConfSnippet = "00-planner"
+
+// PlannerMethod is an enable_* planner setting toggled with Config.PlannerMethod.
+type PlannerMethod string
+
+// Planner methods, each available from the Postgres version noted in plannerMethodVersions.
+const (
+	PlannerBitmapScan             = PlannerMethod("enable_bitmapscan")
+	PlannerGatherMerge            = PlannerMethod("enable_gathermerge")
+	PlannerHashAgg                = PlannerMethod("enable_hashagg")
+	PlannerHashJoin               = PlannerMethod("enable_hashjoin")
+	PlannerIncrementalSort        = PlannerMethod("enable_incremental_sort")
+	PlannerIndexOnlyScan          = PlannerMethod("enable_indexonlyscan")
+	PlannerIndexScan              = PlannerMethod("enable_indexscan")
+	PlannerMaterial               = PlannerMethod("enable_material")
+	PlannerMemoize                = PlannerMethod("enable_memoize")
+	PlannerMergeJoin              = PlannerMethod("enable_mergejoin")
+	PlannerNestLoop               = PlannerMethod("enable_nestloop")
+	PlannerParallelAppend         = PlannerMethod("enable_parallel_append")
+	PlannerParallelHash           = PlannerMethod("enable_parallel_hash")
+	PlannerPartitionPruning       = PlannerMethod("enable_partition_pruning")
+	PlannerPartitionwiseAggregate = PlannerMethod("enable_partitionwise_aggregate")
+	PlannerPartitionwiseJoin      = PlannerMethod("enable_partitionwise_join")
+	PlannerSeqScan                = PlannerMethod("enable_seqscan")
+	PlannerSort                   = PlannerMethod("enable_sort")
+	PlannerTIDScan                = PlannerMethod("enable_tidscan")
+)
+
+// plannerMethodVersions holds the major version each planner method was introduced in.
+var plannerMethodVersions = map[PlannerMethod]int{
+	PlannerBitmapScan:             9,
+	PlannerGatherMerge:            10,
+	PlannerHashAgg:                9,
+	PlannerHashJoin:               9,
+	PlannerIncrementalSort:        13,
+	PlannerIndexOnlyScan:          9,
+	PlannerIndexScan:              9,
+	PlannerMaterial:               9,
+	PlannerMemoize:                14,
+	PlannerMergeJoin:              9,
+	PlannerNestLoop:               9,
+	PlannerParallelAppend:         11,
+	PlannerParallelHash:           11,
+	PlannerPartitionPruning:       11,
+	PlannerPartitionwiseAggregate: 11,
+	PlannerPartitionwiseJoin:      11,
+	PlannerSeqScan:                9,
+	PlannerSort:                   9,
+	PlannerTIDScan:                9,
+}
+
+// plannerSettings returns the settings pinning the configured JIT, parallel worker and planner method behaviour, or an
+// error when one of them cannot be set on the configured version.
+func plannerSettings(config Config) (map[string]string, error) {
+	settings := map[string]string{}
+	major := majorVersion(config.version)
+
+	if config.jit != nil {
+		// JIT was introduced in Postgres 11, so before then it is always disabled
+		switch {
+		case major >= 11:
+			settings["jit"] = onOff(*config.jit)
+		case *config.jit:
+			return nil, fmt.Errorf("JIT requires Postgres 11 or later, configured version is %s", config.version)
+		}
+	}
+
+	if config.parallelWorkers != nil {
+		if *config.parallelWorkers < 0 {
+			return nil, fmt.Errorf("parallel workers per gather must not be negative, got %d", *config.parallelWorkers)
+		}
+
+		settings["max_parallel_workers_per_gather"] = strconv.Itoa(*config.parallelWorkers)
+	}
+
+	for method, enabled := range config.plannerMethods {
+		introduced, ok := plannerMethodVersions[method]
+		if !ok {
+			return nil, fmt.Errorf("unknown planner method %q", method)
+		}
+
+		if major < introduced {
+			// the plan shape the method would produce does not exist before it was introduced
+			if enabled {
+				return nil, fmt.Errorf("%s requires Postgres %d or later, configured version is %s", method, introduced, config.version)
+			}
+
+			continue
+		}
+
+		settings[string(method)] = onOff(enabled)
+	}
+
+	return settings, nil
+}
+
+// writePlannerConf writes the JIT, parallel worker and planner method settings into the drop-in configuration directory.
+func (ep *EmbeddedPostgres) writePlannerConf() error {
+	settings, err := plannerSettings(ep.config)
+	if err != nil {
+		return err
+	}
+
+	return ep.writeManagedConfSnippet(plannerConfSnippet, settings)
+}
+
+func onOff(enabled bool) string {
+	if enabled {
+		return "on"
+	}
+
+	return "off"
+}
@@ -0,0 +1,107 @@
+package embeddedpostgres
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_plannerSettings(t *testing.T) {
+	settings, err := plannerSettings(DefaultConfig())
+	require.NoError(t, err)
+	assert.Empty(t, settings)
+
+	settings, err = plannerSettings(DefaultConfig().
+		JIT(false).
+		ParallelWorkersPerGather(0).
+		PlannerMethod(PlannerSeqScan, false).
+		PlannerMethod(PlannerMemoize, true))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"jit":                             "off",
+		"max_parallel_workers_per_gather": "0",
+		"enable_seqscan":                  "off",
+		"enable_memoize":                  "on",
+	}, settings)
+}
+
+func Test_plannerSettings_OlderVersions(t *testing.T) {
+	settings, err := plannerSettings(DefaultConfig().
+		Version(V10).
+		JIT(false).
+		PlannerMethod(PlannerParallelHash, false).
+		PlannerMethod(PlannerGatherMerge, false))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"enable_gathermerge": "off"}, settings)
+
+	_, err = plannerSettings(DefaultConfig().Version(V10).JIT(true))
+	assert.EqualError(t, err, "JIT requires Postgres 11 or later, configured version is 10.23.0")
+
+	_, err = plannerSettings(DefaultConfig().Version(V12).PlannerMethod(PlannerMemoize, true))
+	assert.EqualError(t, err, "enable_memoize requires Postgres 14 or later, configured version is 12.15.0")
+}
+
+func Test_plannerSettings_Errors(t *testing.T) {
+	_, err := plannerSettings(DefaultConfig().ParallelWorkersPerGather(-1))
+	assert.EqualError(t, err, "parallel workers per gather must not be negative, got -1")
+
+	_, err = plannerSettings(DefaultConfig().PlannerMethod("enable_magic", false))
+	assert.EqualError(t, err, `unknown planner method "enable_magic"`)
+}
+
+func Test_PlannerMethod_DoesNotShareConfig(t *testing.T) {
+	base := DefaultConfig().PlannerMethod(PlannerSeqScan, false)
+	derived := base.PlannerMethod(PlannerSeqScan, true)
+
+	assert.False(t, base.plannerMethods[PlannerSeqScan])
+	assert.True(t, derived.plannerMethods[PlannerSeqScan])
+}
+
+func Test_writeConfDir_Planner(t *testing.T) {
+	tempDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "postgresql.conf"), []byte(""), 0600))
+
+	database := NewDatabase(DefaultConfig().DataPath(tempDir).JIT(false))
+	require.NoError(t, database.writeConfDir())
+
+	snippet, err := os.ReadFile(filepath.Join(database.ConfDir(), plannerConfSnippet+".conf"))
+	require.NoError(t, err)
+	assert.Equal(t, "jit = 'off'\n", string(snippet))
+
+	database.config = DefaultConfig().DataPath(tempDir)
+	require.NoError(t, database.writeConfDir())
+	assert.NoFileExists(t, filepath.Join(database.ConfDir(), plannerConfSnippet+".conf"))
+}
+
+func Test_PlannerSettings(t *testing.T) {
+	database := NewDatabase(DefaultConfig().
+		RuntimePath(t.TempDir()).
+		JIT(false).
+		ParallelWorkersPerGather(0).
+		PlannerMethod(PlannerSeqScan, false))
+	require.NoError(t, database.Start())
+
+	defer func() {
+		assert.NoError(t, database.Stop())
+	}()
+
+	db, err := database.openDB("postgres")
+	require.NoError(t, err)
+
+	defer func() {
+		assert.NoError(t, db.Close())
+	}()
+
+	for name, expected := range map[string]string{
+		"jit":                             "off",
+		"max_parallel_workers_per_gather": "0",
+		"enable_seqscan":                  "off",
+	} {
+		var value string
+		require.NoError(t, db.QueryRow("SELECT current_setting($1)", name).Scan(&value))
+		assert.Equal(t, expected, value, name)
+	}
+}
@@ -0,0 +1,17 @@
+package embeddedpostgres
+
+import "hash/fnv"
+
+// DerivePort returns a port between min and max inclusive derived from seed, such as the import path of the package
+// under test, so that each package is given the same port on every run whilst parallel jobs testing different packages
+// are unlikely to collide. min and max are swapped if given the wrong way round.
+func DerivePort(seed string, min, max uint32) uint32 {
+	if min > max {
+		min, max = max, min
+	}
+
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(seed))
+
+	return min + uint32(uint64(hash.Sum32())%(uint64(max)-uint64(min)+1))
+}
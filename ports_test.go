@@ -0,0 +1,24 @@
+package embeddedpostgres
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_DerivePort(t *testing.T) {
+	port := DerivePort("github.com/fergusstrange/embedded-postgres", 20000, 29999)
+
+	assert.Equal(t, port, DerivePort("github.com/fergusstrange/embedded-postgres", 20000, 29999))
+	assert.Equal(t, port, DerivePort("github.com/fergusstrange/embedded-postgres", 29999, 20000))
+	assert.GreaterOrEqual(t, port, uint32(20000))
+	assert.LessOrEqual(t, port, uint32(29999))
+	assert.NotEqual(t, port, DerivePort("github.com/fergusstrange/embedded-postgres/examples", 20000, 29999))
+}
+
+func Test_DerivePort_FullAndSingleRange(t *testing.T) {
+	assert.Equal(t, uint32(5432), DerivePort("seed", 5432, 5432))
+	assert.NotPanics(t, func() {
+		DerivePort("seed", 0, ^uint32(0))
+	})
+}
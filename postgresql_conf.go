@@ -0,0 +1,257 @@
+package embeddedpostgres
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+const (
+	overlayConfFile = "postgresql.overlay.conf"
+	confDirName     = "conf.d"
+)
+
+type postgresConfTemplateData struct {
+	Port         uint32
+	DataPath     string
+	RuntimePath  string
+	BinariesPath string
+	Username     string
+	Database     string
+}
+
+// writePostgresConf renders the user supplied postgresql.conf, if any, into the data directory.
+func (ep *EmbeddedPostgres) writePostgresConf() error {
+	if ep.config.postgresConfPath == "" {
+		return nil
+	}
+
+	content, err := renderPostgresConf(ep.config)
+	if err != nil {
+		return err
+	}
+
+	if ep.config.postgresConfMode == ReplacePostgresConf {
+		return writeConfFile(ep.config.faults, filepath.Join(ep.config.dataPath, "postgresql.conf"), content, dataFileMode(ep.config))
+	}
+
+	if err := writeConfFile(ep.config.faults, filepath.Join(ep.config.dataPath, overlayConfFile), content, dataFileMode(ep.config)); err != nil {
+		return err
+	}
+
+	return ensureConfDirective(ep.config.faults, ep.config.dataPath, fmt.Sprintf("include_if_exists = '%s'", overlayConfFile), dataFileMode(ep.config))
+}
+
+func renderPostgresConf(config Config) ([]byte, error) {
+	templateContent, err := os.ReadFile(config.postgresConfPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read postgresql.conf %s: %w", config.postgresConfPath, err)
+	}
+
+	tmpl, err := template.New(filepath.Base(config.postgresConfPath)).
+		Option("missingkey=error").
+		Parse(string(templateContent))
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse postgresql.conf %s: %w", config.postgresConfPath, err)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := tmpl.Execute(buf, postgresConfTemplateData{
+		Port:         config.port,
+		DataPath:     config.dataPath,
+		RuntimePath:  config.runtimePath,
+		BinariesPath: config.binariesPath,
+		Username:     config.username,
+		Database:     config.database,
+	}); err != nil {
+		return nil, fmt.Errorf("unable to render postgresql.conf %s: %w", config.postgresConfPath, err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// ConfDir returns the drop-in configuration directory that is included at the end of postgresql.conf.
+func (ep *EmbeddedPostgres) ConfDir() string {
+	return filepath.Join(ep.config.dataPath, confDirName)
+}
+
+// WriteConfSnippet writes the settings to a drop-in file named name.conf in ConfDir.
+// The settings take effect the next time the server is started or its configuration is reloaded.
+func (ep *EmbeddedPostgres) WriteConfSnippet(name string, settings map[string]string) error {
+	if ep.config.dataPath == "" {
+		return errors.New("data directory has not been initialised")
+	}
+
+	if name == "" || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("invalid configuration snippet name %q", name)
+	}
+
+	if err := ep.config.faults.checkFile(ep.ConfDir()); err != nil {
+		return fmt.Errorf("unable to create configuration directory %s: %w", ep.ConfDir(), err)
+	}
+
+	if err := os.MkdirAll(ep.ConfDir(), dataDirMode(ep.config)); err != nil {
+		return fmt.Errorf("unable to create configuration directory %s: %w", ep.ConfDir(), err)
+	}
+
+	return writeConfFile(ep.config.faults, filepath.Join(ep.ConfDir(), name+".conf"), renderConfSettings(settings), dataFileMode(ep.config))
+}
+
+// writeManagedConfSnippet writes a drop-in file for settings managed by the library, removing the file left by a
+// previous start when there are no settings so that reused data directories do not keep stale configuration.
+func (ep *EmbeddedPostgres) writeManagedConfSnippet(name string, settings map[string]string) error {
+	if len(settings) == 0 {
+		path := filepath.Join(ep.ConfDir(), name+".conf")
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("unable to remove %s: %w", path, err)
+		}
+
+		return nil
+	}
+
+	return ep.WriteConfSnippet(name, settings)
+}
+
+// writeConfDir creates the drop-in configuration directory, includes it from postgresql.conf and writes the preset and
+// any configured snippets into it.
+func (ep *EmbeddedPostgres) writeConfDir() error {
+	if err := ep.config.faults.checkFile(ep.ConfDir()); err != nil {
+		return fmt.Errorf("unable to create configuration directory %s: %w", ep.ConfDir(), err)
+	}
+
+	if err := os.MkdirAll(ep.ConfDir(), dataDirMode(ep.config)); err != nil {
+		return fmt.Errorf("unable to create configuration directory %s: %w", ep.ConfDir(), err)
+	}
+
+	if err := ensureConfDirective(ep.config.faults, ep.config.dataPath, fmt.Sprintf("include_dir = '%s'", confDirName), dataFileMode(ep.config)); err != nil {
+		return err
+	}
+
+	if err := ep.writePresetConf(); err != nil {
+		return err
+	}
+
+	if err := ep.writeAutoTuneConf(); err != nil {
+		return err
+	}
+
+	if err := ep.writeManagedConfSnippet(captureStatementsConfSnippet, captureStatementsSettings(ep.config)); err != nil {
+		return err
+	}
+
+	if err := ep.writePreloadConf(); err != nil {
+		return err
+	}
+
+	if err := ep.writeManagedConfSnippet(applicationNameConfSnippet, applicationNameSettings(ep.config)); err != nil {
+		return err
+	}
+
+	if err := ep.writePlannerConf(); err != nil {
+		return err
+	}
+
+	if err := ep.writeTempConf(); err != nil {
+		return err
+	}
+
+	if err := ep.writeWALArchiveConf(); err != nil {
+		return err
+	}
+
+	if err := ep.writeManagedConfSnippet(csvLogConfSnippet, csvLogSettings(ep.config)); err != nil {
+		return err
+	}
+
+	if err := ep.writeSocketConf(); err != nil {
+		return err
+	}
+
+	if err := ep.writeTLSConf(); err != nil {
+		return err
+	}
+
+	for name, settings := range ep.config.confSnippets {
+		if err := ep.WriteConfSnippet(name, settings); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// renderConfSettings renders settings in postgresql.conf format, sorted by name so the output is stable.
+func renderConfSettings(settings map[string]string) []byte {
+	names := make([]string, 0, len(settings))
+	for name := range settings {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	buf := &bytes.Buffer{}
+	for _, name := range names {
+		fmt.Fprintf(buf, "%s = '%s'\n", name, strings.ReplaceAll(settings[name], "'", "''"))
+	}
+
+	return buf.Bytes()
+}
+
+// ensureConfDirective appends the directive to postgresql.conf unless it is already present.
+func ensureConfDirective(faults faultHooks, dataPath, directive string, mode os.FileMode) error {
+	confPath := filepath.Join(dataPath, "postgresql.conf")
+
+	content, err := os.ReadFile(confPath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("unable to read %s: %w", confPath, err)
+	}
+
+	for _, line := range strings.Split(string(content), "\n") {
+		if strings.TrimSpace(line) == directive {
+			return nil
+		}
+	}
+
+	if len(content) > 0 && !bytes.HasSuffix(content, []byte("\n")) {
+		content = append(content, '\n')
+	}
+
+	content = append(content, []byte(directive+"\n")...)
+
+	return writeConfFile(faults, confPath, content, mode)
+}
+
+func writeConfFile(faults faultHooks, path string, content []byte, mode os.FileMode) error {
+	if err := faults.checkFile(path); err != nil {
+		return fmt.Errorf("unable to write %s: %w", path, err)
+	}
+
+	if err := os.WriteFile(path, content, mode); err != nil {
+		return fmt.Errorf("unable to write %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// dataFileMode returns the mode for files created in the data directory, matching what initdb uses.
+func dataFileMode(config Config) os.FileMode {
+	if config.allowGroupAccess {
+		return 0640
+	}
+
+	return 0600
+}
+
+// dataDirMode returns the mode for directories created in the data directory, matching what initdb uses.
+func dataDirMode(config Config) os.FileMode {
+	if config.allowGroupAccess {
+		return 0750
+	}
+
+	return 0700
+}
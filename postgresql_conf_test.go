@@ -0,0 +1,201 @@
+package embeddedpostgres
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestConf(t *testing.T, dir, content string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, "template.conf")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+
+	return path
+}
+
+func Test_writePostgresConf_Replace(t *testing.T) {
+	tempDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "postgresql.conf"), []byte("max_connections = 100\n"), 0600))
+
+	confPath := writeTestConf(t, t.TempDir(), "port = {{.Port}}\nunix_socket_directories = '{{.DataPath}}'\n")
+
+	database := NewDatabase(DefaultConfig().
+		Port(9876).
+		DataPath(tempDir).
+		PostgresConf(confPath, ReplacePostgresConf))
+
+	require.NoError(t, database.writePostgresConf())
+
+	content, err := os.ReadFile(filepath.Join(tempDir, "postgresql.conf"))
+	require.NoError(t, err)
+	assert.Equal(t, "port = 9876\nunix_socket_directories = '"+tempDir+"'\n", string(content))
+}
+
+func Test_writePostgresConf_Overlay(t *testing.T) {
+	tempDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "postgresql.conf"), []byte("max_connections = 100"), 0600))
+
+	confPath := writeTestConf(t, t.TempDir(), "max_connections = 42\n")
+
+	database := NewDatabase(DefaultConfig().
+		DataPath(tempDir).
+		PostgresConf(confPath, OverlayPostgresConf))
+
+	require.NoError(t, database.writePostgresConf())
+	require.NoError(t, database.writePostgresConf())
+
+	content, err := os.ReadFile(filepath.Join(tempDir, "postgresql.conf"))
+	require.NoError(t, err)
+	assert.Equal(t, "max_connections = 100\ninclude_if_exists = 'postgresql.overlay.conf'\n", string(content))
+	assert.Equal(t, 1, strings.Count(string(content), "include_if_exists"))
+
+	overlay, err := os.ReadFile(filepath.Join(tempDir, overlayConfFile))
+	require.NoError(t, err)
+	assert.Equal(t, "max_connections = 42\n", string(overlay))
+}
+
+func Test_writePostgresConf_ErrorWhenTemplateInvalid(t *testing.T) {
+	confPath := writeTestConf(t, t.TempDir(), "port = {{.Port")
+
+	database := NewDatabase(DefaultConfig().
+		DataPath(t.TempDir()).
+		PostgresConf(confPath, ReplacePostgresConf))
+
+	err := database.writePostgresConf()
+
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "unable to parse postgresql.conf "+confPath)
+}
+
+func Test_writePostgresConf_ErrorWhenUnknownPlaceholder(t *testing.T) {
+	confPath := writeTestConf(t, t.TempDir(), "port = {{.NotAField}}")
+
+	database := NewDatabase(DefaultConfig().
+		DataPath(t.TempDir()).
+		PostgresConf(confPath, ReplacePostgresConf))
+
+	err := database.writePostgresConf()
+
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "unable to render postgresql.conf "+confPath)
+}
+
+func Test_writePostgresConf_ErrorWhenMissing(t *testing.T) {
+	database := NewDatabase(DefaultConfig().
+		DataPath(t.TempDir()).
+		PostgresConf("/not-exists-anywhere.conf", OverlayPostgresConf))
+
+	err := database.writePostgresConf()
+
+	assert.EqualError(t, err, "unable to read postgresql.conf /not-exists-anywhere.conf: open /not-exists-anywhere.conf: no such file or directory")
+}
+
+func Test_PostgresConfOverlay(t *testing.T) {
+	confPath := writeTestConf(t, t.TempDir(), "max_connections = 42\n")
+
+	database := NewDatabase(DefaultConfig().
+		PostgresConf(confPath, OverlayPostgresConf))
+	if err := database.Start(); err != nil {
+		shutdownDBAndFail(t, err, database)
+	}
+
+	db, err := sql.Open("postgres", "host=localhost port=5432 user=postgres password=postgres dbname=postgres sslmode=disable")
+	if err != nil {
+		shutdownDBAndFail(t, err, database)
+	}
+
+	var maxConnections string
+	if err := db.QueryRow("SHOW max_connections").Scan(&maxConnections); err != nil {
+		shutdownDBAndFail(t, err, database)
+	}
+
+	assert.Equal(t, "42", maxConnections)
+
+	if err := db.Close(); err != nil {
+		shutdownDBAndFail(t, err, database)
+	}
+
+	if err := database.Stop(); err != nil {
+		shutdownDBAndFail(t, err, database)
+	}
+}
+
+func Test_renderConfSettings(t *testing.T) {
+	content := renderConfSettings(map[string]string{
+		"shared_buffers":  "16MB",
+		"log_line_prefix": "%m [%p] it's ",
+	})
+
+	assert.Equal(t, "log_line_prefix = '%m [%p] it''s '\nshared_buffers = '16MB'\n", string(content))
+}
+
+func Test_writeConfDir(t *testing.T) {
+	tempDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "postgresql.conf"), []byte("max_connections = 100\n"), 0600))
+
+	database := NewDatabase(DefaultConfig().
+		DataPath(tempDir).
+		ConfSnippet("10-connections", map[string]string{"max_connections": "33"}))
+
+	require.NoError(t, database.writeConfDir())
+	require.NoError(t, database.writeConfDir())
+
+	content, err := os.ReadFile(filepath.Join(tempDir, "postgresql.conf"))
+	require.NoError(t, err)
+	assert.Equal(t, "max_connections = 100\ninclude_dir = 'conf.d'\n", string(content))
+
+	snippet, err := os.ReadFile(filepath.Join(database.ConfDir(), "10-connections.conf"))
+	require.NoError(t, err)
+	assert.Equal(t, "max_connections = '33'\n", string(snippet))
+}
+
+func Test_WriteConfSnippet_ErrorWhenNotInitialised(t *testing.T) {
+	database := NewDatabase()
+
+	err := database.WriteConfSnippet("logging", map[string]string{"log_statement": "all"})
+
+	assert.EqualError(t, err, "data directory has not been initialised")
+}
+
+func Test_WriteConfSnippet_ErrorWhenInvalidName(t *testing.T) {
+	database := NewDatabase(DefaultConfig().DataPath(t.TempDir()))
+
+	err := database.WriteConfSnippet("../postgresql", map[string]string{"log_statement": "all"})
+
+	assert.EqualError(t, err, `invalid configuration snippet name "../postgresql"`)
+}
+
+func Test_ConfSnippet(t *testing.T) {
+	database := NewDatabase(DefaultConfig().
+		ConfSnippet("connections", map[string]string{"max_connections": "33"}))
+	if err := database.Start(); err != nil {
+		shutdownDBAndFail(t, err, database)
+	}
+
+	db, err := sql.Open("postgres", "host=localhost port=5432 user=postgres password=postgres dbname=postgres sslmode=disable")
+	if err != nil {
+		shutdownDBAndFail(t, err, database)
+	}
+
+	var maxConnections string
+	if err := db.QueryRow("SHOW max_connections").Scan(&maxConnections); err != nil {
+		shutdownDBAndFail(t, err, database)
+	}
+
+	assert.Equal(t, "33", maxConnections)
+
+	if err := db.Close(); err != nil {
+		shutdownDBAndFail(t, err, database)
+	}
+
+	if err := database.Stop(); err != nil {
+		shutdownDBAndFail(t, err, database)
+	}
+}
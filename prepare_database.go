@@ -9,6 +9,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 
 	"github.com/lib/pq"
 )
@@ -18,11 +19,30 @@ const (
 	fmtAfterError  = "%v happened after error: %w"
 )
 
-type initDatabase func(binaryExtractLocation, runtimePath, pgDataDir, username, password, locale string, logger *os.File) error
-type createDatabase func(ctx context.Context, port uint32, username, password, database string) error
+// InitDatabaseStrategy creates the data directory at pgDataDir using the binaries extracted to binaryExtractLocation,
+// for example by running initdb or restoring a backup. extraArgs are the initdb arguments derived from the Config, and
+// output should be written to logger, which is included in the error when starting fails.
+type InitDatabaseStrategy func(binaryExtractLocation, runtimePath, pgDataDir, username, password, locale string, extraArgs []string, logger *os.File) error
 
-func defaultInitDatabase(binaryExtractLocation, runtimePath, pgDataDir, username, password, locale string, logger *os.File) error {
-	passwordFile, err := createPasswordFile(runtimePath, password)
+// CreateDatabaseStrategy creates the configured database once the server has started. options are the clauses of
+// CREATE DATABASE derived from the Config, such as the database locale.
+type CreateDatabaseStrategy func(ctx context.Context, port uint32, username, password, database string, options []string) error
+
+// DefaultInitDatabase runs initdb with password authentication for username.
+func DefaultInitDatabase(binaryExtractLocation, runtimePath, pgDataDir, username, password, locale string, extraArgs []string, logger *os.File) error {
+	return initDatabaseWithFaults(faultHooks{})(binaryExtractLocation, runtimePath, pgDataDir, username, password, locale, extraArgs, logger)
+}
+
+// initDatabaseWithFaults returns DefaultInitDatabase, consulting the fault hooks before writing the
+// password file and running initdb.
+func initDatabaseWithFaults(faults faultHooks) InitDatabaseStrategy {
+	return func(binaryExtractLocation, runtimePath, pgDataDir, username, password, locale string, extraArgs []string, logger *os.File) error {
+		return runInitDB(faults, binaryExtractLocation, runtimePath, pgDataDir, username, password, locale, extraArgs, logger)
+	}
+}
+
+func runInitDB(faults faultHooks, binaryExtractLocation, runtimePath, pgDataDir, username, password, locale string, extraArgs []string, logger *os.File) error {
+	passwordFile, err := createPasswordFile(faults, runtimePath, password)
 	if err != nil {
 		return err
 	}
@@ -38,12 +58,18 @@ func defaultInitDatabase(binaryExtractLocation, runtimePath, pgDataDir, username
 		args = append(args, fmt.Sprintf("--locale=%s", locale))
 	}
 
+	args = append(args, extraArgs...)
+
 	postgresInitDBBinary := filepath.Join(binaryExtractLocation, "bin/initdb")
 	postgresInitDBProcess := exec.Command(postgresInitDBBinary, args...)
 	postgresInitDBProcess.Stderr = logger
 	postgresInitDBProcess.Stdout = logger
 
-	if err = postgresInitDBProcess.Run(); err != nil {
+	if err = faults.checkCommand(postgresInitDBProcess); err == nil {
+		err = postgresInitDBProcess.Run()
+	}
+
+	if err != nil {
 		logContent, readLogsErr := readLogsOrTimeout(logger) // we want to preserve the original error
 		if readLogsErr != nil {
 			logContent = []byte(string(logContent) + " - " + readLogsErr.Error())
@@ -58,21 +84,153 @@ func defaultInitDatabase(binaryExtractLocation, runtimePath, pgDataDir, username
 	return nil
 }
 
-func createPasswordFile(runtimePath, password string) (string, error) {
+// initDBArgs returns the additional initdb arguments derived from the configuration.
+func initDBArgs(config Config) ([]string, error) {
+	var args []string
+
+	preset, err := config.preset.definition(config.version)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.walPath != "" {
+		if walDirName(config.version) == "pg_xlog" {
+			args = append(args, fmt.Sprintf("--xlogdir=%s", config.walPath))
+		} else {
+			args = append(args, fmt.Sprintf("--waldir=%s", config.walPath))
+		}
+	}
+
+	if config.initDBNoSync || preset.initDBNoSync {
+		// --nosync was renamed to --no-sync in Postgres 10
+		if majorVersion(config.version) < 10 {
+			args = append(args, "--nosync")
+		} else {
+			args = append(args, "--no-sync")
+		}
+	}
+
+	if preset.initDBChecksums {
+		args = append(args, "--data-checksums")
+	}
+
+	if config.encoding != "" {
+		args = append(args, fmt.Sprintf("--encoding=%s", config.encoding))
+	}
+
+	// the locale provider can only be chosen from Postgres 15, earlier versions always using libc
+	if config.localeProvider == LocaleProviderICU && majorVersion(config.version) >= 15 {
+		icuLocale := config.icuLocale
+		if icuLocale == "" {
+			icuLocale = "und"
+		}
+
+		args = append(args, "--locale-provider=icu", fmt.Sprintf("--icu-locale=%s", icuLocale))
+	}
+
+	if config.allowGroupAccess {
+		if majorVersion(config.version) < 11 {
+			return nil, errors.New("group access to the data directory requires Postgres 11 or later")
+		}
+
+		args = append(args, "--allow-group-access")
+	}
+
+	authHost := config.authHost
+	if authHost == "" {
+		authHost = preset.authHost
+	}
+
+	for _, auth := range []struct {
+		flag   string
+		method AuthMethod
+	}{
+		{"--auth-local", config.authLocal},
+		{"--auth-host", authHost},
+	} {
+		if auth.method == "" {
+			continue
+		}
+
+		if auth.method == AuthScramSHA256 && majorVersion(config.version) < 10 {
+			return nil, errors.New("scram-sha-256 authentication requires Postgres 10 or later")
+		}
+
+		args = append(args, fmt.Sprintf("%s=%s", auth.flag, auth.method))
+	}
+
+	for _, arg := range config.initDBArgs {
+		if managedInitDBArg(arg) {
+			return nil, fmt.Errorf("initdb argument %s is set from the Config and cannot be passed with InitDBArgs", arg)
+		}
+	}
+
+	return append(args, config.initDBArgs...), nil
+}
+
+// managedInitDBArg reports whether arg sets the data directory or superuser, which are passed to initdb separately.
+func managedInitDBArg(arg string) bool {
+	name := strings.SplitN(arg, "=", 2)[0]
+
+	switch name {
+	case "-D", "--pgdata", "-U", "--username", "--pwfile", "--pwprompt", "-W":
+		return true
+	}
+
+	return false
+}
+
+func createPasswordFile(faults faultHooks, runtimePath, password string) (string, error) {
 	passwordFileLocation := filepath.Join(runtimePath, "pwfile")
-	if err := os.WriteFile(passwordFileLocation, []byte(password), 0600); err != nil {
+
+	err := faults.checkFile(passwordFileLocation)
+	if err == nil {
+		err = os.WriteFile(passwordFileLocation, []byte(password), 0600)
+	}
+
+	if err != nil {
 		return "", fmt.Errorf("unable to write password file to %s", passwordFileLocation)
 	}
 
 	return passwordFileLocation, nil
 }
 
-func defaultCreateDatabase(ctx context.Context, port uint32, username, password, database string) (err error) {
+// DefaultCreateDatabase runs CREATE DATABASE unless the database is postgres, which initdb creates.
+//
+// Given the ctx passed to a CreateDatabaseStrategy it connects as the library does, through the socket directory when
+// the server only listens on a Unix-domain socket and with SSL when the server requires it. Otherwise it connects to
+// localhost without SSL.
+func DefaultCreateDatabase(ctx context.Context, port uint32, username, password, database string, options []string) error {
+	address, ok := ctx.Value(serverAddressKey{}).(serverAddress)
+	if !ok {
+		address = serverAddress{host: "localhost", sslMode: "disable"}
+	}
+
+	return createDatabaseOn(ctx, address.host, address.sslMode, port, username, password, database, options)
+}
+
+// serverAddressKey is the context key of the serverAddress passed to a CreateDatabaseStrategy.
+type serverAddressKey struct{}
+
+// serverAddress is how the library reaches the server, host being a socket directory when TCP is disabled.
+type serverAddress struct {
+	host    string
+	sslMode string
+}
+
+// withServerAddress returns ctx carrying how the library reaches the server configured by config.
+func withServerAddress(ctx context.Context, config Config) context.Context {
+	return context.WithValue(ctx, serverAddressKey{}, serverAddress{host: connectionHost(config), sslMode: internalSSLMode(config)})
+}
+
+// createDatabaseOn runs DefaultCreateDatabase against the server at host, which may be a socket directory, using the
+// given sslmode.
+func createDatabaseOn(ctx context.Context, host, sslMode string, port uint32, username, password, database string, options []string) (err error) {
 	if database == "postgres" {
 		return nil
 	}
 
-	conn, err := openDatabaseConnection(port, username, password, "postgres")
+	conn, err := openDatabaseConnection(host, sslMode, port, username, password, "postgres")
 	if err != nil {
 		return errorCustomDatabase(database, err)
 	}
@@ -82,13 +240,46 @@ func defaultCreateDatabase(ctx context.Context, port uint32, username, password,
 		err = connectionClose(db, err)
 	}()
 
-	if _, err := db.ExecContext(ctx, fmt.Sprintf("CREATE DATABASE \"%s\"", database)); err != nil {
+	if _, err := db.ExecContext(ctx, strings.Join(append([]string{fmt.Sprintf("CREATE DATABASE \"%s\"", database)}, options...), " ")); err != nil {
 		return errorCustomDatabase(database, err)
 	}
 
 	return nil
 }
 
+// createDatabaseOptions returns the options of CREATE DATABASE that apply the configured locale and encoding to the
+// database.
+func createDatabaseOptions(config Config) ([]string, error) {
+	if config.databaseCollate == "" && config.databaseCType == "" && config.databaseEncoding == "" {
+		return nil, nil
+	}
+
+	if config.database == "postgres" {
+		if config.databaseEncoding != "" {
+			return nil, errors.New("the encoding of the postgres database is set by initdb, use Encoding rather than DatabaseEncoding")
+		}
+
+		return nil, errors.New("the locale of the postgres database is set by initdb, use Locale rather than DatabaseLocale")
+	}
+
+	// template1 may have a different locale and encoding, which is only allowed when copying template0
+	options := []string{"TEMPLATE template0"}
+
+	if config.databaseEncoding != "" {
+		options = append(options, "ENCODING "+pq.QuoteLiteral(config.databaseEncoding))
+	}
+
+	if config.databaseCollate != "" {
+		options = append(options, "LC_COLLATE "+pq.QuoteLiteral(config.databaseCollate))
+	}
+
+	if config.databaseCType != "" {
+		options = append(options, "LC_CTYPE "+pq.QuoteLiteral(config.databaseCType))
+	}
+
+	return options, nil
+}
+
 // connectionClose closes the database connection and handles the error of the function that used the database connection
 func connectionClose(db io.Closer, err error) error {
 	closeErr := db.Close()
@@ -112,7 +303,7 @@ func healthCheckDatabaseOrTimeout(ctx context.Context, config Config) error {
 
 	go func() {
 		for ctx.Err() == nil {
-			if err := healthCheckDatabase(config.port, config.database, config.username, config.password); err != nil {
+			if err := healthCheckDatabase(connectionHost(config), internalSSLMode(config), config.port, config.database, config.username, config.password); err != nil {
 				continue
 			}
 			healthCheckSignal <- true
@@ -129,8 +320,8 @@ func healthCheckDatabaseOrTimeout(ctx context.Context, config Config) error {
 	}
 }
 
-func healthCheckDatabase(port uint32, database, username, password string) (err error) {
-	conn, err := openDatabaseConnection(port, username, password, database)
+func healthCheckDatabase(host, sslMode string, port uint32, database, username, password string) (err error) {
+	conn, err := openDatabaseConnection(host, sslMode, port, username, password, database)
 	if err != nil {
 		return err
 	}
@@ -147,12 +338,15 @@ func healthCheckDatabase(port uint32, database, username, password string) (err
 	return nil
 }
 
-func openDatabaseConnection(port uint32, username string, password string, database string) (*pq.Connector, error) {
-	conn, err := pq.NewConnector(fmt.Sprintf("host=localhost port=%d user=%s password=%s dbname=%s sslmode=disable",
+func openDatabaseConnection(host, sslMode string, port uint32, username string, password string, database string) (*pq.Connector, error) {
+	conn, err := pq.NewConnector(fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s application_name=%s",
+		host,
 		port,
 		username,
 		password,
-		database))
+		database,
+		sslMode,
+		internalApplicationName))
 	if err != nil {
 		return nil, err
 	}
@@ -160,6 +354,50 @@ func openDatabaseConnection(port uint32, username string, password string, datab
 	return conn, nil
 }
 
+// openDB opens a connection pool to the given database of the running instance using the configured credentials.
+func (ep *EmbeddedPostgres) openDB(database string) (*sql.DB, error) {
+	conn, err := openDatabaseConnection(connectionHost(ep.config), internalSSLMode(ep.config), ep.config.port, ep.config.username, ep.config.password, database)
+	if err != nil {
+		return nil, err
+	}
+
+	return sql.OpenDB(conn), nil
+}
+
+// execStatements runs each statement in order against the given database of the running instance.
+func (ep *EmbeddedPostgres) execStatements(ctx context.Context, database string, statements ...string) (err error) {
+	if !ep.started {
+		return errors.New("server has not been started")
+	}
+
+	db, err := ep.openDB(database)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		err = connectionClose(db, err)
+	}()
+
+	for _, statement := range statements {
+		if _, err := db.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("unable to execute '%s': %w", statement, err)
+		}
+	}
+
+	return nil
+}
+
+// quoteQualifiedIdentifier quotes each part of a possibly schema qualified name such as public.users.
+func quoteQualifiedIdentifier(name string) string {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		parts[i] = pq.QuoteIdentifier(part)
+	}
+
+	return strings.Join(parts, ".")
+}
+
 func errorCustomDatabase(database string, err error) error {
 	return fmt.Errorf("unable to connect to create database with custom name %s with the following error: %s", database, err)
 }
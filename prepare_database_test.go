@@ -2,6 +2,7 @@ package embeddedpostgres
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"io/ioutil"
@@ -10,16 +11,18 @@ import (
 	"testing"
 	"time"
 
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
-func Test_defaultInitDatabase_ErrorWhenCannotCreatePasswordFile(t *testing.T) {
-	err := defaultInitDatabase("path_not_exists", "path_not_exists", "path_not_exists", "Tom", "Beer", "", os.Stderr)
+func Test_DefaultInitDatabase_ErrorWhenCannotCreatePasswordFile(t *testing.T) {
+	err := DefaultInitDatabase("path_not_exists", "path_not_exists", "path_not_exists", "Tom", "Beer", "", nil, os.Stderr)
 
 	assert.EqualError(t, err, "unable to write password file to path_not_exists/pwfile")
 }
 
-func Test_defaultInitDatabase_ErrorWhenCannotStartInitDBProcess(t *testing.T) {
+func Test_DefaultInitDatabase_ErrorWhenCannotStartInitDBProcess(t *testing.T) {
 	binTempDir, err := os.MkdirTemp("", "prepare_database_test_bin")
 	if err != nil {
 		panic(err)
@@ -51,7 +54,7 @@ func Test_defaultInitDatabase_ErrorWhenCannotStartInitDBProcess(t *testing.T) {
 
 	_, _ = logFile.Write([]byte("and here are the logs!"))
 
-	err = defaultInitDatabase(binTempDir, runtimeTempDir, filepath.Join(runtimeTempDir, "data"), "Tom", "Beer", "", logFile)
+	err = DefaultInitDatabase(binTempDir, runtimeTempDir, filepath.Join(runtimeTempDir, "data"), "Tom", "Beer", "", nil, logFile)
 
 	assert.NotNil(t, err)
 	assert.Contains(t, err.Error(), fmt.Sprintf("unable to init database using '%s/bin/initdb -A password -U Tom -D %s/data --pwfile=%s/pwfile'",
@@ -62,7 +65,7 @@ func Test_defaultInitDatabase_ErrorWhenCannotStartInitDBProcess(t *testing.T) {
 	assert.FileExists(t, filepath.Join(runtimeTempDir, "pwfile"))
 }
 
-func Test_defaultInitDatabase_ErrorInvalidLocaleSetting(t *testing.T) {
+func Test_DefaultInitDatabase_ErrorInvalidLocaleSetting(t *testing.T) {
 	tempDir, err := os.MkdirTemp("", "prepare_database_test")
 	if err != nil {
 		panic(err)
@@ -74,7 +77,7 @@ func Test_defaultInitDatabase_ErrorInvalidLocaleSetting(t *testing.T) {
 		}
 	}()
 
-	err = defaultInitDatabase(tempDir, tempDir, filepath.Join(tempDir, "data"), "postgres", "postgres", "en_XY", os.Stderr)
+	err = DefaultInitDatabase(tempDir, tempDir, filepath.Join(tempDir, "data"), "postgres", "postgres", "en_XY", nil, os.Stderr)
 
 	assert.NotNil(t, err)
 	assert.Contains(t, err.Error(), fmt.Sprintf("unable to init database using '%s/bin/initdb -A password -U postgres -D %s/data --pwfile=%s/pwfile --locale=en_XY'",
@@ -83,7 +86,7 @@ func Test_defaultInitDatabase_ErrorInvalidLocaleSetting(t *testing.T) {
 		tempDir))
 }
 
-func Test_defaultInitDatabase_PwFileRemoved(t *testing.T) {
+func Test_DefaultInitDatabase_PwFileRemoved(t *testing.T) {
 	tempDir, err := os.MkdirTemp("", "prepare_database_test")
 	if err != nil {
 		panic(err)
@@ -112,16 +115,16 @@ func Test_defaultInitDatabase_PwFileRemoved(t *testing.T) {
 	assert.True(t, os.IsNotExist(err), "pwfile (%v) still exists after starting the db", pwFile)
 }
 
-func Test_defaultCreateDatabase_ErrorWhenSQLOpenError(t *testing.T) {
+func Test_DefaultCreateDatabase_ErrorWhenSQLOpenError(t *testing.T) {
 	ctx, cncl := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cncl()
 
-	err := defaultCreateDatabase(ctx, 1234, "user client_encoding=lol", "password", "database")
+	err := DefaultCreateDatabase(ctx, 1234, "user client_encoding=lol", "password", "database", nil)
 
 	assert.EqualError(t, err, "unable to connect to create database with custom name database with the following error: client_encoding must be absent or 'UTF8'")
 }
 
-func Test_defaultCreateDatabase_DashesInName(t *testing.T) {
+func Test_DefaultCreateDatabase_DashesInName(t *testing.T) {
 	database := NewDatabase(DefaultConfig().
 		Port(9832).
 		Database("my-cool-database"))
@@ -135,7 +138,7 @@ func Test_defaultCreateDatabase_DashesInName(t *testing.T) {
 	}
 }
 
-func Test_defaultCreateDatabase_ErrorWhenQueryError(t *testing.T) {
+func Test_DefaultCreateDatabase_ErrorWhenQueryError(t *testing.T) {
 	database := NewDatabase(DefaultConfig().
 		Port(9831).
 		Database("b33r"))
@@ -153,13 +156,13 @@ func Test_defaultCreateDatabase_ErrorWhenQueryError(t *testing.T) {
 
 	defer cncl()
 
-	err := defaultCreateDatabase(ctx, 9831, "postgres", "postgres", "b33r")
+	err := DefaultCreateDatabase(ctx, 9831, "postgres", "postgres", "b33r", nil)
 
 	assert.EqualError(t, err, `unable to connect to create database with custom name b33r with the following error: pq: database "b33r" already exists`)
 }
 
 func Test_healthCheckDatabase_ErrorWhenSQLConnectingError(t *testing.T) {
-	err := healthCheckDatabase(1234, "tom client_encoding=lol", "more", "b33r")
+	err := healthCheckDatabase("localhost", "disable", 1234, "tom client_encoding=lol", "more", "b33r")
 
 	assert.EqualError(t, err, "client_encoding must be absent or 'UTF8'")
 }
@@ -257,3 +260,329 @@ func TestConnCloserWithErr(t *testing.T) {
 		})
 	}
 }
+
+func Test_initDBArgs_WALPath(t *testing.T) {
+	args, err := initDBArgs(DefaultConfig())
+	assert.NoError(t, err)
+	assert.Empty(t, args)
+
+	args, err = initDBArgs(DefaultConfig().WALPath("/tmp/wal"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"--waldir=/tmp/wal"}, args)
+
+	args, err = initDBArgs(DefaultConfig().Version(V9).WALPath("/tmp/wal"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"--xlogdir=/tmp/wal"}, args)
+}
+
+func Test_initDBArgs_NoSync(t *testing.T) {
+	args, err := initDBArgs(DefaultConfig().InitDBNoSync(true))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"--no-sync"}, args)
+
+	args, err = initDBArgs(DefaultConfig().Version(V9).InitDBNoSync(true))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"--nosync"}, args)
+}
+
+func Test_initDBArgs_AllowGroupAccess(t *testing.T) {
+	args, err := initDBArgs(DefaultConfig().AllowGroupAccess(true))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"--allow-group-access"}, args)
+
+	_, err = initDBArgs(DefaultConfig().Version(V10).AllowGroupAccess(true))
+	assert.EqualError(t, err, "group access to the data directory requires Postgres 11 or later")
+}
+
+func Test_initDBArgs_AuthMethods(t *testing.T) {
+	args, err := initDBArgs(DefaultConfig().AuthLocal(AuthTrust).AuthHost(AuthScramSHA256))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"--auth-local=trust", "--auth-host=scram-sha-256"}, args)
+
+	_, err = initDBArgs(DefaultConfig().Version(V9).AuthHost(AuthScramSHA256))
+	assert.EqualError(t, err, "scram-sha-256 authentication requires Postgres 10 or later")
+}
+
+func Test_initDBArgs_Passthrough(t *testing.T) {
+	args, err := initDBArgs(DefaultConfig().AuthHost(AuthMD5).InitDBArgs("--data-checksums", "--auth-host=scram-sha-256"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"--auth-host=md5", "--data-checksums", "--auth-host=scram-sha-256"}, args)
+
+	for _, arg := range []string{"-D", "--pgdata=/elsewhere", "-U", "--username=admin", "--pwfile=/secret"} {
+		_, err = initDBArgs(DefaultConfig().InitDBArgs(arg))
+		assert.EqualError(t, err, "initdb argument "+arg+" is set from the Config and cannot be passed with InitDBArgs")
+	}
+}
+
+func Test_InitDBArgs(t *testing.T) {
+	database := NewDatabase(DefaultConfig().RuntimePath(t.TempDir()).Port(0).InitDBArgs("--data-checksums"))
+	require.NoError(t, database.Start())
+
+	defer func() {
+		assert.NoError(t, database.Stop())
+	}()
+
+	db, err := database.Open("postgres")
+	require.NoError(t, err)
+
+	var checksums string
+	require.NoError(t, db.QueryRow("SHOW data_checksums").Scan(&checksums))
+	assert.Equal(t, "on", checksums)
+}
+
+func Test_AuthHostScram(t *testing.T) {
+	database := NewDatabase(DefaultConfig().
+		Port(9833).
+		AuthLocal(AuthTrust).
+		AuthHost(AuthScramSHA256))
+	if err := database.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() {
+		if err := database.Stop(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	db, err := sql.Open("postgres", "host=localhost port=9833 user=postgres password=postgres dbname=postgres sslmode=disable")
+	assert.NoError(t, err)
+
+	var passwordEncryption string
+	assert.NoError(t, db.QueryRow("SHOW password_encryption").Scan(&passwordEncryption))
+	assert.Equal(t, "scram-sha-256", passwordEncryption)
+	assert.NoError(t, db.Close())
+}
+
+func Test_AllowGroupAccess(t *testing.T) {
+	database := NewDatabase(DefaultConfig().AllowGroupAccess(true))
+	if err := database.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() {
+		if err := database.Stop(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	info, err := os.Stat(database.config.dataPath)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0750), info.Mode().Perm())
+
+	info, err = os.Stat(database.ConfDir())
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0750), info.Mode().Perm())
+}
+
+func Test_WALPath(t *testing.T) {
+	walDir, err := os.MkdirTemp("", "prepare_database_test_wal")
+	if err != nil {
+		panic(err)
+	}
+
+	defer func() {
+		if err := os.RemoveAll(walDir); err != nil {
+			panic(err)
+		}
+	}()
+
+	walPath := filepath.Join(walDir, "pg_wal")
+
+	database := NewDatabase(DefaultConfig().WALPath(walPath))
+	if err := database.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() {
+		if err := database.Stop(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	linkTarget, err := os.Readlink(filepath.Join(database.config.dataPath, "pg_wal"))
+	assert.NoError(t, err)
+	assert.Equal(t, walPath, linkTarget)
+}
+
+func Test_createDatabaseOptions(t *testing.T) {
+	options, err := createDatabaseOptions(DefaultConfig().Database("app"))
+	assert.NoError(t, err)
+	assert.Empty(t, options)
+
+	options, err = createDatabaseOptions(DefaultConfig().Database("app").DatabaseLocale("de_DE.UTF-8", "C"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"TEMPLATE template0", "LC_COLLATE 'de_DE.UTF-8'", "LC_CTYPE 'C'"}, options)
+
+	options, err = createDatabaseOptions(DefaultConfig().Database("app").DatabaseLocale("", "C"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"TEMPLATE template0", "LC_CTYPE 'C'"}, options)
+
+	_, err = createDatabaseOptions(DefaultConfig().DatabaseLocale("C", "C"))
+	assert.EqualError(t, err, "the locale of the postgres database is set by initdb, use Locale rather than DatabaseLocale")
+}
+
+func Test_createDatabaseOptions_Encoding(t *testing.T) {
+	options, err := createDatabaseOptions(DefaultConfig().Database("app").DatabaseEncoding("LATIN1").DatabaseLocale("C", "C"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"TEMPLATE template0", "ENCODING 'LATIN1'", "LC_COLLATE 'C'", "LC_CTYPE 'C'"}, options)
+
+	_, err = createDatabaseOptions(DefaultConfig().DatabaseEncoding("LATIN1"))
+	assert.EqualError(t, err, "the encoding of the postgres database is set by initdb, use Encoding rather than DatabaseEncoding")
+}
+
+func Test_initDBArgs_Encoding(t *testing.T) {
+	args, err := initDBArgs(DefaultConfig().Encoding("SQL_ASCII"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"--encoding=SQL_ASCII"}, args)
+}
+
+func Test_initDBArgs_LocaleProvider(t *testing.T) {
+	args, err := initDBArgs(DefaultConfig().Version(V15).LocaleProvider(LocaleProviderICU, "de-DE"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"--locale-provider=icu", "--icu-locale=de-DE"}, args)
+
+	args, err = initDBArgs(DefaultConfig().Version(V15).LocaleProvider(LocaleProviderICU, ""))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"--locale-provider=icu", "--icu-locale=und"}, args)
+
+	args, err = initDBArgs(DefaultConfig().Version(V14).LocaleProvider(LocaleProviderICU, "de-DE"))
+	assert.NoError(t, err)
+	assert.Empty(t, args)
+
+	args, err = initDBArgs(DefaultConfig().Version(V15).LocaleProvider(LocaleProviderLibc, ""))
+	assert.NoError(t, err)
+	assert.Empty(t, args)
+}
+
+func Test_LocaleProvider(t *testing.T) {
+	database := NewDatabase(DefaultConfig().
+		Version(V15).
+		RuntimePath(t.TempDir()).
+		Port(0).
+		LocaleProvider(LocaleProviderICU, "en-US"))
+	require.NoError(t, database.Start())
+
+	defer func() {
+		assert.NoError(t, database.Stop())
+	}()
+
+	db, err := database.Open("postgres")
+	require.NoError(t, err)
+
+	var provider, icuLocale string
+	require.NoError(t, db.QueryRow("SELECT datlocprovider::text, daticulocale FROM pg_database WHERE datname = 'postgres'").Scan(&provider, &icuLocale))
+	assert.Equal(t, "i", provider)
+	assert.Equal(t, "en-US", icuLocale)
+}
+
+func Test_Encoding(t *testing.T) {
+	database := NewDatabase(DefaultConfig().
+		RuntimePath(t.TempDir()).
+		Port(0).
+		Locale("C").
+		Encoding("SQL_ASCII").
+		Database("app").
+		DatabaseEncoding("LATIN1"))
+	require.NoError(t, database.Start())
+
+	defer func() {
+		assert.NoError(t, database.Stop())
+	}()
+
+	db, err := database.Open("postgres")
+	require.NoError(t, err)
+
+	for database, expected := range map[string]string{"postgres": "SQL_ASCII", "app": "LATIN1"} {
+		var encoding string
+		require.NoError(t, db.QueryRow("SELECT pg_encoding_to_char(encoding) FROM pg_database WHERE datname = $1", database).Scan(&encoding))
+		assert.Equal(t, expected, encoding)
+	}
+}
+
+func Test_DatabaseLocale(t *testing.T) {
+	database := NewDatabase(DefaultConfig().
+		RuntimePath(t.TempDir()).
+		Database("app").
+		DatabaseLocale("C", "POSIX").
+		DatabaseClientEncoding("LATIN1"))
+	require.NoError(t, database.Start())
+
+	defer func() {
+		assert.NoError(t, database.Stop())
+	}()
+
+	db, err := database.openDB("postgres")
+	require.NoError(t, err)
+
+	defer func() {
+		assert.NoError(t, db.Close())
+	}()
+
+	var collate, ctype string
+	require.NoError(t, db.QueryRow("SELECT datcollate, datctype FROM pg_database WHERE datname = 'app'").Scan(&collate, &ctype))
+	assert.Equal(t, "C", collate)
+	assert.Equal(t, "POSIX", ctype)
+
+	var settings []string
+	require.NoError(t, db.QueryRow(`SELECT setconfig FROM pg_db_role_setting s JOIN pg_database d ON d.oid = s.setdatabase
+WHERE d.datname = 'app' AND s.setrole = 0`).Scan(pq.Array(&settings)))
+	assert.Equal(t, []string{"client_encoding=LATIN1"}, settings)
+}
+
+func Test_InitDatabaseStrategy(t *testing.T) {
+	jarFile, cleanUp := createTempXzArchive()
+	defer cleanUp()
+
+	var dataPath string
+
+	database := NewDatabase(DefaultConfig().
+		RuntimePath(filepath.Join(t.TempDir(), "runtime")).
+		InitDatabaseStrategy(func(binaryExtractLocation, runtimePath, pgDataDir, username, password, locale string, extraArgs []string, logger *os.File) error {
+			dataPath = pgDataDir
+			return errors.New("restore from backup failed")
+		}))
+
+	database.cacheLocator = func() (string, bool) {
+		return jarFile, true
+	}
+
+	err := database.Start()
+
+	assert.EqualError(t, err, "restore from backup failed")
+	assert.Equal(t, filepath.Join(database.config.runtimePath, "data"), dataPath)
+}
+
+func Test_withServerAddress(t *testing.T) {
+	ctx := withServerAddress(context.Background(), DefaultConfig())
+	assert.Equal(t, serverAddress{host: "localhost", sslMode: "disable"}, ctx.Value(serverAddressKey{}))
+
+	ctx = withServerAddress(context.Background(), DefaultConfig().UnixSocketOnly("/tmp/sockets"))
+	assert.Equal(t, serverAddress{host: "/tmp/sockets", sslMode: "disable"}, ctx.Value(serverAddressKey{}))
+
+	ctx = withServerAddress(context.Background(), DefaultConfig().Preset(ManagedCloud))
+	assert.Equal(t, serverAddress{host: "localhost", sslMode: "require"}, ctx.Value(serverAddressKey{}))
+}
+
+func Test_DefaultCreateDatabase_ConnectsToServerAddress(t *testing.T) {
+	socketDir := t.TempDir()
+	ctx := withServerAddress(context.Background(), DefaultConfig().UnixSocketOnly(socketDir))
+
+	err := DefaultCreateDatabase(ctx, 9831, "postgres", "postgres", "b33r", nil)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), filepath.Join(socketDir, ".s.PGSQL.9831"))
+}
+
+func Test_CreateDatabaseStrategy(t *testing.T) {
+	var created string
+
+	database := NewDatabase(DefaultConfig().
+		CreateDatabaseStrategy(func(ctx context.Context, port uint32, username, password, database string, options []string) error {
+			created = database
+			return DefaultCreateDatabase(ctx, port, username, password, "postgres", options)
+		}))
+
+	require.NoError(t, database.createDatabase(context.Background(), 5432, "postgres", "postgres", "beer", nil))
+	assert.Equal(t, "beer", created)
+}
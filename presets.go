@@ -0,0 +1,129 @@
+package embeddedpostgres
+
+import (
+	"fmt"
+	"time"
+)
+
+// Preset represents a named bundle of server settings selected with Config.Preset.
+type Preset string
+
+// Predefined presets.
+const (
+	// ManagedCloud approximates the defaults of managed Postgres services such as Amazon RDS and Google Cloud SQL:
+	// scram-sha-256 password authentication, TCP keepalive and idle transaction timeouts, verbose logging and, as with
+	// rds.force_ssl, TLS required of every TCP connection. TLS is enabled as by Config.TLS, with a generated certificate
+	// unless one is provided, and connection strings default to sslmode=require.
+	ManagedCloud = Preset("managed-cloud")
+	// FastEphemeral trades durability for speed, the canonical tuning for throwaway test databases: fsync,
+	// synchronous_commit, full_page_writes and autovacuum are turned off, shared_buffers is kept small and initdb does
+	// not sync the new data directory to disk. It is unsafe for data that matters, which an operating system crash or
+	// power loss can corrupt.
+	FastEphemeral = Preset("fast-ephemeral")
+	// Durable keeps every safeguard against data loss on: fsync, synchronous_commit and full_page_writes are turned on
+	// explicitly and the data directory is initialised with data checksums.
+	Durable = Preset("durable")
+	// CI suits test suites on shared continuous integration runners: durability is traded for speed as with
+	// FastEphemeral, but autovacuum is left on for long suites, max_connections is raised for parallel tests, lock waits
+	// are logged to diagnose flaky tests and the start timeout is raised to 60 seconds for slow runners.
+	CI = Preset("ci")
+	// Benchmark aims for stable, realistic measurements: durability is left on, shared_buffers and max_wal_size are
+	// raised so that checkpoints are rare, autovacuum is turned off so that it does not run during measurements, I/O
+	// timing is tracked and the start timeout is raised to 60 seconds for the larger shared memory.
+	Benchmark = Preset("benchmark")
+)
+
+const presetConfSnippet = "00-preset"
+
+type presetDefinition struct {
+	parameters      map[string]string
+	authHost        AuthMethod
+	initDBNoSync    bool
+	initDBChecksums bool
+	requireTLS      bool
+	startTimeout    time.Duration
+}
+
+func (p Preset) definition(version PostgresVersion) (presetDefinition, error) {
+	switch p {
+	case "":
+		return presetDefinition{}, nil
+	case ManagedCloud:
+		definition := presetDefinition{
+			parameters: map[string]string{
+				"tcp_keepalives_idle":                 "300",
+				"tcp_keepalives_interval":             "30",
+				"tcp_keepalives_count":                "2",
+				"idle_in_transaction_session_timeout": "86400000",
+				"log_line_prefix":                     "%t:%r:%u@%d:[%p]:",
+				"log_checkpoints":                     "on",
+				"log_autovacuum_min_duration":         "10000",
+				"log_min_error_statement":             "error",
+			},
+			requireTLS: true,
+		}
+
+		// scram-sha-256 was introduced in Postgres 10
+		if majorVersion(version) >= 10 {
+			definition.parameters["password_encryption"] = "scram-sha-256"
+			definition.authHost = AuthScramSHA256
+		}
+
+		return definition, nil
+	case FastEphemeral:
+		return presetDefinition{
+			parameters: map[string]string{
+				"fsync":              "off",
+				"synchronous_commit": "off",
+				"full_page_writes":   "off",
+				"shared_buffers":     "32MB",
+				"autovacuum":         "off",
+			},
+			initDBNoSync: true,
+		}, nil
+	case Durable:
+		return presetDefinition{
+			parameters: map[string]string{
+				"fsync":              "on",
+				"synchronous_commit": "on",
+				"full_page_writes":   "on",
+			},
+			initDBChecksums: true,
+		}, nil
+	case CI:
+		return presetDefinition{
+			parameters: map[string]string{
+				"fsync":              "off",
+				"synchronous_commit": "off",
+				"full_page_writes":   "off",
+				"max_connections":    "200",
+				"log_lock_waits":     "on",
+			},
+			initDBNoSync: true,
+			startTimeout: time.Minute,
+		}, nil
+	case Benchmark:
+		return presetDefinition{
+			parameters: map[string]string{
+				"shared_buffers":     "256MB",
+				"max_wal_size":       "4GB",
+				"checkpoint_timeout": "30min",
+				"autovacuum":         "off",
+				"track_io_timing":    "on",
+			},
+			startTimeout: time.Minute,
+		}, nil
+	default:
+		return presetDefinition{}, fmt.Errorf("unknown preset %q", p)
+	}
+}
+
+// writePresetConf writes the settings of the configured preset into the drop-in configuration directory.
+func (ep *EmbeddedPostgres) writePresetConf() error {
+	definition, err := ep.config.preset.definition(ep.config.version)
+	if err != nil {
+		return err
+	}
+
+	return ep.writeManagedConfSnippet(presetConfSnippet, definition.parameters)
+}
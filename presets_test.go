@@ -0,0 +1,196 @@
+package embeddedpostgres
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Preset_ManagedCloud(t *testing.T) {
+	definition, err := ManagedCloud.definition(V15)
+	require.NoError(t, err)
+	assert.Equal(t, "scram-sha-256", definition.parameters["password_encryption"])
+	assert.Equal(t, AuthScramSHA256, definition.authHost)
+	assert.True(t, definition.requireTLS)
+
+	definition, err = ManagedCloud.definition(V9)
+	require.NoError(t, err)
+	assert.NotContains(t, definition.parameters, "password_encryption")
+	assert.Equal(t, AuthMethod(""), definition.authHost)
+}
+
+func Test_Preset_ErrorWhenUnknown(t *testing.T) {
+	_, err := Preset("serverless").definition(V15)
+
+	assert.EqualError(t, err, `unknown preset "serverless"`)
+}
+
+func Test_Preset_ManagedCloudRequiresTLS(t *testing.T) {
+	config := DefaultConfig().Preset(ManagedCloud)
+	assert.True(t, config.tls)
+	assert.Equal(t, "", config.tlsCertFile)
+	assert.Equal(t, "require", internalSSLMode(config))
+
+	config = DefaultConfig().TLS("server.crt", "server.key").Preset(ManagedCloud)
+	assert.Equal(t, "server.crt", config.tlsCertFile)
+
+	assert.Equal(t, "disable", internalSSLMode(DefaultConfig().Preset(ManagedCloud).UnixSocketOnly("")))
+	assert.Equal(t, "disable", internalSSLMode(DefaultConfig().TLS("", "")))
+}
+
+func Test_initDBArgs_Preset(t *testing.T) {
+	args, err := initDBArgs(DefaultConfig().Preset(ManagedCloud))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"--auth-host=scram-sha-256"}, args)
+
+	args, err = initDBArgs(DefaultConfig().Preset(ManagedCloud).AuthHost(AuthMD5))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"--auth-host=md5"}, args)
+}
+
+func Test_writePresetConf(t *testing.T) {
+	tempDir := t.TempDir()
+	presetPath := filepath.Join(tempDir, confDirName, presetConfSnippet+".conf")
+
+	database := NewDatabase(DefaultConfig().DataPath(tempDir).Preset(ManagedCloud))
+	require.NoError(t, database.writePresetConf())
+
+	content, err := os.ReadFile(presetPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "password_encryption = 'scram-sha-256'\n")
+
+	database = NewDatabase(DefaultConfig().DataPath(tempDir))
+	require.NoError(t, database.writePresetConf())
+
+	_, err = os.Stat(presetPath)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func Test_PresetManagedCloud(t *testing.T) {
+	database := NewDatabase(DefaultConfig().Preset(ManagedCloud))
+	if err := database.Start(); err != nil {
+		shutdownDBAndFail(t, err, database)
+	}
+
+	plain, err := sql.Open("postgres", "host=localhost port=5432 user=postgres password=postgres dbname=postgres sslmode=disable")
+	if err != nil {
+		shutdownDBAndFail(t, err, database)
+	}
+
+	assert.Error(t, plain.Ping())
+
+	if err := plain.Close(); err != nil {
+		shutdownDBAndFail(t, err, database)
+	}
+
+	db, err := sql.Open("postgres", database.ConnectionString())
+	if err != nil {
+		shutdownDBAndFail(t, err, database)
+	}
+
+	var passwordEncryption, keepalivesIdle string
+	var ssl bool
+	if err := db.QueryRow("SELECT current_setting('password_encryption'), current_setting('tcp_keepalives_idle'), ssl FROM pg_stat_ssl WHERE pid = pg_backend_pid()").Scan(&passwordEncryption, &keepalivesIdle, &ssl); err != nil {
+		shutdownDBAndFail(t, err, database)
+	}
+
+	assert.Equal(t, "scram-sha-256", passwordEncryption)
+	assert.Equal(t, "300", keepalivesIdle)
+	assert.True(t, ssl)
+
+	if err := db.Close(); err != nil {
+		shutdownDBAndFail(t, err, database)
+	}
+
+	if err := database.Stop(); err != nil {
+		shutdownDBAndFail(t, err, database)
+	}
+}
+
+func Test_Preset_FastEphemeral(t *testing.T) {
+	definition, err := FastEphemeral.definition(V15)
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]string{
+		"fsync":              "off",
+		"synchronous_commit": "off",
+		"full_page_writes":   "off",
+		"shared_buffers":     "32MB",
+		"autovacuum":         "off",
+	}, definition.parameters)
+
+	args, err := initDBArgs(DefaultConfig().Preset(FastEphemeral))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"--no-sync"}, args)
+
+	args, err = initDBArgs(DefaultConfig().Version(V9).Preset(FastEphemeral))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"--nosync"}, args)
+}
+
+func Test_PresetFastEphemeral(t *testing.T) {
+	database := StartForTest(t, DefaultConfig().Preset(FastEphemeral))
+
+	db, err := database.Open("postgres")
+	require.NoError(t, err)
+
+	for setting, expected := range map[string]string{"fsync": "off", "autovacuum": "off", "shared_buffers": "32MB"} {
+		var value string
+		require.NoError(t, db.QueryRow("SHOW "+setting).Scan(&value))
+		assert.Equal(t, expected, value, setting)
+	}
+}
+
+func Test_Preset_Profiles(t *testing.T) {
+	durable, err := Durable.definition(V15)
+	require.NoError(t, err)
+	assert.Equal(t, "on", durable.parameters["fsync"])
+	assert.Equal(t, "on", durable.parameters["synchronous_commit"])
+
+	ci, err := CI.definition(V15)
+	require.NoError(t, err)
+	assert.Equal(t, "off", ci.parameters["fsync"])
+	assert.Equal(t, "200", ci.parameters["max_connections"])
+
+	benchmark, err := Benchmark.definition(V15)
+	require.NoError(t, err)
+	assert.Equal(t, "off", benchmark.parameters["autovacuum"])
+	assert.Equal(t, "256MB", benchmark.parameters["shared_buffers"])
+}
+
+func Test_initDBArgs_PresetProfiles(t *testing.T) {
+	args, err := initDBArgs(DefaultConfig().Preset(Durable))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"--data-checksums"}, args)
+
+	args, err = initDBArgs(DefaultConfig().Preset(CI))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"--no-sync"}, args)
+
+	args, err = initDBArgs(DefaultConfig().Preset(Benchmark))
+	assert.NoError(t, err)
+	assert.Empty(t, args)
+}
+
+func Test_Preset_StartTimeout(t *testing.T) {
+	assert.Equal(t, time.Minute, DefaultConfig().StartTimeout(time.Second).Preset(CI).startTimeout)
+	assert.Equal(t, time.Second, DefaultConfig().Preset(CI).StartTimeout(time.Second).startTimeout)
+	assert.Equal(t, time.Second, DefaultConfig().StartTimeout(time.Second).Preset(Durable).startTimeout)
+	assert.Equal(t, 15*time.Second, DefaultConfig().Preset(Preset("serverless")).startTimeout)
+}
+
+func Test_PresetDurable(t *testing.T) {
+	database := StartForTest(t, DefaultConfig().Preset(Durable))
+
+	db, err := database.Open("postgres")
+	require.NoError(t, err)
+
+	var checksums string
+	require.NoError(t, db.QueryRow("SHOW data_checksums").Scan(&checksums))
+	assert.Equal(t, "on", checksums)
+}
@@ -0,0 +1,72 @@
+package embeddedpostgres
+
+import (
+	"errors"
+	"fmt"
+)
+
+// IOPriorityClass is an IO scheduling class as understood by ionice.
+type IOPriorityClass int
+
+const (
+	// IOPriorityDefault leaves the IO priority of the postgres process unchanged.
+	IOPriorityDefault IOPriorityClass = 0
+	// IOPriorityBestEffort schedules IO of the postgres process according to its priority level.
+	IOPriorityBestEffort IOPriorityClass = 2
+	// IOPriorityIdle only schedules IO of the postgres process when no other process needs the disk.
+	IOPriorityIdle IOPriorityClass = 3
+)
+
+// checkPriority returns an error for priorities that cannot be applied on the given operating system.
+func checkPriority(config Config, goos string) error {
+	switch {
+	case config.ioPriorityClass != IOPriorityDefault && goos != "linux":
+		return errors.New("IO priority is only supported on Linux")
+	case config.ioPriorityClass != IOPriorityDefault &&
+		config.ioPriorityClass != IOPriorityBestEffort &&
+		config.ioPriorityClass != IOPriorityIdle:
+		return fmt.Errorf("unsupported IO priority class %d", config.ioPriorityClass)
+	case config.ioPriorityLevel < 0 || config.ioPriorityLevel > 7:
+		return fmt.Errorf("IO priority level %d is not between 0 and 7", config.ioPriorityLevel)
+	case config.niceness < 0 && goos == "windows":
+		return errors.New("raising the priority of postgres is not supported on Windows")
+	}
+
+	return nil
+}
+
+// priorityCommands returns the shell commands that apply the configured priorities to the shell that postgres replaces.
+func priorityCommands(config Config) []string {
+	var commands []string
+
+	if config.niceness != 0 {
+		commands = append(commands, fmt.Sprintf("renice -n %d -p $$ >/dev/null", config.niceness))
+	}
+
+	switch config.ioPriorityClass {
+	case IOPriorityBestEffort:
+		commands = append(commands, fmt.Sprintf("ionice -c 2 -n %d -p $$", config.ioPriorityLevel))
+	case IOPriorityIdle:
+		commands = append(commands, "ionice -c 3 -p $$")
+	}
+
+	return commands
+}
+
+// windowsPriorityClass returns the process creation flag for the priority class matching the niceness. Only lower
+// classes are inherited by the processes pg_ctl starts.
+func windowsPriorityClass(niceness int) uint32 {
+	const (
+		idlePriorityClass        = 0x00000040
+		belowNormalPriorityClass = 0x00004000
+	)
+
+	switch {
+	case niceness >= 15:
+		return idlePriorityClass
+	case niceness > 0:
+		return belowNormalPriorityClass
+	default:
+		return 0
+	}
+}
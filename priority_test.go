@@ -0,0 +1,64 @@
+package embeddedpostgres
+
+import (
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_checkPriority(t *testing.T) {
+	assert.NoError(t, checkPriority(DefaultConfig().Niceness(10).IOPriority(IOPriorityIdle, 0), "linux"))
+	assert.NoError(t, checkPriority(DefaultConfig().Niceness(-5), "darwin"))
+	assert.NoError(t, checkPriority(DefaultConfig().Niceness(10), "windows"))
+
+	assert.EqualError(t, checkPriority(DefaultConfig().IOPriority(IOPriorityIdle, 0), "darwin"),
+		"IO priority is only supported on Linux")
+	assert.EqualError(t, checkPriority(DefaultConfig().IOPriority(IOPriorityClass(1), 0), "linux"),
+		"unsupported IO priority class 1")
+	assert.EqualError(t, checkPriority(DefaultConfig().IOPriority(IOPriorityBestEffort, 8), "linux"),
+		"IO priority level 8 is not between 0 and 7")
+	assert.EqualError(t, checkPriority(DefaultConfig().Niceness(-5), "windows"),
+		"raising the priority of postgres is not supported on Windows")
+}
+
+func Test_priorityCommands(t *testing.T) {
+	assert.Empty(t, priorityCommands(DefaultConfig()))
+	assert.Equal(t, []string{
+		"renice -n 10 -p $$ >/dev/null",
+		"ionice -c 2 -n 4 -p $$",
+	}, priorityCommands(DefaultConfig().Niceness(10).IOPriority(IOPriorityBestEffort, 4)))
+	assert.Equal(t, []string{"ionice -c 3 -p $$"}, priorityCommands(DefaultConfig().IOPriority(IOPriorityIdle, 0)))
+}
+
+func Test_windowsPriorityClass(t *testing.T) {
+	assert.Equal(t, uint32(0), windowsPriorityClass(0))
+	assert.Equal(t, uint32(0x4000), windowsPriorityClass(5))
+	assert.Equal(t, uint32(0x40), windowsPriorityClass(19))
+}
+
+func Test_limitedCommand_Niceness(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("niceness is applied through pg_ctl on Windows")
+	}
+
+	current, err := exec.Command("nice").Output()
+	require.NoError(t, err)
+
+	output, err := limitedCommand(DefaultConfig().Niceness(5), "", "nice").Output()
+	require.NoError(t, err)
+
+	currentNiceness, err := strconv.Atoi(strings.TrimSpace(string(current)))
+	require.NoError(t, err)
+
+	expected := currentNiceness + 5
+	if expected > 19 {
+		expected = 19
+	}
+
+	assert.Equal(t, strconv.Itoa(expected), strings.TrimSpace(string(output)))
+}
@@ -8,35 +8,59 @@ import (
 	"fmt"
 	"os/exec"
 	"path/filepath"
+	"runtime"
+	"sync"
 	"syscall"
 	"time"
 )
 
 type postgresProcess struct {
-	Config Config
-	Logger *syncedLogger
-	cmd    *exec.Cmd
+	Config   Config
+	Logger   *syncedLogger
+	cmd      *exec.Cmd
+	cgroup   string
+	exitOnce sync.Once
+	exit     chan struct{}
+	exitErr  error
 }
 
 func encodeOptions(port uint32, parameters map[string]string) []string {
 	options := []string{"-p", fmt.Sprintf("%d", port)}
-	for k, v := range parameters {
-		options = append(options, "-c", fmt.Sprintf("%s=%s", k, v))
+	for _, k := range sortedParameterNames(parameters) {
+		options = append(options, "-c", fmt.Sprintf("%s=%s", k, parameters[k]))
 	}
 	return options
 }
 
 func (pp *postgresProcess) Start(ctx context.Context) error {
+	if err := checkPriority(pp.Config, runtime.GOOS); err != nil {
+		return err
+	}
+
+	cgroup, err := createCgroup(pp.Config)
+	if err != nil {
+		return err
+	}
+
+	pp.cgroup = cgroup
+
 	postgresBinary := filepath.Join(pp.Config.binariesPath, "bin/postgres")
-	cmd := exec.Command(postgresBinary,
+	cmd := limitedCommand(pp.Config, cgroup, postgresBinary,
 		append(
 			[]string{"-D", pp.Config.dataPath},
 			encodeOptions(pp.Config.port, pp.Config.startParameters)...)...)
-	cmd.Stdout = pp.Logger.file
+	cmd.Stdout = pp.Logger.stdoutFile()
 	cmd.Stderr = pp.Logger.file
 	pp.cmd = cmd
 
+	if err := pp.Config.faults.checkCommand(cmd); err != nil {
+		_ = removeCgroup(pp.cgroup)
+
+		return fmt.Errorf("could not start postgres using %s: %w", cmd.String(), err)
+	}
+
 	if err := pp.cmd.Start(); err != nil {
+		_ = removeCgroup(pp.cgroup)
 		_ = pp.Logger.flush()
 		logContent, _ := readLogsOrTimeout(pp.Logger.file)
 
@@ -84,8 +108,45 @@ func (pp *postgresProcess) waitForPostmasterReady(ctx context.Context, interval
 	}
 }
 
-// Stop will try to stop the Postgres process gracefully returning an error when there were any problems.
+// exited returns a channel closed once the process has exited, waiting for it in the background from the first call.
+// The error it exited with is then held in exitErr.
+func (pp *postgresProcess) exited() <-chan struct{} {
+	pp.exitOnce.Do(func() {
+		pp.exit = make(chan struct{})
+
+		go func() {
+			pp.exitErr = pp.cmd.Wait()
+			close(pp.exit)
+		}()
+	})
+
+	return pp.exit
+}
+
+// Stop will try to stop the Postgres process gracefully returning an error when there were any problems. It is stopped
+// with pg_ctl as on Windows, and then waited for.
 func (pp *postgresProcess) Stop() error {
-	_ = pp.cmd.Process.Signal(syscall.SIGINT)
-	return pp.cmd.Wait()
+	select {
+	case <-pp.exited():
+	default:
+		if err := pgCtlStop(pp.Config, pp.Logger); err != nil {
+			select {
+			case <-pp.exited():
+				// the process exited whilst pg_ctl was stopping it
+			default:
+				return err
+			}
+		}
+	}
+
+	<-pp.exited()
+
+	// the cgroup is removed even when the process exited with an error so that it is not left behind
+	cgroupErr := removeCgroup(pp.cgroup)
+
+	if pp.exitErr != nil {
+		return pp.exitErr
+	}
+
+	return cgroupErr
 }
@@ -0,0 +1,62 @@
+//go:build !windows
+// +build !windows
+
+package embeddedpostgres
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_postgresProcess_Stop_RemovesCgroupWhenExitedWithError(t *testing.T) {
+	cgroup := filepath.Join(t.TempDir(), "embedded-postgres-1234")
+	require.NoError(t, os.Mkdir(cgroup, 0755))
+
+	cmd := exec.Command("sh", "-c", "exit 3")
+	require.NoError(t, cmd.Start())
+
+	process := &postgresProcess{cmd: cmd, cgroup: cgroup}
+	<-process.exited()
+
+	assert.Error(t, process.Stop())
+
+	_, err := os.Stat(cgroup)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func Test_postgresProcess_Stop_CommandFault(t *testing.T) {
+	database := startFakeServer(t)
+	process := database.cmd
+	config := process.Config
+
+	process.Config = config.CommandFault(func(cmd *exec.Cmd) error {
+		assert.Equal(t, "pg_ctl", filepath.Base(cmd.Path))
+		return errDiskFull
+	})
+
+	assert.True(t, errors.Is(database.Stop(), errDiskFull))
+	assert.True(t, database.isStarted())
+
+	process.Config = config
+
+	assert.NoError(t, database.Stop())
+	assert.False(t, database.isStarted())
+}
+
+func Test_Stop_AfterPostmasterKilled(t *testing.T) {
+	database := NewDatabase(DefaultConfig().RuntimePath(t.TempDir()).Port(0))
+	require.NoError(t, database.Start())
+
+	require.NoError(t, database.cmd.cmd.Process.Kill())
+	<-database.cmd.exited()
+
+	require.NoError(t, database.Stop())
+	require.NoError(t, database.Start())
+	assert.NoError(t, database.Stop())
+}
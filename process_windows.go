@@ -5,21 +5,28 @@ package embeddedpostgres
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
+	"syscall"
+	"time"
 )
 
 type postgresProcess struct {
-	Config Config
-	Logger *syncedLogger
+	Config   Config
+	Logger   *syncedLogger
+	exitOnce sync.Once
+	exit     chan struct{}
+	exitErr  error
 }
 
 func encodeOptions(port uint32, parameters map[string]string) string {
 	options := []string{fmt.Sprintf("-p %d", port)}
-	for k, v := range parameters {
-		options = append(options, fmt.Sprintf("-c %s='%s'", k, v))
+	for _, k := range sortedParameterNames(parameters) {
+		options = append(options, fmt.Sprintf("-c %s='%s'", k, parameters[k]))
 	}
 	return strings.Join(options, " ")
 }
@@ -29,12 +36,25 @@ func encodeOptions(port uint32, parameters map[string]string) string {
 // Postgres won't start as administrator.
 // So for now we just use pg_ctl on Windows since it does the hoop jumping.
 func (pp *postgresProcess) Start(ctx context.Context) error {
+	if hasResourceLimits(pp.Config) {
+		return errors.New("resource limits are not supported on Windows")
+	}
+
+	if err := checkPriority(pp.Config, "windows"); err != nil {
+		return err
+	}
+
 	pgCtlBinary := filepath.Join(pp.Config.binariesPath, "bin/pg_ctl")
 	cmd := exec.Command(pgCtlBinary, "start", "-w",
 		"-D", pp.Config.dataPath,
 		"-o", encodeOptions(pp.Config.port, pp.Config.startParameters))
-	cmd.Stdout = pp.Logger.file
+	cmd.Stdout = pp.Logger.stdoutFile()
 	cmd.Stderr = pp.Logger.file
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: windowsPriorityClass(pp.Config.niceness)}
+
+	if err := pp.Config.faults.checkCommand(cmd); err != nil {
+		return fmt.Errorf("could not start postgres using %s: %w", cmd.String(), err)
+	}
 
 	if err := cmd.Run(); err != nil {
 		_ = pp.Logger.flush()
@@ -46,17 +66,30 @@ func (pp *postgresProcess) Start(ctx context.Context) error {
 	return nil
 }
 
-// Stop will try to stop the Postgres process gracefully returning an error when there were any problems.
-// Again, on Windows, we use pg_ctl.
-func (pp *postgresProcess) Stop() error {
+// exited returns a channel closed once the server is no longer running. The process is started by pg_ctl rather than
+// as a child, so its status is polled with pg_ctl in the background from the first call. A status that cannot be
+// determined, as when the data directory has been removed, is taken as the server having exited.
+func (pp *postgresProcess) exited() <-chan struct{} {
+	pp.exitOnce.Do(func() {
+		pp.exit = make(chan struct{})
 
-	pgCtlBinary := filepath.Join(pp.Config.binariesPath, "bin/pg_ctl")
-	cmd := exec.Command(pgCtlBinary, "stop", "-w", "-D", pp.Config.dataPath)
-	cmd.Stdout = pp.Logger.file
-	cmd.Stderr = pp.Logger.file
+		go func() {
+			defer close(pp.exit)
 
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("could not stop postgres using %s", cmd.String())
-	}
-	return nil
+			for {
+				if status, err := pgCtlStatus(pp.Config); err != nil || !status.Running {
+					return
+				}
+
+				time.Sleep(time.Second)
+			}
+		}()
+	})
+
+	return pp.exit
+}
+
+// Stop will try to stop the Postgres process gracefully returning an error when there were any problems.
+func (pp *postgresProcess) Stop() error {
+	return pgCtlStop(pp.Config, pp.Logger)
 }
@@ -0,0 +1,132 @@
+package embeddedpostgres
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/lib/pq"
+)
+
+// provision applies the declarative configuration that needs a running server, such as session defaults, schemas and extensions.
+// It is run on every start after the configured database has been created.
+func (ep *EmbeddedPostgres) provision(ctx context.Context) error {
+	if ep.config.standbyOf != "" {
+		// a standby is read only, so is provisioned through its primary
+		return nil
+	}
+
+	// roles are created first so that they can own databases and schemas
+	if err := ep.createRoles(ctx); err != nil {
+		return err
+	}
+
+	if err := ep.createDatabases(ctx); err != nil {
+		return err
+	}
+
+	if err := ep.grantRoles(ctx); err != nil {
+		return err
+	}
+
+	statements, err := sessionDefaultStatements(ep.config)
+	if err != nil {
+		return err
+	}
+
+	if len(statements) > 0 {
+		if err := ep.execStatements(ctx, "postgres", statements...); err != nil {
+			return err
+		}
+	}
+
+	if schemaStatements := createSchemaStatements(ep.config); len(schemaStatements) > 0 {
+		if err := ep.execStatements(ctx, ep.config.database, schemaStatements...); err != nil {
+			return err
+		}
+	}
+
+	if ep.config.commonExtensions {
+		unavailable, err := ep.createAvailableExtensions(ctx, ep.config.database, commonExtensions)
+		if err != nil {
+			return err
+		}
+
+		ep.unavailableExtensions = unavailable
+	}
+
+	if err := ep.createExtensions(ctx); err != nil {
+		return err
+	}
+
+	if err := ep.createTempTablespace(ctx); err != nil {
+		return err
+	}
+
+	return ep.createPreloadedExtensions(ctx)
+}
+
+func createSchemaStatements(config Config) []string {
+	owner := config.schemaOwner
+	if owner == "" {
+		owner = config.username
+	}
+
+	statements := make([]string, 0, len(config.schemas))
+	for _, schema := range config.schemas {
+		statements = append(statements, fmt.Sprintf("CREATE SCHEMA IF NOT EXISTS %s AUTHORIZATION %s",
+			pq.QuoteIdentifier(schema),
+			pq.QuoteIdentifier(owner)))
+	}
+
+	return statements
+}
+
+func sessionDefaultStatements(config Config) ([]string, error) {
+	databaseSettings := config.databaseSettings
+
+	if config.clientEncoding != "" {
+		databaseSettings = make(map[string]string, len(config.databaseSettings)+1)
+		for name, value := range config.databaseSettings {
+			databaseSettings[name] = value
+		}
+
+		databaseSettings["client_encoding"] = config.clientEncoding
+	}
+
+	databaseStatements, err := alterSetStatements("DATABASE", config.database, databaseSettings)
+	if err != nil {
+		return nil, err
+	}
+
+	roleStatements, err := alterSetStatements("ROLE", config.username, config.roleSettings)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(databaseStatements, roleStatements...), nil
+}
+
+func alterSetStatements(objectType, name string, settings map[string]string) ([]string, error) {
+	parameters := make([]string, 0, len(settings))
+	for parameter := range settings {
+		parameters = append(parameters, parameter)
+	}
+
+	sort.Strings(parameters)
+
+	statements := make([]string, 0, len(parameters))
+	for _, parameter := range parameters {
+		if !parameterNamePattern.MatchString(parameter) {
+			return nil, fmt.Errorf("invalid runtime parameter name %q", parameter)
+		}
+
+		statements = append(statements, fmt.Sprintf("ALTER %s %s SET %s = %s",
+			objectType,
+			pq.QuoteIdentifier(name),
+			parameter,
+			parameterValueSQL(parameter, settings[parameter])))
+	}
+
+	return statements, nil
+}
@@ -0,0 +1,119 @@
+package embeddedpostgres
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_sessionDefaultStatements(t *testing.T) {
+	statements, err := sessionDefaultStatements(DefaultConfig().
+		Database("app").
+		Username("migrator").
+		DatabaseSettings(map[string]string{
+			"statement_timeout": "5s",
+			"lock_timeout":      "1s",
+		}).
+		RoleSettings(map[string]string{
+			"search_path": "app, public",
+		}))
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		`ALTER DATABASE "app" SET lock_timeout = '1s'`,
+		`ALTER DATABASE "app" SET statement_timeout = '5s'`,
+		`ALTER ROLE "migrator" SET search_path = 'app', 'public'`,
+	}, statements)
+}
+
+func Test_sessionDefaultStatements_ClientEncoding(t *testing.T) {
+	settings := map[string]string{"client_encoding": "UTF8"}
+
+	statements, err := sessionDefaultStatements(DefaultConfig().
+		Database("app").
+		DatabaseSettings(settings).
+		DatabaseClientEncoding("LATIN1"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{`ALTER DATABASE "app" SET client_encoding = 'LATIN1'`}, statements)
+	assert.Equal(t, map[string]string{"client_encoding": "UTF8"}, settings)
+}
+
+func Test_sessionDefaultStatements_ErrorWhenInvalidName(t *testing.T) {
+	_, err := sessionDefaultStatements(DefaultConfig().
+		DatabaseSettings(map[string]string{"statement_timeout = 0; --": "5s"}))
+
+	assert.EqualError(t, err, `invalid runtime parameter name "statement_timeout = 0; --"`)
+}
+
+func Test_createSchemaStatements(t *testing.T) {
+	assert.Empty(t, createSchemaStatements(DefaultConfig()))
+	assert.Equal(t, []string{
+		`CREATE SCHEMA IF NOT EXISTS "app" AUTHORIZATION "postgres"`,
+		`CREATE SCHEMA IF NOT EXISTS "audit" AUTHORIZATION "postgres"`,
+	}, createSchemaStatements(DefaultConfig().Schemas("app", "audit")))
+	assert.Equal(t, []string{
+		`CREATE SCHEMA IF NOT EXISTS "app" AUTHORIZATION "migrator"`,
+	}, createSchemaStatements(DefaultConfig().Schemas("app").SchemaOwner("migrator")))
+}
+
+func Test_Schemas(t *testing.T) {
+	database := NewDatabase(DefaultConfig().
+		Database("app").
+		Schemas("app", "audit"))
+	if err := database.Start(); err != nil {
+		shutdownDBAndFail(t, err, database)
+	}
+
+	db, err := sql.Open("postgres", "host=localhost port=5432 user=postgres password=postgres dbname=app sslmode=disable")
+	if err != nil {
+		shutdownDBAndFail(t, err, database)
+	}
+
+	var count int
+	if err := db.QueryRow("SELECT count(*) FROM information_schema.schemata WHERE schema_name IN ('app', 'audit')").Scan(&count); err != nil {
+		shutdownDBAndFail(t, err, database)
+	}
+
+	assert.Equal(t, 2, count)
+
+	if err := db.Close(); err != nil {
+		shutdownDBAndFail(t, err, database)
+	}
+
+	if err := database.Stop(); err != nil {
+		shutdownDBAndFail(t, err, database)
+	}
+}
+
+func Test_SessionDefaults(t *testing.T) {
+	database := NewDatabase(DefaultConfig().
+		Database("app").
+		DatabaseSettings(map[string]string{"statement_timeout": "5s"}).
+		RoleSettings(map[string]string{"search_path": "app, public"}))
+	if err := database.Start(); err != nil {
+		shutdownDBAndFail(t, err, database)
+	}
+
+	db, err := sql.Open("postgres", "host=localhost port=5432 user=postgres password=postgres dbname=app sslmode=disable")
+	if err != nil {
+		shutdownDBAndFail(t, err, database)
+	}
+
+	var statementTimeout, searchPath string
+	if err := db.QueryRow("SELECT current_setting('statement_timeout'), current_setting('search_path')").Scan(&statementTimeout, &searchPath); err != nil {
+		shutdownDBAndFail(t, err, database)
+	}
+
+	assert.Equal(t, "5s", statementTimeout)
+	assert.Equal(t, "app, public", searchPath)
+
+	if err := db.Close(); err != nil {
+		shutdownDBAndFail(t, err, database)
+	}
+
+	if err := database.Stop(); err != nil {
+		shutdownDBAndFail(t, err, database)
+	}
+}
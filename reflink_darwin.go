@@ -0,0 +1,42 @@
+package embeddedpostgres
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	// sysClonefileat is clonefileat(2), which the syscall package has no constant for.
+	sysClonefileat = 462
+	atFDCWD        = -2
+)
+
+// cloneFile creates dst as a copy-on-write clone of src with clonefile on APFS, failing where the filesystem does not
+// support it.
+func cloneFile(src, dst string, mode os.FileMode) error {
+	srcPtr, err := syscall.BytePtrFromString(src)
+	if err != nil {
+		return err
+	}
+
+	dstPtr, err := syscall.BytePtrFromString(dst)
+	if err != nil {
+		return err
+	}
+
+	// clonefile refuses to replace an existing file
+	if err := os.Remove(dst); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	fd := atFDCWD
+	if _, _, errno := syscall.Syscall6(sysClonefileat,
+		uintptr(fd), uintptr(unsafe.Pointer(srcPtr)),
+		uintptr(fd), uintptr(unsafe.Pointer(dstPtr)),
+		0, 0); errno != 0 {
+		return errno
+	}
+
+	return os.Chmod(dst, mode)
+}
@@ -0,0 +1,39 @@
+package embeddedpostgres
+
+import (
+	"os"
+	"syscall"
+)
+
+// ficlone is the FICLONE ioctl, which shares the extents of one file with another on filesystems supporting reflinks
+// such as btrfs and XFS.
+const ficlone = 0x40049409
+
+// cloneFile creates dst as a copy-on-write clone of src, failing where the filesystem does not support it.
+func cloneFile(src, dst string, mode os.FileMode) (err error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = in.Close()
+	}()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, out.Fd(), ficlone, in.Fd())
+	if closeErr := out.Close(); errno == 0 && closeErr != nil {
+		return closeErr
+	}
+
+	if errno != 0 {
+		_ = os.Remove(dst)
+		return errno
+	}
+
+	return nil
+}
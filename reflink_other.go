@@ -0,0 +1,14 @@
+//go:build !linux && !darwin
+// +build !linux,!darwin
+
+package embeddedpostgres
+
+import (
+	"errors"
+	"os"
+)
+
+// cloneFile is unsupported on this platform, so files are always copied.
+func cloneFile(src, dst string, mode os.FileMode) error {
+	return errors.New("cloning files is not supported on this platform")
+}
@@ -0,0 +1,41 @@
+package embeddedpostgres
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_cloneFile(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	dst := filepath.Join(dir, "dst")
+	require.NoError(t, os.WriteFile(src, []byte("beer"), 0600))
+
+	if err := cloneFile(src, dst, 0640); err != nil {
+		// the filesystem does not support clones, which must leave nothing behind for the copy
+		assert.NoFileExists(t, dst)
+		return
+	}
+
+	assertFileContent(t, dst, "beer")
+
+	info, err := os.Stat(dst)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0640), info.Mode().Perm())
+}
+
+func Test_copyFile(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	dst := filepath.Join(dir, "dst")
+	require.NoError(t, os.WriteFile(src, []byte("beer"), 0600))
+	require.NoError(t, os.WriteFile(dst, []byte("previously copied"), 0600))
+
+	require.NoError(t, copyFile(src, dst, 0600))
+
+	assertFileContent(t, dst, "beer")
+}
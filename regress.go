@@ -0,0 +1,196 @@
+package embeddedpostgres
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// RegressionResult is the outcome of running a single pg_regress style test.
+type RegressionResult struct {
+	// Name is the name of the test, which is the file name of its SQL script without the .sql extension.
+	Name string
+	// Passed is true when the output of the test matched the expected output.
+	Passed bool
+	// ResultPath is the location the actual output of the test was written to.
+	ResultPath string
+	// Diff describes the first difference between the expected and actual output when the test did not pass.
+	Diff string
+}
+
+// RunRegressionTests runs pg_regress style tests against the configured database.
+//
+// Following the pg_regress layout, each test is a SQL script inputDir/sql/<name>.sql that is run through psql with its
+// output written to inputDir/results/<name>.out and compared to inputDir/expected/<name>.out. When no tests are named,
+// every script in inputDir/sql is run in lexical order. psql is taken from the binaries directory, falling back to the
+// PATH when the binaries do not include it.
+func (ep *EmbeddedPostgres) RunRegressionTests(ctx context.Context, inputDir string, tests ...string) ([]RegressionResult, error) {
+	if !ep.started {
+		return nil, errors.New("server has not been started")
+	}
+
+	psql, err := ep.findPsql()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tests) == 0 {
+		if tests, err = discoverRegressionTests(inputDir); err != nil {
+			return nil, err
+		}
+	}
+
+	resultsDir := filepath.Join(inputDir, "results")
+	if err := os.MkdirAll(resultsDir, 0755); err != nil {
+		return nil, fmt.Errorf("unable to create regression results directory %s: %w", resultsDir, err)
+	}
+
+	results := make([]RegressionResult, 0, len(tests))
+
+	for _, test := range tests {
+		result, err := ep.runRegressionTest(ctx, psql, inputDir, test)
+		if err != nil {
+			return results, err
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// RegressionTests runs pg_regress style tests as described by RunRegressionTests, reporting each test as a subtest.
+func (ep *EmbeddedPostgres) RegressionTests(t *testing.T, inputDir string, tests ...string) {
+	t.Helper()
+
+	results, err := ep.RunRegressionTests(context.Background(), inputDir, tests...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, result := range results {
+		result := result
+
+		t.Run(result.Name, func(t *testing.T) {
+			if !result.Passed {
+				t.Errorf("output %s does not match expected output: %s", result.ResultPath, result.Diff)
+			}
+		})
+	}
+}
+
+func (ep *EmbeddedPostgres) findPsql() (string, error) {
+	psql := filepath.Join(ep.config.binariesPath, "bin", "psql")
+	if _, err := exec.LookPath(psql); err == nil {
+		return psql, nil
+	}
+
+	psql, err := exec.LookPath("psql")
+	if err != nil {
+		return "", fmt.Errorf("psql is required to run regression tests but was not found in %s or on the PATH",
+			filepath.Join(ep.config.binariesPath, "bin"))
+	}
+
+	return psql, nil
+}
+
+func (ep *EmbeddedPostgres) runRegressionTest(ctx context.Context, psql, inputDir, test string) (RegressionResult, error) {
+	result := RegressionResult{
+		Name:       test,
+		ResultPath: filepath.Join(inputDir, "results", test+".out"),
+	}
+
+	input, err := os.Open(filepath.Join(inputDir, "sql", test+".sql"))
+	if err != nil {
+		return result, fmt.Errorf("unable to open regression test %s: %w", test, err)
+	}
+
+	defer func() {
+		_ = input.Close()
+	}()
+
+	output := &bytes.Buffer{}
+
+	// the same options and environment pg_regress uses, so the output is deterministic
+	cmd := exec.CommandContext(ctx, psql, "-X", "-a", "-q",
+		"-h", connectionHost(ep.config),
+		"-p", fmt.Sprintf("%d", ep.config.port),
+		"-U", ep.config.username,
+		"-d", ep.config.database)
+	cmd.Env = append(os.Environ(),
+		"PGPASSWORD="+ep.config.password,
+		"PGSSLMODE="+internalSSLMode(ep.config),
+		"PGTZ=PST8PDT",
+		"PGDATESTYLE=Postgres, MDY",
+		"LC_MESSAGES=C")
+	cmd.Stdin = input
+	cmd.Stdout = output
+	cmd.Stderr = output
+
+	// a failing script is reported through its output rather than the exit code, just as pg_regress does
+	if err := cmd.Run(); err != nil && !errors.As(err, new(*exec.ExitError)) {
+		return result, fmt.Errorf("unable to run regression test %s using %s: %w", test, cmd.String(), err)
+	}
+
+	if err := os.WriteFile(result.ResultPath, output.Bytes(), 0644); err != nil {
+		return result, fmt.Errorf("unable to write regression test output %s: %w", result.ResultPath, err)
+	}
+
+	expected, err := os.ReadFile(filepath.Join(inputDir, "expected", test+".out"))
+	if err != nil {
+		return result, fmt.Errorf("unable to read expected output of regression test %s: %w", test, err)
+	}
+
+	result.Diff = firstDifference(string(expected), output.String())
+	result.Passed = result.Diff == ""
+
+	return result, nil
+}
+
+func discoverRegressionTests(inputDir string) ([]string, error) {
+	scripts, err := filepath.Glob(filepath.Join(inputDir, "sql", "*.sql"))
+	if err != nil {
+		return nil, fmt.Errorf("unable to find regression tests in %s: %w", inputDir, err)
+	}
+
+	tests := make([]string, 0, len(scripts))
+	for _, script := range scripts {
+		tests = append(tests, strings.TrimSuffix(filepath.Base(script), ".sql"))
+	}
+
+	sort.Strings(tests)
+
+	return tests, nil
+}
+
+// firstDifference describes the first line that differs between expected and actual, or returns an empty string when
+// they are the same.
+func firstDifference(expected, actual string) string {
+	expectedLines := strings.Split(strings.ReplaceAll(expected, "\r\n", "\n"), "\n")
+	actualLines := strings.Split(strings.ReplaceAll(actual, "\r\n", "\n"), "\n")
+
+	for i := 0; i < len(expectedLines) || i < len(actualLines); i++ {
+		var expectedLine, actualLine string
+
+		if i < len(expectedLines) {
+			expectedLine = expectedLines[i]
+		}
+
+		if i < len(actualLines) {
+			actualLine = actualLines[i]
+		}
+
+		if i >= len(expectedLines) || i >= len(actualLines) || expectedLine != actualLine {
+			return fmt.Sprintf("line %d: expected %q, got %q", i+1, expectedLine, actualLine)
+		}
+	}
+
+	return ""
+}
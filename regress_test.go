@@ -0,0 +1,63 @@
+package embeddedpostgres
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_firstDifference(t *testing.T) {
+	assert.Equal(t, "", firstDifference("a\nb\n", "a\r\nb\r\n"))
+	assert.Equal(t, `line 2: expected "b", got "c"`, firstDifference("a\nb\n", "a\nc\n"))
+	assert.Equal(t, `line 3: expected "", got "d"`, firstDifference("a\nb", "a\nb\nd"))
+}
+
+func Test_RunRegressionTests_ErrorWhenNotStarted(t *testing.T) {
+	database := NewDatabase()
+
+	_, err := database.RunRegressionTests(context.Background(), t.TempDir())
+
+	assert.EqualError(t, err, "server has not been started")
+}
+
+func Test_RunRegressionTests(t *testing.T) {
+	binariesPath := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(binariesPath, "bin"), 0755))
+	// a stand-in for psql that echoes the script, much like psql -a does
+	require.NoError(t, os.WriteFile(filepath.Join(binariesPath, "bin", "psql"), []byte("#!/bin/sh\ncat\n"), 0755))
+
+	inputDir := t.TempDir()
+	for _, dir := range []string{"sql", "expected"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(inputDir, dir), 0755))
+	}
+
+	require.NoError(t, os.WriteFile(filepath.Join(inputDir, "sql", "pass.sql"), []byte("SELECT 1;\n"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(inputDir, "expected", "pass.out"), []byte("SELECT 1;\n"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(inputDir, "sql", "fail.sql"), []byte("SELECT 2;\n"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(inputDir, "expected", "fail.out"), []byte("SELECT 3;\n"), 0600))
+
+	database := NewDatabase(DefaultConfig().BinariesPath(binariesPath))
+	database.started = true
+
+	results, err := database.RunRegressionTests(context.Background(), inputDir)
+	require.NoError(t, err)
+
+	assert.Equal(t, []RegressionResult{
+		{
+			Name:       "fail",
+			Passed:     false,
+			ResultPath: filepath.Join(inputDir, "results", "fail.out"),
+			Diff:       `line 1: expected "SELECT 3;", got "SELECT 2;"`,
+		},
+		{
+			Name:       "pass",
+			Passed:     true,
+			ResultPath: filepath.Join(inputDir, "results", "pass.out"),
+		},
+	}, results)
+	assert.FileExists(t, filepath.Join(inputDir, "results", "pass.out"))
+}
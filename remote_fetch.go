@@ -3,10 +3,12 @@ package embeddedpostgres
 import (
 	"archive/zip"
 	"bytes"
+	"crypto/md5" //nolint:gosec
 	"crypto/sha256"
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"hash"
 	"io"
 	"log"
 	"net/http"
@@ -18,8 +20,16 @@ import (
 // RemoteFetchStrategy provides a strategy to fetch a Postgres binary so that it is available for use.
 type RemoteFetchStrategy func() error
 
-//nolint:funlen
 func defaultRemoteFetchStrategy(remoteFetchHost string, versionStrategy VersionStrategy, cacheLocator CacheLocator) RemoteFetchStrategy {
+	return remoteFetchStrategyWithProgress(remoteFetchHost, versionStrategy, cacheLocator, nil)
+}
+
+// remoteFetchStrategyWithProgress returns the default strategy reporting the progress of the download to progress when
+// not nil.
+//
+//nolint:funlen
+func remoteFetchStrategyWithProgress(remoteFetchHost string, versionStrategy VersionStrategy, cacheLocator CacheLocator,
+	progress func(received, total int64)) RemoteFetchStrategy {
 	return func() error {
 		operatingSystem, architecture, version := versionStrategy()
 
@@ -43,27 +53,98 @@ func defaultRemoteFetchStrategy(remoteFetchHost string, versionStrategy VersionS
 			return fmt.Errorf("no version found matching %s", version)
 		}
 
-		jarBodyBytes, err := io.ReadAll(jarDownloadResponse.Body)
+		var body io.Reader = jarDownloadResponse.Body
+		if progress != nil {
+			progress(0, jarDownloadResponse.ContentLength)
+			body = &progressReader{reader: body, total: jarDownloadResponse.ContentLength, progress: progress}
+		}
+
+		jarBodyBytes, err := io.ReadAll(body)
 		if err != nil {
 			return errorFetchingPostgres(err)
 		}
 
-		shaDownloadURL := fmt.Sprintf("%s.sha256", jarDownloadURL)
-		shaDownloadResponse, err := http.Get(shaDownloadURL)
+		if err := verifyDownload(jarDownloadURL, jarBodyBytes); err != nil {
+			return err
+		}
 
-		defer closeBody(shaDownloadResponse)()
+		return decompressResponse(jarBodyBytes, jarDownloadResponse.ContentLength, cacheLocator, jarDownloadURL)
+	}
+}
 
-		if err == nil && shaDownloadResponse.StatusCode == http.StatusOK {
-			if shaBodyBytes, err := io.ReadAll(shaDownloadResponse.Body); err == nil {
-				jarChecksum := sha256.Sum256(jarBodyBytes)
-				if !bytes.Equal(shaBodyBytes, []byte(hex.EncodeToString(jarChecksum[:]))) {
-					return errors.New("downloaded checksums do not match")
-				}
-			}
+// progressReader reports the bytes read so far to progress on every read.
+type progressReader struct {
+	reader   io.Reader
+	received int64
+	total    int64
+	progress func(received, total int64)
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		r.received += int64(n)
+		r.progress(r.received, r.total)
+	}
+
+	return n, err
+}
+
+// publishedChecksums lists the checksum files a repository may publish alongside an artifact, strongest first.
+var publishedChecksums = []struct {
+	extension string
+	hash      func() hash.Hash
+}{
+	{".sha256", sha256.New},
+	{".md5", md5.New},
+}
+
+// verifyDownload checks the downloaded artifact against the strongest checksum published alongside it, accepting it
+// unchecked only when the repository publishes none, as some proxies do not.
+func verifyDownload(downloadURL string, content []byte) error {
+	for _, checksum := range publishedChecksums {
+		published, ok := fetchChecksum(downloadURL + checksum.extension)
+		if !ok {
+			continue
 		}
 
-		return decompressResponse(jarBodyBytes, jarDownloadResponse.ContentLength, cacheLocator, jarDownloadURL)
+		h := checksum.hash()
+		h.Write(content)
+
+		if !strings.EqualFold(published, hex.EncodeToString(h.Sum(nil))) {
+			return errors.New("downloaded checksums do not match")
+		}
+
+		return nil
+	}
+
+	return nil
+}
+
+// fetchChecksum returns the checksum published at checksumURL, which may be followed by the name of the file.
+func fetchChecksum(checksumURL string) (string, bool) {
+	response, err := http.Get(checksumURL)
+	if err != nil {
+		return "", false
+	}
+
+	defer closeBody(response)()
+
+	if response.StatusCode != http.StatusOK {
+		return "", false
+	}
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return "", false
+	}
+
+	fields := strings.Fields(string(body))
+	if len(fields) == 0 {
+		return "", false
 	}
+
+	return fields[0], true
 }
 
 func closeBody(resp *http.Response) func() {
@@ -141,6 +222,11 @@ func decompressSingleFile(file *zip.File, cacheLocation string) error {
 		return errorExtractingPostgres(err)
 	}
 
+	// the checksum of the archive is recorded so that a cache corrupted later is downloaded again
+	if err := writeArchiveChecksum(cacheLocation, archiveBytes); err != nil {
+		return errorExtractingPostgres(err)
+	}
+
 	// Windows cannot rename a file if is it still open.
 	// The file needs to be manually closed to allow the rename to happen
 	if err := tmp.Close(); err != nil {
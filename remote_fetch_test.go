@@ -2,6 +2,7 @@ package embeddedpostgres
 
 import (
 	"archive/zip"
+	"crypto/md5" //nolint:gosec
 	"crypto/sha256"
 	"encoding/hex"
 	"github.com/stretchr/testify/require"
@@ -77,7 +78,7 @@ func Test_defaultRemoteFetchStrategy_ErrorWhenCannotUnzipSubFile(t *testing.T) {
 
 func Test_defaultRemoteFetchStrategy_ErrorWhenCannotUnzip(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if strings.HasSuffix(r.RequestURI, ".sha256") {
+		if strings.HasSuffix(r.RequestURI, ".sha256") || strings.HasSuffix(r.RequestURI, ".md5") {
 			w.WriteHeader(404)
 			return
 		}
@@ -99,7 +100,7 @@ func Test_defaultRemoteFetchStrategy_ErrorWhenCannotUnzip(t *testing.T) {
 
 func Test_defaultRemoteFetchStrategy_ErrorWhenNoSubTarArchive(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if strings.HasSuffix(r.RequestURI, ".sha256") {
+		if strings.HasSuffix(r.RequestURI, ".sha256") || strings.HasSuffix(r.RequestURI, ".md5") {
 			w.WriteHeader(http.StatusNotFound)
 			return
 		}
@@ -126,7 +127,7 @@ func Test_defaultRemoteFetchStrategy_ErrorWhenCannotExtractSubArchive(t *testing
 	defer cleanUp()
 
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if strings.HasSuffix(r.RequestURI, ".sha256") {
+		if strings.HasSuffix(r.RequestURI, ".sha256") || strings.HasSuffix(r.RequestURI, ".md5") {
 			w.WriteHeader(http.StatusNotFound)
 			return
 		}
@@ -165,7 +166,7 @@ func Test_defaultRemoteFetchStrategy_ErrorWhenCannotCreateCacheDirectory(t *test
 	cacheLocation := filepath.Join(fileBlockingExtractDirectory, "cache_file.jar")
 
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if strings.HasSuffix(r.RequestURI, ".sha256") {
+		if strings.HasSuffix(r.RequestURI, ".sha256") || strings.HasSuffix(r.RequestURI, ".md5") {
 			w.WriteHeader(http.StatusNotFound)
 			return
 		}
@@ -203,7 +204,7 @@ func Test_defaultRemoteFetchStrategy_ErrorWhenCannotCreateSubArchiveFile(t *test
 	}
 
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if strings.HasSuffix(r.RequestURI, ".sha256") {
+		if strings.HasSuffix(r.RequestURI, ".sha256") || strings.HasSuffix(r.RequestURI, ".md5") {
 			w.WriteHeader(http.StatusNotFound)
 			return
 		}
@@ -417,3 +418,83 @@ func Test_defaultRemoteFetchStrategy_whenContentLengthNotSet(t *testing.T) {
 	assert.NoError(t, err)
 	assert.FileExists(t, cacheLocation)
 }
+
+func Test_remoteFetchStrategyWithProgress(t *testing.T) {
+	jarFile, cleanUp := createTempZipArchive()
+	defer cleanUp()
+
+	cacheLocation := filepath.Join(filepath.Dir(jarFile), "extract_location", "cache.jar")
+
+	bytes, err := os.ReadFile(jarFile)
+	require.NoError(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.RequestURI, ".sha256") || strings.HasSuffix(r.RequestURI, ".md5") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		_, _ = w.Write(bytes)
+	}))
+	defer server.Close()
+
+	var received, totals []int64
+
+	remoteFetchStrategy := remoteFetchStrategyWithProgress(server.URL+"/maven2",
+		testVersionStrategy(),
+		func() (s string, b bool) {
+			return cacheLocation, false
+		},
+		func(r, total int64) {
+			received = append(received, r)
+			totals = append(totals, total)
+		})
+
+	require.NoError(t, remoteFetchStrategy())
+
+	require.GreaterOrEqual(t, len(received), 2)
+	assert.Equal(t, int64(0), received[0])
+	assert.Equal(t, int64(len(bytes)), received[len(received)-1])
+
+	for _, total := range totals {
+		assert.Equal(t, int64(len(bytes)), total)
+	}
+}
+
+func Test_defaultRemoteFetchStrategy_MD5(t *testing.T) {
+	jarFile, cleanUp := createTempZipArchive()
+	defer cleanUp()
+
+	cacheLocation := filepath.Join(filepath.Dir(jarFile), "extract_location", "cache.txz")
+
+	bytes, err := os.ReadFile(jarFile)
+	require.NoError(t, err)
+
+	checksum := md5.Sum(bytes) //nolint:gosec
+	published := strings.ToUpper(hex.EncodeToString(checksum[:])) + "  embedded-postgres-binaries.jar\n"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.RequestURI, ".sha256"):
+			w.WriteHeader(http.StatusNotFound)
+		case strings.HasSuffix(r.RequestURI, ".md5"):
+			_, _ = w.Write([]byte(published))
+		default:
+			_, _ = w.Write(bytes)
+		}
+	}))
+	defer server.Close()
+
+	remoteFetchStrategy := defaultRemoteFetchStrategy(server.URL+"/maven2",
+		testVersionStrategy(),
+		func() (s string, b bool) {
+			return cacheLocation, false
+		})
+
+	require.NoError(t, remoteFetchStrategy())
+	assert.FileExists(t, cacheLocation)
+	assert.NoError(t, verifyCachedArchive(cacheLocation))
+
+	published = "d41d8cd98f00b204e9800998ecf8427e"
+	assert.EqualError(t, remoteFetchStrategy(), "downloaded checksums do not match")
+}
@@ -0,0 +1,94 @@
+package embeddedpostgres
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// hasResourceLimits returns true when any limits have been configured for the postgres process.
+func hasResourceLimits(config Config) bool {
+	return config.openFilesLimit > 0 || config.coreFileSizeLimit != nil || config.memoryLimit > 0
+}
+
+// createCgroup creates a cgroup limiting the memory of the server when a memory limit is configured, returning its path.
+func createCgroup(config Config) (string, error) {
+	if config.memoryLimit == 0 {
+		return "", nil
+	}
+
+	if runtime.GOOS != "linux" {
+		return "", errors.New("memory limits are only supported on Linux")
+	}
+
+	if _, err := os.Stat(filepath.Join(config.cgroupParent, "cgroup.controllers")); err != nil {
+		return "", fmt.Errorf("%s is not a cgroup v2 directory: %w", config.cgroupParent, err)
+	}
+
+	cgroup := filepath.Join(config.cgroupParent, fmt.Sprintf("embedded-postgres-%d", config.port))
+
+	// a cgroup left behind by a server that was not stopped is reused
+	if err := os.Mkdir(cgroup, 0755); err != nil && !os.IsExist(err) {
+		return "", fmt.Errorf("unable to create cgroup %s: %w", cgroup, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(cgroup, "memory.max"), []byte(fmt.Sprintf("%d", config.memoryLimit)), 0644); err != nil {
+		_ = os.Remove(cgroup)
+		return "", fmt.Errorf("unable to set memory limit of cgroup %s, check the memory controller is enabled: %w", cgroup, err)
+	}
+
+	return cgroup, nil
+}
+
+// removeCgroup removes a cgroup created by createCgroup, which is only possible once the server has exited.
+func removeCgroup(cgroup string) error {
+	if cgroup == "" {
+		return nil
+	}
+
+	if err := os.Remove(cgroup); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("unable to remove cgroup %s: %w", cgroup, err)
+	}
+
+	return nil
+}
+
+// limitedCommand runs binary through a shell that applies the resource limits and priorities before replacing itself
+// with the binary, so that they apply to it from the start and are inherited by every backend it forks.
+func limitedCommand(config Config, cgroup, binary string, args ...string) *exec.Cmd {
+	setup := append(limitCommands(config, cgroup), priorityCommands(config)...)
+	if len(setup) == 0 {
+		return exec.Command(binary, args...)
+	}
+
+	script := strings.Join(append(setup, `exec "$0" "$@"`), " && ")
+
+	return exec.Command("sh", append([]string{"-c", script, binary}, args...)...)
+}
+
+func limitCommands(config Config, cgroup string) []string {
+	var commands []string
+
+	if config.openFilesLimit > 0 {
+		commands = append(commands, fmt.Sprintf("ulimit -n %d", config.openFilesLimit))
+	}
+
+	if config.coreFileSizeLimit != nil {
+		// POSIX sh measures core files in 512 byte blocks
+		commands = append(commands, fmt.Sprintf("ulimit -c %d", (*config.coreFileSizeLimit+511)/512))
+	}
+
+	if cgroup != "" {
+		commands = append(commands, "echo $$ > "+shellQuote(filepath.Join(cgroup, "cgroup.procs")))
+	}
+
+	return commands
+}
+
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
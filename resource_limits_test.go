@@ -0,0 +1,82 @@
+package embeddedpostgres
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_limitCommands(t *testing.T) {
+	assert.Empty(t, limitCommands(DefaultConfig(), ""))
+	assert.False(t, hasResourceLimits(DefaultConfig()))
+
+	config := DefaultConfig().OpenFilesLimit(256).CoreFileSizeLimit(1000)
+
+	assert.True(t, hasResourceLimits(config))
+	assert.Equal(t, []string{
+		"ulimit -n 256",
+		"ulimit -c 2",
+		"echo $$ > '/sys/fs/cgroup/it'\\''s/cgroup.procs'",
+	}, limitCommands(config, "/sys/fs/cgroup/it's"))
+	assert.Equal(t, []string{"ulimit -c 0"}, limitCommands(DefaultConfig().CoreFileSizeLimit(0), ""))
+}
+
+func Test_limitedCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("resource limits are not supported on Windows")
+	}
+
+	assert.Equal(t, []string{"postgres", "-D", "data"}, limitedCommand(DefaultConfig(), "", "postgres", "-D", "data").Args)
+
+	output, err := limitedCommand(DefaultConfig().OpenFilesLimit(256), "", "sh", "-c", "ulimit -n").Output()
+
+	require.NoError(t, err)
+	assert.Equal(t, "256", strings.TrimSpace(string(output)))
+}
+
+func Test_createCgroup(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("memory limits are only supported on Linux")
+	}
+
+	cgroupParent := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(cgroupParent, "cgroup.controllers"), []byte("memory\n"), 0644))
+
+	cgroup, err := createCgroup(DefaultConfig().Port(9876).MemoryLimit(1<<30, cgroupParent))
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(cgroupParent, "embedded-postgres-9876"), cgroup)
+
+	memoryMax, err := os.ReadFile(filepath.Join(cgroup, "memory.max"))
+	require.NoError(t, err)
+	assert.Equal(t, "1073741824", string(memoryMax))
+
+	// a real cgroup has no files to remove once empty
+	require.NoError(t, os.Remove(filepath.Join(cgroup, "memory.max")))
+	require.NoError(t, removeCgroup(cgroup))
+	assert.NoDirExists(t, cgroup)
+}
+
+func Test_createCgroup_ErrorWhenNotCgroup(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("memory limits are only supported on Linux")
+	}
+
+	cgroupParent := t.TempDir()
+
+	_, err := createCgroup(DefaultConfig().MemoryLimit(1<<30, cgroupParent))
+
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), cgroupParent+" is not a cgroup v2 directory")
+}
+
+func Test_createCgroup_NoMemoryLimit(t *testing.T) {
+	cgroup, err := createCgroup(DefaultConfig())
+
+	assert.NoError(t, err)
+	assert.Empty(t, cgroup)
+}
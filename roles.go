@@ -0,0 +1,100 @@
+package embeddedpostgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// RoleSpec describes a role created on start, such as distinct roles for migrations, the application and read only
+// access.
+type RoleSpec struct {
+	Name string
+	// Password is the password of the role, which is left without one when empty.
+	Password  string
+	Login     bool
+	Superuser bool
+	// Grants are granted to the role within the configured database once databases have been created, each being
+	// what follows GRANT, such as "pg_read_all_data" or "SELECT ON ALL TABLES IN SCHEMA public".
+	Grants []string
+}
+
+// roleStatements returns the statements bringing an existing role in line with spec.
+func roleStatements(spec RoleSpec) []string {
+	options := " NOLOGIN"
+	if spec.Login {
+		options = " LOGIN"
+	}
+
+	if spec.Superuser {
+		options += " SUPERUSER"
+	} else {
+		options += " NOSUPERUSER"
+	}
+
+	if spec.Password != "" {
+		options += " PASSWORD " + pq.QuoteLiteral(spec.Password)
+	}
+
+	return []string{fmt.Sprintf("ALTER ROLE %s WITH%s", pq.QuoteIdentifier(spec.Name), options)}
+}
+
+func grantStatements(spec RoleSpec) []string {
+	statements := make([]string, 0, len(spec.Grants))
+	for _, grant := range spec.Grants {
+		statements = append(statements, fmt.Sprintf("GRANT %s TO %s", grant, pq.QuoteIdentifier(spec.Name)))
+	}
+
+	return statements
+}
+
+// createRoles creates the roles configured with Config.Roles, updating those that already exist so that they match.
+func (ep *EmbeddedPostgres) createRoles(ctx context.Context) (err error) {
+	if len(ep.config.roles) == 0 {
+		return nil
+	}
+
+	db, err := ep.openDB("postgres")
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		err = connectionClose(db, err)
+	}()
+
+	for _, spec := range ep.config.roles {
+		var exists bool
+		if err := db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM pg_roles WHERE rolname = $1)", spec.Name).Scan(&exists); err != nil {
+			return fmt.Errorf("unable to check whether role %s exists: %w", spec.Name, err)
+		}
+
+		statements := roleStatements(spec)
+		if !exists {
+			statements = append([]string{"CREATE ROLE " + pq.QuoteIdentifier(spec.Name)}, statements...)
+		}
+
+		for _, statement := range statements {
+			if _, err := db.ExecContext(ctx, statement); err != nil {
+				return fmt.Errorf("unable to create role %s: %w", spec.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// grantRoles grants the configured roles their privileges within the configured database.
+func (ep *EmbeddedPostgres) grantRoles(ctx context.Context) error {
+	var statements []string
+	for _, spec := range ep.config.roles {
+		statements = append(statements, grantStatements(spec)...)
+	}
+
+	if len(statements) == 0 {
+		return nil
+	}
+
+	return ep.execStatements(ctx, ep.config.database, statements...)
+}
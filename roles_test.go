@@ -0,0 +1,48 @@
+package embeddedpostgres
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_roleStatements(t *testing.T) {
+	assert.Equal(t, []string{`ALTER ROLE "readonly" WITH NOLOGIN NOSUPERUSER`}, roleStatements(RoleSpec{Name: "readonly"}))
+	assert.Equal(t, []string{`ALTER ROLE "migrator" WITH LOGIN SUPERUSER PASSWORD 'it''s secret'`},
+		roleStatements(RoleSpec{Name: "migrator", Password: "it's secret", Login: true, Superuser: true}))
+}
+
+func Test_grantStatements(t *testing.T) {
+	assert.Equal(t, []string{
+		`GRANT readonly TO "app"`,
+		`GRANT SELECT ON ALL TABLES IN SCHEMA public TO "app"`,
+	}, grantStatements(RoleSpec{Name: "app", Grants: []string{"readonly", "SELECT ON ALL TABLES IN SCHEMA public"}}))
+}
+
+func Test_Roles(t *testing.T) {
+	database := NewDatabase(DefaultConfig().
+		RuntimePath(t.TempDir()).
+		Port(0).
+		Roles(
+			RoleSpec{Name: "readonly"},
+			RoleSpec{Name: "app", Password: "app", Login: true, Grants: []string{"readonly"}},
+			RoleSpec{Name: "auditor", Login: true}).
+		DatabaseSpecs(DatabaseSpec{Name: "audit", Owner: "auditor"}))
+	require.NoError(t, database.Start())
+
+	defer func() {
+		assert.NoError(t, database.Stop())
+	}()
+
+	db, err := database.Open("postgres", WithUser("app", "app"))
+	require.NoError(t, err)
+
+	var member bool
+	require.NoError(t, db.QueryRow("SELECT pg_has_role('app', 'readonly', 'MEMBER')").Scan(&member))
+	assert.True(t, member)
+
+	var owner string
+	require.NoError(t, db.QueryRow("SELECT pg_get_userbyid(datdba) FROM pg_database WHERE datname = 'audit'").Scan(&owner))
+	assert.Equal(t, "auditor", owner)
+}
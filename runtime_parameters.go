@@ -0,0 +1,105 @@
+package embeddedpostgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+var parameterNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// listParameters are parameters whose comma separated elements must be quoted individually when set through SQL,
+// otherwise the whole value is treated as a single element.
+var listParameters = map[string]bool{
+	"search_path":               true,
+	"temp_tablespaces":          true,
+	"shared_preload_libraries":  true,
+	"session_preload_libraries": true,
+	"local_preload_libraries":   true,
+}
+
+// SetRuntimeParameter persists a server parameter using ALTER SYSTEM and reloads the server configuration.
+// The returned restartRequired is true when the parameter can only be changed at server start, in which case the new
+// value will not take effect until the Postgres process is restarted.
+func (ep *EmbeddedPostgres) SetRuntimeParameter(name, value string) (restartRequired bool, err error) {
+	pendingRestart, err := ep.ApplySettings(map[string]string{name: value})
+
+	return len(pendingRestart) > 0, err
+}
+
+// ApplySettings persists each of the server parameters using ALTER SYSTEM, then reloads the server configuration once
+// so that they take effect together. The returned pendingRestart lists, in order, the parameters that can only be
+// changed at server start, which will not take effect until the Postgres process is restarted with Restart.
+func (ep *EmbeddedPostgres) ApplySettings(settings map[string]string) (pendingRestart []string, err error) {
+	if !ep.started {
+		return nil, errors.New("server has not been started")
+	}
+
+	names := sortedParameterNames(settings)
+
+	for _, name := range names {
+		if !parameterNamePattern.MatchString(name) {
+			return nil, fmt.Errorf("invalid runtime parameter name %q", name)
+		}
+	}
+
+	db, err := ep.openDB("postgres")
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		err = connectionClose(db, err)
+	}()
+
+	ctx := context.Background()
+
+	for _, name := range names {
+		if _, err := db.ExecContext(ctx, fmt.Sprintf("ALTER SYSTEM SET %s = %s", name, parameterValueSQL(name, settings[name]))); err != nil {
+			return nil, fmt.Errorf("unable to set runtime parameter %s: %w", name, err)
+		}
+	}
+
+	if _, err := db.ExecContext(ctx, "SELECT pg_reload_conf()"); err != nil {
+		return nil, fmt.Errorf("unable to reload configuration: %w", err)
+	}
+
+	for _, name := range names {
+		var parameterContext string
+
+		err := db.QueryRowContext(ctx, "SELECT context FROM pg_settings WHERE name = $1", name).Scan(&parameterContext)
+		if errors.Is(err, sql.ErrNoRows) {
+			// custom parameters such as those defined by extensions are not always present in pg_settings
+			continue
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("unable to read context of runtime parameter %s: %w", name, err)
+		}
+
+		if parameterContext == "postmaster" {
+			pendingRestart = append(pendingRestart, name)
+		}
+	}
+
+	return pendingRestart, nil
+}
+
+// parameterValueSQL renders a parameter value as used in SET, ALTER SYSTEM, ALTER DATABASE and ALTER ROLE statements.
+func parameterValueSQL(name, value string) string {
+	if !listParameters[strings.ToLower(name)] {
+		return pq.QuoteLiteral(value)
+	}
+
+	elements := strings.Split(value, ",")
+	for i, element := range elements {
+		elements[i] = pq.QuoteLiteral(strings.TrimSpace(element))
+	}
+
+	return strings.Join(elements, ", ")
+}
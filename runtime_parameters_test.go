@@ -0,0 +1,106 @@
+package embeddedpostgres
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SetRuntimeParameter_ErrorWhenNotStarted(t *testing.T) {
+	database := NewDatabase()
+
+	_, err := database.SetRuntimeParameter("work_mem", "8MB")
+
+	assert.EqualError(t, err, "server has not been started")
+}
+
+func Test_SetRuntimeParameter_ErrorWhenInvalidName(t *testing.T) {
+	database := NewDatabase()
+	database.started = true
+
+	_, err := database.SetRuntimeParameter("work_mem = '1MB'; DROP TABLE users; --", "8MB")
+
+	assert.EqualError(t, err, `invalid runtime parameter name "work_mem = '1MB'; DROP TABLE users; --"`)
+}
+
+func Test_SetRuntimeParameter(t *testing.T) {
+	database := NewDatabase()
+	if err := database.Start(); err != nil {
+		shutdownDBAndFail(t, err, database)
+	}
+
+	restartRequired, err := database.SetRuntimeParameter("work_mem", "8MB")
+	if err != nil {
+		shutdownDBAndFail(t, err, database)
+	}
+
+	assert.False(t, restartRequired)
+
+	restartRequired, err = database.SetRuntimeParameter("max_connections", "50")
+	if err != nil {
+		shutdownDBAndFail(t, err, database)
+	}
+
+	assert.True(t, restartRequired)
+
+	db, err := sql.Open("postgres", "host=localhost port=5432 user=postgres password=postgres dbname=postgres sslmode=disable")
+	if err != nil {
+		shutdownDBAndFail(t, err, database)
+	}
+
+	var workMem string
+	if err := db.QueryRow("SHOW work_mem").Scan(&workMem); err != nil {
+		shutdownDBAndFail(t, err, database)
+	}
+
+	assert.Equal(t, "8MB", workMem)
+
+	if err := db.Close(); err != nil {
+		shutdownDBAndFail(t, err, database)
+	}
+
+	if err := database.Stop(); err != nil {
+		shutdownDBAndFail(t, err, database)
+	}
+}
+
+func Test_ApplySettings_ErrorWhenInvalidName(t *testing.T) {
+	database := NewDatabase()
+	database.started = true
+
+	_, err := database.ApplySettings(map[string]string{"work_mem": "8MB", "bad name": "1"})
+
+	assert.EqualError(t, err, `invalid runtime parameter name "bad name"`)
+}
+
+func Test_ApplySettings(t *testing.T) {
+	database := NewDatabase(DefaultConfig().RuntimePath(t.TempDir()).Port(0))
+	require.NoError(t, database.Start())
+
+	defer func() {
+		assert.NoError(t, database.Stop())
+	}()
+
+	pendingRestart, err := database.ApplySettings(map[string]string{
+		"work_mem":        "8MB",
+		"shared_buffers":  "32MB",
+		"max_connections": "50",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"max_connections", "shared_buffers"}, pendingRestart)
+
+	db, err := database.Open("postgres")
+	require.NoError(t, err)
+
+	var workMem string
+	require.NoError(t, db.QueryRow("SHOW work_mem").Scan(&workMem))
+	assert.Equal(t, "8MB", workMem)
+}
+
+func Test_parameterValueSQL(t *testing.T) {
+	assert.Equal(t, `'8MB'`, parameterValueSQL("work_mem", "8MB"))
+	assert.Equal(t, `'%m [%p], it''s '`, parameterValueSQL("log_line_prefix", "%m [%p], it's "))
+	assert.Equal(t, `'app', '$user', 'public'`, parameterValueSQL("search_path", "app, $user,public"))
+}
@@ -0,0 +1,124 @@
+package embeddedpostgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const seedsTable = "embedded_postgres_seeds"
+
+// seedFiles returns the .sql files within dir and its subdirectories in lexical order of their paths relative to dir,
+// which are how applied files are recorded.
+func seedFiles(dir string) ([]string, error) {
+	var files []string
+
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
+			return nil
+		}
+
+		relative, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		files = append(files, filepath.ToSlash(relative))
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to read seed directory %s: %w", dir, err)
+	}
+
+	return files, nil
+}
+
+// Seed applies the .sql files within dir to the configured database in lexical order, each within its own
+// transaction. Applied files are recorded in the embedded_postgres_seeds table, so only files added since are applied
+// when called again, including against a reused data directory. It is run on every start for Config.SeedDirectory.
+func (ep *EmbeddedPostgres) Seed(ctx context.Context, dir string) (err error) {
+	if !ep.isStarted() {
+		return errors.New("server has not been started")
+	}
+
+	files, err := seedFiles(dir)
+	if err != nil {
+		return err
+	}
+
+	db, err := ep.openDB(ep.config.database)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		err = connectionClose(db, err)
+	}()
+
+	if _, err := db.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS "+seedsTable+
+		" (file text PRIMARY KEY, applied_at timestamptz NOT NULL DEFAULT now())"); err != nil {
+		return fmt.Errorf("unable to create %s: %w", seedsTable, err)
+	}
+
+	for _, file := range files {
+		if err := applySeedFile(ctx, db, dir, file); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func applySeedFile(ctx context.Context, db *sql.DB, dir, file string) (err error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("unable to apply seed %s: %w", file, err)
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	// the row lock stops concurrent seeding applying the same file twice
+	result, err := tx.ExecContext(ctx, "INSERT INTO "+seedsTable+" (file) VALUES ($1) ON CONFLICT DO NOTHING", file)
+	if err != nil {
+		return fmt.Errorf("unable to record seed %s: %w", file, err)
+	}
+
+	applied, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("unable to record seed %s: %w", file, err)
+	}
+
+	if applied == 0 {
+		// applied by a previous start
+		return tx.Rollback()
+	}
+
+	script, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(file)))
+	if err != nil {
+		return fmt.Errorf("unable to read seed %s: %w", file, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, string(script)); err != nil {
+		return fmt.Errorf("unable to apply seed %s: %w", file, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("unable to apply seed %s: %w", file, err)
+	}
+
+	return nil
+}
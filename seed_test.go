@@ -0,0 +1,65 @@
+package embeddedpostgres
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_seedFiles(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "02-fixtures"), 0700))
+
+	for _, name := range []string{"03-late.sql", "01-schema.sql", "02-fixtures/b.sql", "02-fixtures/a.sql", "notes.txt"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), nil, 0600))
+	}
+
+	files, err := seedFiles(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"01-schema.sql", "02-fixtures/a.sql", "02-fixtures/b.sql", "03-late.sql"}, files)
+}
+
+func Test_Seed_ErrorWhenNotStarted(t *testing.T) {
+	assert.EqualError(t, NewDatabase().Seed(context.Background(), t.TempDir()), "server has not been started")
+}
+
+func Test_SeedDirectory(t *testing.T) {
+	seeds := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(seeds, "01-schema.sql"), []byte("CREATE TABLE brews (name text);"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(seeds, "02-stout.sql"), []byte("INSERT INTO brews VALUES ('stout');"), 0600))
+
+	config := DefaultConfig().
+		RuntimePath(t.TempDir()).
+		DataPath(filepath.Join(t.TempDir(), "data")).
+		Port(0).
+		SeedDirectory(seeds)
+
+	database := NewDatabase(config)
+	require.NoError(t, database.Start())
+	assert.Equal(t, []string{"stout"}, brews(t, database))
+	require.NoError(t, database.Stop())
+
+	// only the file added since is applied to the reused data directory
+	require.NoError(t, os.WriteFile(filepath.Join(seeds, "03-lager.sql"), []byte("INSERT INTO brews VALUES ('lager');"), 0600))
+
+	database = NewDatabase(config)
+	require.NoError(t, database.Start())
+
+	defer func() {
+		assert.NoError(t, database.Stop())
+	}()
+
+	assert.Equal(t, []string{"lager", "stout"}, brews(t, database))
+
+	// a failing file is rolled back and not recorded
+	require.NoError(t, os.WriteFile(filepath.Join(seeds, "04-broken.sql"), []byte("INSERT INTO brews VALUES ('porter'); SELECT broken;"), 0600))
+
+	err := database.Seed(context.Background(), seeds)
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "unable to apply seed 04-broken.sql")
+	assert.Equal(t, []string{"lager", "stout"}, brews(t, database))
+}
@@ -0,0 +1,88 @@
+package embeddedpostgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrVersionMismatch is returned by ServerVersion when the running server is not the configured Postgres version, for
+// example when BinariesPath points at binaries of a different version.
+var ErrVersionMismatch = errors.New("server version does not match configured version")
+
+// ServerVersion describes the build of a running Postgres server.
+type ServerVersion struct {
+	// Version is the server_version setting, for example 15.3.
+	Version string
+	// VersionNum is the server_version_num setting, for example 150003.
+	VersionNum int
+	// Description is the output of version(), including the platform and compiler.
+	Description string
+	// CompileOptions holds the build options reported by the pg_config view, such as CONFIGURE and CFLAGS.
+	CompileOptions map[string]string
+}
+
+// ServerVersion queries the version and compile options of the running server.
+// If the server does not match the configured version the ServerVersion is returned along with an error wrapping
+// ErrVersionMismatch.
+func (ep *EmbeddedPostgres) ServerVersion(ctx context.Context) (version ServerVersion, err error) {
+	if !ep.started {
+		return version, errors.New("server has not been started")
+	}
+
+	db, err := ep.openDB(ep.config.database)
+	if err != nil {
+		return version, err
+	}
+
+	defer func() {
+		err = connectionClose(db, err)
+	}()
+
+	if err := db.QueryRowContext(ctx, "SELECT current_setting('server_version'), current_setting('server_version_num')::int, version()").
+		Scan(&version.Version, &version.VersionNum, &version.Description); err != nil {
+		return version, fmt.Errorf("unable to query server version: %w", err)
+	}
+
+	rows, err := db.QueryContext(ctx, "SELECT name, setting FROM pg_config")
+	if err != nil {
+		return version, fmt.Errorf("unable to query compile options: %w", err)
+	}
+
+	version.CompileOptions = map[string]string{}
+
+	for rows.Next() {
+		var name, setting string
+		if err := rows.Scan(&name, &setting); err != nil {
+			_ = rows.Close()
+			return version, fmt.Errorf("unable to query compile options: %w", err)
+		}
+
+		version.CompileOptions[name] = setting
+	}
+
+	if err := rows.Close(); err != nil {
+		return version, fmt.Errorf("unable to query compile options: %w", err)
+	}
+
+	if expected, ok := versionNum(ep.config.version); ok && expected != version.VersionNum {
+		return version, fmt.Errorf("%w: running %s, configured %s", ErrVersionMismatch, version.Version, ep.config.version)
+	}
+
+	return version, nil
+}
+
+// versionNum converts a configured version into the server_version_num the server reports for it.
+// Since Postgres 10 the version number only has a major and minor component, so 15.3.0 is 150003 and 9.6.24 is 90624.
+func versionNum(version PostgresVersion) (int, bool) {
+	var major, minor, patch int
+	if _, err := fmt.Sscanf(string(version), "%d.%d.%d", &major, &minor, &patch); err != nil {
+		return 0, false
+	}
+
+	if major >= 10 {
+		return major*10000 + minor, true
+	}
+
+	return major*10000 + minor*100 + patch, true
+}
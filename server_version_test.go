@@ -0,0 +1,65 @@
+package embeddedpostgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_versionNum(t *testing.T) {
+	tests := []struct {
+		version  PostgresVersion
+		expected int
+		ok       bool
+	}{
+		{V15, 150003, true},
+		{V10, 100023, true},
+		{V9, 90624, true},
+		{"latest", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.version), func(t *testing.T) {
+			num, ok := versionNum(tt.version)
+
+			assert.Equal(t, tt.expected, num)
+			assert.Equal(t, tt.ok, ok)
+		})
+	}
+}
+
+func Test_ServerVersion_ErrorWhenNotStarted(t *testing.T) {
+	database := NewDatabase()
+
+	_, err := database.ServerVersion(context.Background())
+
+	assert.EqualError(t, err, "server has not been started")
+}
+
+func Test_ServerVersion(t *testing.T) {
+	database := NewDatabase(DefaultConfig().Version(V15))
+	if err := database.Start(); err != nil {
+		shutdownDBAndFail(t, err, database)
+	}
+
+	version, err := database.ServerVersion(context.Background())
+	if err != nil {
+		shutdownDBAndFail(t, err, database)
+	}
+
+	assert.Equal(t, "15.3", version.Version)
+	assert.Equal(t, 150003, version.VersionNum)
+	assert.Contains(t, version.Description, "PostgreSQL 15.3")
+	assert.NotEmpty(t, version.CompileOptions["CONFIGURE"])
+
+	database.config.version = V14
+
+	_, err = database.ServerVersion(context.Background())
+	assert.True(t, errors.Is(err, ErrVersionMismatch))
+
+	if err := database.Stop(); err != nil {
+		shutdownDBAndFail(t, err, database)
+	}
+}
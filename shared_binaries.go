@@ -0,0 +1,62 @@
+package embeddedpostgres
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// sharedBinariesPath returns the directory binaries are shared through with Config.SharedBinaries, named after the
+// archive at cacheLocation and any extension archives extracted alongside it, within ExecDir when set or otherwise
+// alongside the cache.
+func sharedBinariesPath(config Config, cacheLocation string) string {
+	name := strings.TrimSuffix(filepath.Base(cacheLocation), filepath.Ext(cacheLocation))
+
+	if len(config.extensionArchives) > 0 {
+		hash := sha256.New()
+
+		for _, archive := range config.extensionArchives {
+			_, _ = fmt.Fprintf(hash, "%s\x00", archive)
+
+			// an archive replaced in place is extracted afresh
+			if info, err := os.Stat(archive); err == nil {
+				_, _ = fmt.Fprintf(hash, "%d\x00%d\x00", info.Size(), info.ModTime().UnixNano())
+			}
+		}
+
+		name += "-" + hex.EncodeToString(hash.Sum(nil))[:12]
+	}
+
+	dir := filepath.Dir(cacheLocation)
+	if config.execDir != "" {
+		dir = config.execDir
+	}
+
+	return filepath.Join(dir, "binaries", name)
+}
+
+// publishSharedBinaries moves binaries extracted into temp into place at target, unless another process has already
+// done so, so that binaries are never seen partially extracted.
+func publishSharedBinaries(temp, target, archive string, extensions []string) error {
+	if err := os.Rename(temp, target); err == nil {
+		return nil
+	}
+
+	if _, ok := binariesExtracted(target, archive, extensions); ok {
+		return nil
+	}
+
+	// left incomplete by a process that was interrupted whilst extracting
+	if err := os.RemoveAll(target); err != nil {
+		return fmt.Errorf("unable to move extracted binaries into %s: %w", target, err)
+	}
+
+	if err := os.Rename(temp, target); err != nil {
+		return fmt.Errorf("unable to move extracted binaries into %s: %w", target, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,77 @@
+package embeddedpostgres
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_sharedBinariesPath(t *testing.T) {
+	cacheLocation := filepath.Join("cache", "embedded-postgres-binaries-linux-amd64-15.3.0.txz")
+
+	assert.Equal(t, filepath.Join("cache", "binaries", "embedded-postgres-binaries-linux-amd64-15.3.0"),
+		sharedBinariesPath(DefaultConfig(), cacheLocation))
+
+	assert.Equal(t, filepath.Join("exec", "binaries", "embedded-postgres-binaries-linux-amd64-15.3.0"),
+		sharedBinariesPath(DefaultConfig().ExecDir("exec"), cacheLocation))
+
+	withExtensions := sharedBinariesPath(DefaultConfig().ExtensionArchives("timescaledb.txz"), cacheLocation)
+	assert.True(t, strings.HasPrefix(filepath.Base(withExtensions), "embedded-postgres-binaries-linux-amd64-15.3.0-"))
+	assert.NotEqual(t, withExtensions, sharedBinariesPath(DefaultConfig().ExtensionArchives("postgis.txz"), cacheLocation))
+}
+
+func Test_publishSharedBinaries(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "binaries")
+
+	temp := extractedBinaries(t, "cache.txz")
+	require.NoError(t, publishSharedBinaries(temp, target, "cache.txz", nil))
+	assert.DirExists(t, filepath.Join(target, "bin"))
+	assert.NoDirExists(t, temp)
+
+	// already published by another process
+	other := extractedBinaries(t, "cache.txz")
+	require.NoError(t, os.WriteFile(filepath.Join(other, "bin", "other"), nil, 0600))
+	require.NoError(t, publishSharedBinaries(other, target, "cache.txz", nil))
+	assert.NoFileExists(t, filepath.Join(target, "bin", "other"))
+
+	// left incomplete
+	require.NoError(t, os.Remove(filepath.Join(target, binariesMarkerFile)))
+	require.NoError(t, publishSharedBinaries(other, target, "cache.txz", nil))
+	assert.FileExists(t, filepath.Join(target, "bin", "other"))
+}
+
+func Test_downloadAndExtractBinary_SharedBinaries(t *testing.T) {
+	archive, cleanUp := createTempXzArchive()
+	defer cleanUp()
+
+	binariesPath := filepath.Join(t.TempDir(), "binaries", "shared")
+
+	database := NewDatabase(DefaultConfig().SharedBinaries(true))
+	database.config.binariesPath = binariesPath
+	database.sharedBinaries = true
+
+	require.NoError(t, database.downloadAndExtractBinary(true, archive))
+
+	assert.FileExists(t, filepath.Join(binariesPath, "dir1", "dir2", "some_content"))
+	assert.FileExists(t, filepath.Join(binariesPath, binariesMarkerFile))
+
+	entries, err := os.ReadDir(filepath.Dir(binariesPath))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func Test_SharedBinaries(t *testing.T) {
+	config := DefaultConfig().SharedBinaries(true)
+
+	first := StartForTest(t, config)
+	second := StartForTest(t, config)
+
+	assert.Equal(t, first.config.binariesPath, second.config.binariesPath)
+	assert.NotEqual(t, first.config.runtimePath, first.config.binariesPath)
+	assert.NoDirExists(t, filepath.Join(first.config.runtimePath, "bin"))
+}
@@ -0,0 +1,84 @@
+//go:build go1.21
+// +build go1.21
+
+package embeddedpostgres
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+// Slog sends the output of postgres, initdb and the other Postgres tools to logger a line at a time, rather than to
+// the writer given with Logger. Server log lines are logged at the level of their severity, with ERROR, FATAL and
+// PANIC at error level, and the lines that follow them such as DETAIL and STATEMENT at the same level. Other output is
+// logged at info level. Problems the library runs into that cannot be returned as errors are logged at warning level.
+func (c Config) Slog(logger *slog.Logger) Config {
+	c.logger = &slogWriter{logger: logger, level: slog.LevelInfo}
+	c.diagnostic = func(message string) {
+		logger.Warn(strings.TrimSpace(message), "source", "embedded-postgres")
+	}
+
+	return c
+}
+
+// slogWriter logs the lines written to it, holding back any partial line until it is completed.
+type slogWriter struct {
+	mu      sync.Mutex
+	logger  *slog.Logger
+	partial []byte
+	level   slog.Level
+}
+
+func (w *slogWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.partial = append(w.partial, p...)
+
+	for {
+		end := bytes.IndexByte(w.partial, '\n')
+		if end < 0 {
+			break
+		}
+
+		line := strings.TrimRight(string(w.partial[:end]), "\r")
+		w.partial = w.partial[end+1:]
+
+		if line != "" {
+			w.log(line)
+		}
+	}
+
+	return len(p), nil
+}
+
+func (w *slogWriter) log(line string) {
+	severity, message, ok := parseServerLogLine(line)
+	if !ok {
+		w.logger.Info(line, "source", "postgres")
+		return
+	}
+
+	if severity, ok := logSeverity(severity); ok {
+		w.level = slogLevel(severity)
+	}
+
+	w.logger.Log(context.Background(), w.level, message, "source", "postgres", "severity", severity)
+}
+
+// slogLevel returns the slog level for a severity.
+func slogLevel(severity LogSeverity) slog.Level {
+	switch {
+	case severity >= LogSeverityError:
+		return slog.LevelError
+	case severity == LogSeverityWarning:
+		return slog.LevelWarn
+	case severity == LogSeverityDebug:
+		return slog.LevelDebug
+	default:
+		return slog.LevelInfo
+	}
+}
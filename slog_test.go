@@ -0,0 +1,56 @@
+//go:build go1.21
+// +build go1.21
+
+package embeddedpostgres
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_slogWriter(t *testing.T) {
+	var out bytes.Buffer
+
+	logger := slog.New(slog.NewTextHandler(&out, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+
+			return a
+		},
+	}))
+	writer := &slogWriter{logger: logger, level: slog.LevelInfo}
+
+	_, err := writer.Write([]byte("The files belonging to this database system will be owned by user \"postgres\".\n" +
+		"2022-03-01 12:00:00.000 UTC [42] ERROR:  relation \"beer\" does not exist at character 15\n" +
+		"2022-03-01 12:00:00.000 UTC [42] STATEMENT:  SELECT * FROM beer\n" +
+		"2022-03-01 12:00:00.000 UTC [42] WARNING:  there is no transaction in progress\n" +
+		"2022-03-01 12:00:00.000 UTC [7] DEBUG2:  checkpoint"))
+	require.NoError(t, err)
+
+	_, err = writer.Write([]byte("er starting\n"))
+	require.NoError(t, err)
+
+	assert.Equal(t, `level=INFO msg="The files belonging to this database system will be owned by user \"postgres\"." source=postgres
+level=ERROR msg="relation \"beer\" does not exist at character 15" source=postgres severity=ERROR
+level=ERROR msg="SELECT * FROM beer" source=postgres severity=STATEMENT
+level=WARN msg="there is no transaction in progress" source=postgres severity=WARNING
+level=DEBUG msg="checkpointer starting" source=postgres severity=DEBUG2
+`, out.String())
+}
+
+func Test_Slog(t *testing.T) {
+	var out bytes.Buffer
+
+	config := DefaultConfig().Slog(slog.New(slog.NewTextHandler(&out, nil)))
+	config.diagnostic("unable to stop postgres: exit status 1\n")
+
+	assert.IsType(t, &slogWriter{}, config.logger)
+	assert.Contains(t, out.String(), `level=WARN msg="unable to stop postgres: exit status 1" source=embedded-postgres`)
+}
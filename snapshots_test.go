@@ -0,0 +1,72 @@
+package embeddedpostgres
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Snapshot_InvalidName(t *testing.T) {
+	database := NewDatabase()
+
+	for _, name := range []string{"", ".", "..", "a/b", `a\b`} {
+		assert.EqualError(t, database.Snapshot(name), fmt.Sprintf("invalid snapshot name %q", name))
+		assert.EqualError(t, database.Restore(name), fmt.Sprintf("invalid snapshot name %q", name))
+	}
+}
+
+func Test_Snapshot_NotInitialised(t *testing.T) {
+	database := NewDatabase(DefaultConfig().DataPath(t.TempDir()))
+
+	assert.EqualError(t, database.Snapshot("seeded"), "data directory has not been initialised, call Start first")
+}
+
+func Test_Restore_NoSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	database := NewDatabase(DefaultConfig().SnapshotPath(dir))
+
+	err := database.Restore("seeded")
+
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "no snapshot seeded found in "+filepath.Join(dir, "seeded"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func Test_SnapshotAndRestore(t *testing.T) {
+	snapshots := t.TempDir()
+	database := StartForTest(t, DefaultConfig().SnapshotPath(snapshots))
+
+	db, err := database.Open("postgres")
+	require.NoError(t, err)
+
+	_, err = db.Exec("CREATE TABLE beer (name text); INSERT INTO beer VALUES ('stout')")
+	require.NoError(t, err)
+
+	require.NoError(t, database.Snapshot("seeded"))
+	assert.True(t, database.isStarted())
+	assert.DirExists(t, filepath.Join(snapshots, "seeded", "data"))
+
+	db, err = database.Open("postgres")
+	require.NoError(t, err)
+
+	_, err = db.Exec("INSERT INTO beer VALUES ('lager')")
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		require.NoError(t, database.Restore("seeded"))
+
+		db, err = database.Open("postgres")
+		require.NoError(t, err)
+
+		var count int
+		require.NoError(t, db.QueryRow("SELECT count(*) FROM beer").Scan(&count))
+		assert.Equal(t, 1, count)
+
+		_, err = db.Exec("INSERT INTO beer VALUES ('porter')")
+		require.NoError(t, err)
+	}
+}
@@ -0,0 +1,59 @@
+package embeddedpostgres
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+const socketConfSnippet = "00-socket"
+
+// socketDirectory returns the directory holding the Unix-domain socket configured with Config.UnixSocketOnly, which
+// defaults to the runtime directory.
+func socketDirectory(config Config) string {
+	if config.socketDir != "" {
+		return config.socketDir
+	}
+
+	return config.runtimePath
+}
+
+// connectionHost returns the host that clients connect to, which is the socket directory when TCP is disabled.
+func connectionHost(config Config) string {
+	if config.socketOnly {
+		return socketDirectory(config)
+	}
+
+	return "localhost"
+}
+
+func checkSocketOnly(config Config, goos string) error {
+	if config.socketOnly && goos == "windows" {
+		return errors.New("listening only on a Unix-domain socket is not supported on Windows")
+	}
+
+	return nil
+}
+
+// socketSettings stops the server listening on TCP, leaving only the Unix-domain socket in the socket directory.
+func socketSettings(config Config) map[string]string {
+	if !config.socketOnly {
+		return nil
+	}
+
+	return map[string]string{
+		"listen_addresses":        "",
+		"unix_socket_directories": socketDirectory(config),
+	}
+}
+
+// writeSocketConf creates the socket directory and writes the settings listening on it alone.
+func (ep *EmbeddedPostgres) writeSocketConf() error {
+	if ep.config.socketOnly {
+		if err := os.MkdirAll(socketDirectory(ep.config), 0700); err != nil {
+			return fmt.Errorf("unable to create socket directory %s: %w", socketDirectory(ep.config), err)
+		}
+	}
+
+	return ep.writeManagedConfSnippet(socketConfSnippet, socketSettings(ep.config))
+}
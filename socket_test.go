@@ -0,0 +1,90 @@
+package embeddedpostgres
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_socketSettings(t *testing.T) {
+	assert.Nil(t, socketSettings(DefaultConfig()))
+
+	assert.Equal(t, map[string]string{
+		"listen_addresses":        "",
+		"unix_socket_directories": "/tmp/pg",
+	}, socketSettings(DefaultConfig().UnixSocketOnly("/tmp/pg")))
+
+	assert.Equal(t, "/runtime", socketSettings(DefaultConfig().RuntimePath("/runtime").UnixSocketOnly(""))["unix_socket_directories"])
+}
+
+func Test_connectionHost(t *testing.T) {
+	assert.Equal(t, "localhost", connectionHost(DefaultConfig()))
+	assert.Equal(t, "/tmp/pg", connectionHost(DefaultConfig().UnixSocketOnly("/tmp/pg")))
+}
+
+func Test_checkSocketOnly(t *testing.T) {
+	assert.EqualError(t, checkSocketOnly(DefaultConfig().UnixSocketOnly("/tmp/pg"), "windows"),
+		"listening only on a Unix-domain socket is not supported on Windows")
+	assert.NoError(t, checkSocketOnly(DefaultConfig().UnixSocketOnly("/tmp/pg"), "linux"))
+	assert.NoError(t, checkSocketOnly(DefaultConfig(), "windows"))
+}
+
+func Test_UnixSocketOnly_DefaultsPort(t *testing.T) {
+	database := NewDatabase(DefaultConfig().Port(0).UnixSocketOnly("/tmp/pg"))
+
+	assert.Equal(t, uint32(5432), database.Port())
+	assert.Equal(t, "postgresql://postgres:postgres@/postgres?host=%2Ftmp%2Fpg&port=5432&sslmode=disable", database.ConnectionString())
+	assert.Equal(t, "host=/tmp/pg port=5432 user=postgres password=postgres dbname=postgres sslmode=disable",
+		database.ConnectionString(WithKeywordValueFormat()))
+}
+
+func Test_UnixSocketOnly(t *testing.T) {
+	// the port is in use, which does not matter without TCP
+	listener, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+
+	defer func() {
+		assert.NoError(t, listener.Close())
+	}()
+
+	database := NewDatabase(DefaultConfig().
+		RuntimePath(t.TempDir()).
+		Port(uint32(listener.Addr().(*net.TCPAddr).Port)).
+		Database("beer").
+		UnixSocketOnly(t.TempDir()))
+	require.NoError(t, database.Start())
+
+	defer func() {
+		assert.NoError(t, database.Stop())
+	}()
+
+	db, err := database.Open("postgres")
+	require.NoError(t, err)
+
+	var listenAddresses string
+	require.NoError(t, db.QueryRow("SHOW listen_addresses").Scan(&listenAddresses))
+	assert.Equal(t, "", listenAddresses)
+}
+
+func Test_UnixSocketOnly_CreateDatabaseStrategy(t *testing.T) {
+	var created string
+
+	database := NewDatabase(DefaultConfig().
+		RuntimePath(t.TempDir()).
+		Database("beer").
+		UnixSocketOnly(t.TempDir()).
+		CreateDatabaseStrategy(func(ctx context.Context, port uint32, username, password, database string, options []string) error {
+			created = database
+			return DefaultCreateDatabase(ctx, port, username, password, database, options)
+		}))
+	require.NoError(t, database.Start())
+
+	defer func() {
+		assert.NoError(t, database.Stop())
+	}()
+
+	assert.Equal(t, "beer", created)
+}
@@ -0,0 +1,90 @@
+package embeddedpostgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+)
+
+// standbyInitDatabase returns an InitDatabaseStrategy that copies the data directory of the primary with
+// pg_basebackup in place of running initdb, configuring the copy to stream changes from the primary.
+func standbyInitDatabase(primaryConnInfo string, faults faultHooks) InitDatabaseStrategy {
+	return func(binaryExtractLocation, runtimePath, pgDataDir, username, password, locale string, extraArgs []string, logger *os.File) error {
+		args := []string{
+			"-D", pgDataDir,
+			"-d", primaryConnInfo,
+			"-X", "stream",
+			"--checkpoint=fast",
+			// writes primary_conninfo and marks the copy as a standby in the format of the server's version
+			"-R",
+		}
+
+		baseBackupProcess := exec.Command(filepath.Join(binaryExtractLocation, "bin/pg_basebackup"), args...)
+		baseBackupProcess.Stderr = logger
+		baseBackupProcess.Stdout = logger
+
+		err := faults.checkCommand(baseBackupProcess)
+		if err == nil {
+			err = baseBackupProcess.Run()
+		}
+
+		if err != nil {
+			logContent, readLogsErr := readLogsOrTimeout(logger) // we want to preserve the original error
+			if readLogsErr != nil {
+				logContent = []byte(string(logContent) + " - " + readLogsErr.Error())
+			}
+
+			return fmt.Errorf("unable to copy primary using '%s': %w\n%s", baseBackupProcess.String(), err, string(logContent))
+		}
+
+		return nil
+	}
+}
+
+// StandbyLag returns how far the standby configured with Config.StandbyOf is behind its primary, measured as the time
+// since the last transaction it replayed was committed on the primary. It is zero when all WAL received has been
+// replayed.
+func (ep *EmbeddedPostgres) StandbyLag(ctx context.Context) (lag time.Duration, err error) {
+	if !ep.isStarted() {
+		return 0, errors.New("server has not been started")
+	}
+
+	if ep.config.standbyOf == "" {
+		return 0, errors.New("server is not a standby, configure it with Config.StandbyOf")
+	}
+
+	db, err := ep.openDB("postgres")
+	if err != nil {
+		return 0, err
+	}
+
+	defer func() {
+		err = connectionClose(db, err)
+	}()
+
+	// the WAL location functions were renamed from xlog in Postgres 10
+	receiveLSN, replayLSN := "pg_last_wal_receive_lsn()", "pg_last_wal_replay_lsn()"
+	if majorVersion(ep.config.version) < 10 {
+		receiveLSN, replayLSN = "pg_last_xlog_receive_location()", "pg_last_xlog_replay_location()"
+	}
+
+	var seconds sql.NullFloat64
+
+	err = db.QueryRowContext(ctx, fmt.Sprintf(`SELECT CASE WHEN %s = %s THEN 0
+		ELSE EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()) END`, receiveLSN, replayLSN)).Scan(&seconds)
+	if err != nil {
+		return 0, fmt.Errorf("unable to read standby lag: %w", err)
+	}
+
+	if !seconds.Valid {
+		// nothing has been replayed since the standby started
+		return 0, nil
+	}
+
+	return time.Duration(seconds.Float64 * float64(time.Second)), nil
+}
@@ -0,0 +1,110 @@
+package embeddedpostgres
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_standbyInitDatabase(t *testing.T) {
+	binariesPath := t.TempDir()
+	argsFile := filepath.Join(t.TempDir(), "args")
+	require.NoError(t, os.MkdirAll(filepath.Join(binariesPath, "bin"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(binariesPath, "bin", "pg_basebackup"),
+		[]byte("#!/bin/sh\necho \"$@\" > "+argsFile+"\n"), 0755))
+
+	logFile, err := os.CreateTemp(t.TempDir(), "log")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = logFile.Close()
+	}()
+
+	initDB := standbyInitDatabase("host=primary port=5432 user=replicator", faultHooks{})
+
+	require.NoError(t, initDB(binariesPath, t.TempDir(), "/data", "postgres", "postgres", "", nil, logFile))
+
+	args, err := os.ReadFile(argsFile)
+	require.NoError(t, err)
+	assert.Equal(t, "-D /data -d host=primary port=5432 user=replicator -X stream --checkpoint=fast -R\n", string(args))
+}
+
+func Test_standbyInitDatabase_ErrorWhenBaseBackupFails(t *testing.T) {
+	binariesPath := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(binariesPath, "bin"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(binariesPath, "bin", "pg_basebackup"),
+		[]byte("#!/bin/sh\necho 'could not connect to server' >&2\nexit 1\n"), 0755))
+
+	logFile, err := os.CreateTemp(t.TempDir(), "log")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = logFile.Close()
+	}()
+
+	initDB := standbyInitDatabase("host=primary", faultHooks{})
+
+	err = initDB(binariesPath, t.TempDir(), "/data", "postgres", "postgres", "", nil, logFile)
+
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "unable to copy primary using")
+	assert.Contains(t, err.Error(), "could not connect to server")
+}
+
+func Test_StandbyLag_Errors(t *testing.T) {
+	_, err := NewDatabase(DefaultConfig().StandbyOf("host=primary")).StandbyLag(context.Background())
+	assert.EqualError(t, err, "server has not been started")
+
+	database := NewDatabase()
+	database.started = true
+
+	_, err = database.StandbyLag(context.Background())
+	assert.EqualError(t, err, "server is not a standby, configure it with Config.StandbyOf")
+}
+
+func Test_StandbyOf(t *testing.T) {
+	primary := NewDatabase(DefaultConfig().RuntimePath(t.TempDir()))
+	require.NoError(t, primary.Start())
+
+	defer func() {
+		assert.NoError(t, primary.Stop())
+	}()
+
+	standby := NewDatabase(DefaultConfig().
+		RuntimePath(t.TempDir()).
+		Port(5433).
+		StandbyOf("host=localhost port=5432 user=postgres password=postgres"))
+	require.NoError(t, standby.Start())
+
+	defer func() {
+		assert.NoError(t, standby.Stop())
+	}()
+
+	require.NoError(t, primary.execStatements(context.Background(), "postgres",
+		"CREATE TABLE brews (name text)",
+		"INSERT INTO brews VALUES ('stout')"))
+
+	db, err := standby.openDB("postgres")
+	require.NoError(t, err)
+
+	defer func() {
+		assert.NoError(t, db.Close())
+	}()
+
+	assert.Eventually(t, func() bool {
+		var name string
+		return db.QueryRow("SELECT name FROM brews").Scan(&name) == nil && name == "stout"
+	}, 10*time.Second, 100*time.Millisecond)
+
+	var inRecovery bool
+	require.NoError(t, db.QueryRow("SELECT pg_is_in_recovery()").Scan(&inRecovery))
+	assert.True(t, inRecovery)
+
+	_, err = standby.StandbyLag(context.Background())
+	assert.NoError(t, err)
+}
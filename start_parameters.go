@@ -0,0 +1,139 @@
+package embeddedpostgres
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// WALLevel determines how much information is written to the write-ahead log.
+type WALLevel string
+
+// Predefined WAL levels. Replica was named hot_standby before Postgres 9.6.
+const (
+	WALLevelMinimal = WALLevel("minimal")
+	WALLevelReplica = WALLevel("replica")
+	WALLevelLogical = WALLevel("logical")
+)
+
+// memoryUnits holds the multipliers in bytes of the units memory parameters accept.
+var memoryUnits = map[string]float64{
+	"B":  1,
+	"kB": 1024,
+	"MB": 1024 * 1024,
+	"GB": 1024 * 1024 * 1024,
+	"TB": 1024 * 1024 * 1024 * 1024,
+}
+
+// durationUnits holds the multipliers in milliseconds of the units time parameters accept.
+var durationUnits = map[string]float64{
+	"us":  0.001,
+	"ms":  1,
+	"s":   1000,
+	"min": 60 * 1000,
+	"h":   60 * 60 * 1000,
+	"d":   24 * 60 * 60 * 1000,
+}
+
+// checkStartParameters rejects parameter names that Postgres would fail to parse, and values of the resource settings
+// with typed builders that are out of range, which otherwise only surfaces as a server that does not start.
+func checkStartParameters(config Config) error {
+	for _, name := range sortedParameterNames(config.startParameters) {
+		if !parameterNamePattern.MatchString(name) {
+			return fmt.Errorf("invalid start parameter name %q", name)
+		}
+
+		if err := checkResourceParameter(name, config.startParameters[name]); err != nil {
+			return fmt.Errorf("invalid value %q for start parameter %s: %w", config.startParameters[name], name, err)
+		}
+	}
+
+	return nil
+}
+
+func checkResourceParameter(name, value string) error {
+	switch name {
+	case "max_connections":
+		connections, err := strconv.Atoi(value)
+		if err != nil {
+			return errors.New("not a whole number")
+		}
+
+		if connections < 1 || connections > 262143 {
+			return errors.New("must be between 1 and 262143")
+		}
+	case "shared_buffers":
+		// without a unit shared_buffers is a number of 8kB blocks
+		return checkMinimumMemory(value, 8*1024, 128*1024)
+	case "work_mem":
+		return checkMinimumMemory(value, 1024, 64*1024)
+	case "statement_timeout":
+		timeout, err := parseUnitValue(value, durationUnits, 1)
+		if err != nil {
+			return err
+		}
+
+		if timeout < 0 {
+			return errors.New("must not be negative")
+		}
+	}
+
+	return nil
+}
+
+func checkMinimumMemory(value string, defaultUnit, minimum float64) error {
+	bytes, err := parseUnitValue(value, memoryUnits, defaultUnit)
+	if err != nil {
+		return err
+	}
+
+	if bytes < minimum {
+		return fmt.Errorf("must be at least %dkB", int(minimum/1024))
+	}
+
+	return nil
+}
+
+// parseUnitValue parses a number followed by one of units, or by no unit in which case it is in defaultUnit.
+func parseUnitValue(value string, units map[string]float64, defaultUnit float64) (float64, error) {
+	number := strings.TrimRightFunc(strings.TrimSpace(value), func(r rune) bool {
+		return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z'
+	})
+
+	if strings.TrimSpace(number) == "" {
+		return 0, errors.New("not a number")
+	}
+
+	unit := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(value), number))
+
+	multiplier := defaultUnit
+	if unit != "" {
+		var ok bool
+		if multiplier, ok = units[unit]; !ok {
+			return 0, fmt.Errorf("unknown unit %q", unit)
+		}
+	}
+
+	parsed, err := strconv.ParseFloat(strings.TrimSpace(number), 64)
+	if err != nil || math.IsNaN(parsed) || math.IsInf(parsed, 0) {
+		return 0, errors.New("not a number")
+	}
+
+	return parsed * multiplier, nil
+}
+
+// sortedParameterNames returns the names of the parameters in order, so that servers are started with the same
+// command line each time.
+func sortedParameterNames(parameters map[string]string) []string {
+	names := make([]string, 0, len(parameters))
+	for name := range parameters {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
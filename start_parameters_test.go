@@ -0,0 +1,82 @@
+package embeddedpostgres
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_StartParameter(t *testing.T) {
+	parameters := map[string]string{"max_connections": "101"}
+	base := DefaultConfig().StartParameters(parameters)
+
+	config := base.
+		StartParameter("log_statement", "all").
+		SharedBuffers(16 * 1024 * 1024).
+		WALLevel(WALLevelLogical)
+
+	assert.Equal(t, map[string]string{
+		"max_connections": "101",
+		"log_statement":   "all",
+		"shared_buffers":  "16384kB",
+		"wal_level":       "logical",
+	}, config.startParameters)
+
+	// builders copy rather than modify the parameters they were given
+	assert.Equal(t, map[string]string{"max_connections": "101"}, parameters)
+	assert.Equal(t, map[string]string{"max_connections": "101"}, base.startParameters)
+
+	assert.Equal(t, "50", DefaultConfig().MaxConnections(50).startParameters["max_connections"])
+	assert.Equal(t, "4096kB", DefaultConfig().WorkMem(4 * 1024 * 1024).startParameters["work_mem"])
+	assert.Equal(t, "1500ms", DefaultConfig().StatementTimeout(1500 * time.Millisecond).startParameters["statement_timeout"])
+	assert.Equal(t, "1ms", DefaultConfig().StatementTimeout(time.Microsecond).startParameters["statement_timeout"])
+	assert.Equal(t, "0ms", DefaultConfig().StatementTimeout(0).startParameters["statement_timeout"])
+}
+
+func Test_checkStartParameters(t *testing.T) {
+	assert.NoError(t, checkStartParameters(DefaultConfig().StartParameter("auto_explain.log_min_duration", "0")))
+	assert.EqualError(t, checkStartParameters(DefaultConfig().StartParameter("max connections", "5")),
+		`invalid start parameter name "max connections"`)
+}
+
+func Test_checkStartParameters_ResourceSettings(t *testing.T) {
+	valid := DefaultConfig().
+		MaxConnections(20).
+		SharedBuffers(128 * 1024).
+		WorkMem(64 * 1024).
+		StatementTimeout(time.Minute)
+	assert.NoError(t, checkStartParameters(valid))
+
+	for _, parameters := range []map[string]string{
+		{"shared_buffers": "16"},
+		{"shared_buffers": "1GB"},
+		{"work_mem": "64"},
+		{"work_mem": "0.5MB"},
+		{"statement_timeout": "0"},
+		{"statement_timeout": "5min"},
+		{"statement_timeout": "250us"},
+	} {
+		assert.NoError(t, checkStartParameters(DefaultConfig().StartParameters(parameters)), parameters)
+	}
+
+	for _, test := range []struct {
+		config   Config
+		expected string
+	}{
+		{DefaultConfig().MaxConnections(0), `invalid value "0" for start parameter max_connections: must be between 1 and 262143`},
+		{DefaultConfig().StartParameter("max_connections", "many"), `invalid value "many" for start parameter max_connections: not a whole number`},
+		{DefaultConfig().SharedBuffers(64 * 1024), `invalid value "64kB" for start parameter shared_buffers: must be at least 128kB`},
+		{DefaultConfig().StartParameter("shared_buffers", "15"), `invalid value "15" for start parameter shared_buffers: must be at least 128kB`},
+		{DefaultConfig().WorkMem(1024), `invalid value "1kB" for start parameter work_mem: must be at least 64kB`},
+		{DefaultConfig().StartParameter("work_mem", "4mb"), `invalid value "4mb" for start parameter work_mem: unknown unit "mb"`},
+		{DefaultConfig().StatementTimeout(-time.Second), `invalid value "-1000ms" for start parameter statement_timeout: must not be negative`},
+		{DefaultConfig().StartParameter("statement_timeout", "soon"), `invalid value "soon" for start parameter statement_timeout: not a number`},
+	} {
+		assert.EqualError(t, checkStartParameters(test.config), test.expected)
+	}
+}
+
+func Test_sortedParameterNames(t *testing.T) {
+	assert.Equal(t, []string{"a", "b", "c"}, sortedParameterNames(map[string]string{"c": "", "a": "", "b": ""}))
+}
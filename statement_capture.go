@@ -0,0 +1,141 @@
+package embeddedpostgres
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const captureStatementsConfSnippet = "00-capture-statements"
+
+// CapturedStatements returns the SQL statements executed by the server since Start or the last call to
+// ResetCapturedStatements, in the order they were executed. Config.CaptureStatements must be enabled.
+func (ep *EmbeddedPostgres) CapturedStatements() ([]string, error) {
+	if err := ep.checkCapturingStatements(); err != nil {
+		return nil, err
+	}
+
+	ep.syncCSVLog()
+
+	file, err := os.Open(ep.syncedLogger.file.Name())
+	if err != nil {
+		return nil, fmt.Errorf("unable to read captured statements: %w", err)
+	}
+
+	defer func() {
+		_ = file.Close()
+	}()
+
+	if _, err := file.Seek(ep.captureOffset, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("unable to read captured statements: %w", err)
+	}
+
+	logContent, err := io.ReadAll(file)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read captured statements: %w", err)
+	}
+
+	return parseLoggedStatements(logContent), nil
+}
+
+// ResetCapturedStatements discards the statements captured so far, for example between tests.
+func (ep *EmbeddedPostgres) ResetCapturedStatements() error {
+	if err := ep.checkCapturingStatements(); err != nil {
+		return err
+	}
+
+	ep.syncCSVLog()
+
+	info, err := os.Stat(ep.syncedLogger.file.Name())
+	if err != nil {
+		return fmt.Errorf("unable to reset captured statements: %w", err)
+	}
+
+	ep.captureOffset = info.Size()
+
+	return nil
+}
+
+func (ep *EmbeddedPostgres) checkCapturingStatements() error {
+	if !ep.config.captureStatements {
+		return errors.New("statement capture is not enabled")
+	}
+
+	if ep.syncedLogger == nil {
+		return errors.New("server has not been started")
+	}
+
+	return nil
+}
+
+func captureStatementsSettings(config Config) map[string]string {
+	if !config.captureStatements {
+		return nil
+	}
+
+	return map[string]string{
+		"log_statement":   "all",
+		"log_destination": "stderr",
+	}
+}
+
+// parseLoggedStatements extracts the statements from server log output produced with log_statement=all.
+// Simple queries are logged as "LOG:  statement: ..." and prepared statements as "LOG:  execute <name>: ...", with any
+// further lines of a multi-line statement indented by a tab.
+func parseLoggedStatements(logContent []byte) []string {
+	var (
+		statements []string
+		current    *strings.Builder
+	)
+
+	endStatement := func() {
+		if current != nil {
+			statements = append(statements, current.String())
+			current = nil
+		}
+	}
+
+	sc := bufio.NewScanner(bytes.NewReader(logContent))
+	sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	for sc.Scan() {
+		line := sc.Text()
+
+		if current != nil && strings.HasPrefix(line, "\t") {
+			current.WriteString("\n")
+			current.WriteString(line[1:])
+
+			continue
+		}
+
+		endStatement()
+
+		if statement, ok := loggedStatement(line); ok {
+			current = &strings.Builder{}
+			current.WriteString(statement)
+		}
+	}
+
+	endStatement()
+
+	return statements
+}
+
+func loggedStatement(line string) (string, bool) {
+	if i := strings.Index(line, "LOG:  statement: "); i >= 0 {
+		return line[i+len("LOG:  statement: "):], true
+	}
+
+	if i := strings.Index(line, "LOG:  execute "); i >= 0 {
+		rest := line[i+len("LOG:  execute "):]
+		if j := strings.Index(rest, ": "); j >= 0 {
+			return rest[j+2:], true
+		}
+	}
+
+	return "", false
+}
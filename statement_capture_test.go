@@ -0,0 +1,84 @@
+package embeddedpostgres
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parseLoggedStatements(t *testing.T) {
+	logContent := "2023-11-21 10:00:00.000 UTC [101] LOG:  statement: SELECT 1\n" +
+		"2023-11-21 10:00:00.001 UTC [101] LOG:  execute <unnamed>: SELECT * FROM beers WHERE name = $1\n" +
+		"2023-11-21 10:00:00.001 UTC [101] DETAIL:  parameters: $1 = 'stout'\n" +
+		"2023-11-21 10:00:00.002 UTC [101] LOG:  statement: SELECT name\n" +
+		"\tFROM beers\n" +
+		"\tORDER BY name\n" +
+		"2023-11-21 10:00:00.003 UTC [101] LOG:  checkpoint starting: time\n" +
+		"2023-11-21 10:00:00.004 UTC [101] LOG:  execute S_1: COMMIT\n"
+
+	assert.Equal(t, []string{
+		"SELECT 1",
+		"SELECT * FROM beers WHERE name = $1",
+		"SELECT name\nFROM beers\nORDER BY name",
+		"COMMIT",
+	}, parseLoggedStatements([]byte(logContent)))
+}
+
+func Test_CapturedStatements_ErrorWhenNotEnabled(t *testing.T) {
+	database := NewDatabase()
+
+	_, err := database.CapturedStatements()
+
+	assert.EqualError(t, err, "statement capture is not enabled")
+}
+
+func Test_CapturedStatements_ErrorWhenNotStarted(t *testing.T) {
+	database := NewDatabase(DefaultConfig().CaptureStatements(true))
+
+	_, err := database.CapturedStatements()
+
+	assert.EqualError(t, err, "server has not been started")
+}
+
+func Test_CaptureStatements(t *testing.T) {
+	database := NewDatabase(DefaultConfig().CaptureStatements(true))
+	if err := database.Start(); err != nil {
+		shutdownDBAndFail(t, err, database)
+	}
+
+	db, err := sql.Open("postgres", "host=localhost port=5432 user=postgres password=postgres dbname=postgres sslmode=disable")
+	if err != nil {
+		shutdownDBAndFail(t, err, database)
+	}
+
+	if _, err := db.Exec("SELECT 42"); err != nil {
+		shutdownDBAndFail(t, err, database)
+	}
+
+	statements, err := database.CapturedStatements()
+	if err != nil {
+		shutdownDBAndFail(t, err, database)
+	}
+
+	assert.Contains(t, statements, "SELECT 42")
+
+	if err := database.ResetCapturedStatements(); err != nil {
+		shutdownDBAndFail(t, err, database)
+	}
+
+	statements, err = database.CapturedStatements()
+	if err != nil {
+		shutdownDBAndFail(t, err, database)
+	}
+
+	assert.NotContains(t, statements, "SELECT 42")
+
+	if err := db.Close(); err != nil {
+		shutdownDBAndFail(t, err, database)
+	}
+
+	if err := database.Stop(); err != nil {
+		shutdownDBAndFail(t, err, database)
+	}
+}
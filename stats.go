@@ -0,0 +1,118 @@
+package embeddedpostgres
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// DatabaseStats is a sample of the activity counters of the configured database, taken from pg_stat_database and
+// pg_stat_activity. The counters are cumulative since the statistics were last reset, so rates are found by comparing
+// two samples.
+type DatabaseStats struct {
+	SampledAt         time.Time
+	Connections       int
+	ActiveConnections int
+	XactCommit        int64
+	XactRollback      int64
+	TupReturned       int64
+	TupFetched        int64
+	TupInserted       int64
+	TupUpdated        int64
+	TupDeleted        int64
+	Deadlocks         int64
+}
+
+// SampleStats samples the activity counters of the configured database.
+func (ep *EmbeddedPostgres) SampleStats(ctx context.Context) (stats DatabaseStats, err error) {
+	if !ep.isStarted() {
+		return DatabaseStats{}, errors.New("server has not been started")
+	}
+
+	db, err := ep.openDB("postgres")
+	if err != nil {
+		return DatabaseStats{}, err
+	}
+
+	defer func() {
+		err = connectionClose(db, err)
+	}()
+
+	// the connection used to sample is not counted, and nor are background processes from Postgres 10
+	activeQuery := "SELECT count(*) FROM pg_stat_activity WHERE datname = $1 AND state = 'active' AND pid <> pg_backend_pid()"
+	if majorVersion(ep.config.version) >= 10 {
+		activeQuery += " AND backend_type = 'client backend'"
+	}
+
+	err = db.QueryRowContext(ctx, `SELECT numbackends, xact_commit, xact_rollback, tup_returned, tup_fetched,
+		tup_inserted, tup_updated, tup_deleted, deadlocks, (`+activeQuery+`)
+		FROM pg_stat_database WHERE datname = $1`, ep.config.database).Scan(
+		&stats.Connections,
+		&stats.XactCommit,
+		&stats.XactRollback,
+		&stats.TupReturned,
+		&stats.TupFetched,
+		&stats.TupInserted,
+		&stats.TupUpdated,
+		&stats.TupDeleted,
+		&stats.Deadlocks,
+		&stats.ActiveConnections)
+	if err != nil {
+		return DatabaseStats{}, err
+	}
+
+	if ep.config.database == "postgres" {
+		// numbackends includes the connection used to sample when it is to the configured database
+		stats.Connections--
+	}
+
+	stats.SampledAt = time.Now()
+
+	return stats, nil
+}
+
+// LatestStats returns the most recent sample taken by the poller enabled with Config.StatsPollInterval, or the error
+// taking it failed with. It returns an error until the first sample has been taken.
+func (ep *EmbeddedPostgres) LatestStats() (DatabaseStats, error) {
+	ep.statsMu.Lock()
+	defer ep.statsMu.Unlock()
+
+	if ep.latestStats.SampledAt.IsZero() && ep.latestStatsErr == nil {
+		return DatabaseStats{}, errors.New("no statistics have been sampled")
+	}
+
+	return ep.latestStats, ep.latestStatsErr
+}
+
+// watchStats samples the activity counters at the configured interval for LatestStats.
+func (ep *EmbeddedPostgres) watchStats() {
+	ep.statsMu.Lock()
+	ep.latestStats, ep.latestStatsErr = DatabaseStats{}, nil
+	ep.statsMu.Unlock()
+
+	if ep.config.statsPollInterval <= 0 {
+		return
+	}
+
+	background := ep.background()
+
+	ep.watch(ep.config.statsPollInterval, func(now time.Time) bool {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		stats, err := background.SampleStats(ctx)
+
+		background.statsMu.Lock()
+		defer background.statsMu.Unlock()
+
+		if err != nil {
+			// the previous sample is kept so that a transient failure does not lose the counters
+			background.latestStatsErr = err
+			return false
+		}
+
+		background.latestStats, background.latestStatsErr = stats, nil
+
+		return false
+	})
+}
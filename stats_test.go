@@ -0,0 +1,55 @@
+package embeddedpostgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SampleStats_ErrorWhenNotStarted(t *testing.T) {
+	_, err := NewDatabase().SampleStats(context.Background())
+
+	assert.EqualError(t, err, "server has not been started")
+}
+
+func Test_LatestStats_ErrorWhenNotSampled(t *testing.T) {
+	_, err := NewDatabase().LatestStats()
+
+	assert.EqualError(t, err, "no statistics have been sampled")
+}
+
+func Test_StatsPollInterval(t *testing.T) {
+	database := NewDatabase(DefaultConfig().
+		RuntimePath(t.TempDir()).
+		Database("beer").
+		StatsPollInterval(100 * time.Millisecond))
+	require.NoError(t, database.Start())
+
+	defer func() {
+		assert.NoError(t, database.Stop())
+	}()
+
+	db, err := database.openDB("beer")
+	require.NoError(t, err)
+
+	defer func() {
+		assert.NoError(t, db.Close())
+	}()
+
+	_, err = db.Exec("CREATE TABLE brews (name text); INSERT INTO brews SELECT 'stout' FROM generate_series(1, 10)")
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		stats, err := database.LatestStats()
+		return err == nil && stats.TupInserted >= 10
+	}, 10*time.Second, 100*time.Millisecond)
+
+	stats, err := database.LatestStats()
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, stats.Connections, 1)
+	assert.Greater(t, stats.XactCommit, int64(0))
+	assert.False(t, stats.SampledAt.IsZero())
+}
@@ -0,0 +1,39 @@
+package embeddedpostgres
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ServerStatus is the state of the Postgres server using the configured data directory, as reported by pg_ctl status.
+type ServerStatus struct {
+	PID     int
+	Running bool
+	DataDir string
+	// Output is the unparsed output of pg_ctl status, which includes the command line of a running server.
+	Output string
+	// Orphaned is true when a server is running against the data directory without having been started by this
+	// instance, such as one left behind by a test process that was killed.
+	Orphaned bool
+}
+
+// Status reports the state of the server using the configured data directory, whether or not it was started by this
+// instance. It can be called once Start has extracted the binaries.
+func (ep *EmbeddedPostgres) Status() (ServerStatus, error) {
+	if ep.config.binariesPath == "" || ep.config.dataPath == "" {
+		return ServerStatus{}, errors.New("data directory has not been initialised")
+	}
+
+	status, err := pgCtlStatus(ep.config)
+	if err != nil {
+		return ServerStatus{}, fmt.Errorf("unable to read server status: %w", err)
+	}
+
+	return ServerStatus{
+		PID:      status.Pid,
+		Running:  status.Running,
+		DataDir:  ep.config.dataPath,
+		Output:   status.Output,
+		Orphaned: status.Running && !ep.isStarted(),
+	}, nil
+}
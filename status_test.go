@@ -0,0 +1,49 @@
+package embeddedpostgres
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Status_ErrorWhenNotInitialised(t *testing.T) {
+	_, err := NewDatabase().Status()
+
+	assert.EqualError(t, err, "data directory has not been initialised")
+}
+
+func Test_Status(t *testing.T) {
+	binariesPath := t.TempDir()
+	pgCtl := filepath.Join(binariesPath, "bin", "pg_ctl")
+	require.NoError(t, os.MkdirAll(filepath.Dir(pgCtl), 0755))
+
+	database := NewDatabase(DefaultConfig().BinariesPath(binariesPath).DataPath("/data"))
+
+	running := "pg_ctl: server is running (PID: 4242)\n/usr/bin/postgres \"-D\" \"/data\"\n"
+	require.NoError(t, os.WriteFile(pgCtl, []byte("#!/bin/sh\nprintf '"+running+"'\n"), 0755))
+
+	status, err := database.Status()
+	require.NoError(t, err)
+	assert.Equal(t, ServerStatus{
+		PID:      4242,
+		Running:  true,
+		DataDir:  "/data",
+		Output:   running,
+		Orphaned: true,
+	}, status)
+
+	database.started = true
+
+	status, err = database.Status()
+	require.NoError(t, err)
+	assert.False(t, status.Orphaned)
+
+	require.NoError(t, os.WriteFile(pgCtl, []byte("#!/bin/sh\necho 'pg_ctl: no server running'\nexit 3\n"), 0755))
+
+	status, err = database.Status()
+	require.NoError(t, err)
+	assert.Equal(t, ServerStatus{DataDir: "/data", Output: "pg_ctl: no server running\n"}, status)
+}
@@ -0,0 +1,122 @@
+package embeddedpostgres
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+const (
+	tempConfSnippet = "00-temp"
+	// managedTempTablespace is the tablespace created within the directory set with Config.TempFileDirectory.
+	managedTempTablespace = "embedded_postgres_temp"
+)
+
+// tempSettings returns the settings directing statistics and temporary files to the configured locations.
+func tempSettings(config Config) map[string]string {
+	settings := map[string]string{}
+
+	// the statistics collector and with it stats_temp_directory were removed in Postgres 15
+	if config.statsTempDirectory != "" && majorVersion(config.version) < 15 {
+		settings["stats_temp_directory"] = config.statsTempDirectory
+	}
+
+	tablespaces := config.tempTablespaces
+	if config.tempFileDirectory != "" {
+		tablespaces = append([]string{managedTempTablespace}, tablespaces...)
+	}
+
+	if len(tablespaces) > 0 {
+		settings["temp_tablespaces"] = strings.Join(tablespaces, ",")
+	}
+
+	return settings
+}
+
+// writeTempConf writes the statistics and temporary file settings into the drop-in configuration directory, creating
+// the statistics directory as Postgres expects it to exist.
+func (ep *EmbeddedPostgres) writeTempConf() error {
+	settings := tempSettings(ep.config)
+
+	if directory, ok := settings["stats_temp_directory"]; ok {
+		if !filepath.IsAbs(directory) {
+			directory = filepath.Join(ep.config.dataPath, directory)
+		}
+
+		if err := os.MkdirAll(directory, dataDirMode(ep.config)); err != nil {
+			return fmt.Errorf("unable to create statistics directory %s: %w", directory, err)
+		}
+	}
+
+	return ep.writeManagedConfSnippet(tempConfSnippet, settings)
+}
+
+// createTempTablespace creates the tablespace temporary files are written to within Config.TempFileDirectory, unless
+// a reused data directory already has it. The directory is emptied first as Postgres only creates tablespaces in empty
+// directories.
+func (ep *EmbeddedPostgres) createTempTablespace(ctx context.Context) (err error) {
+	if ep.config.tempFileDirectory == "" {
+		return nil
+	}
+
+	db, err := ep.openDB("postgres")
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		err = connectionClose(db, err)
+	}()
+
+	var exists bool
+	if err := db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM pg_tablespace WHERE spcname = $1)", managedTempTablespace).Scan(&exists); err != nil {
+		return fmt.Errorf("unable to check for tablespace %s: %w", managedTempTablespace, err)
+	}
+
+	if exists {
+		return nil
+	}
+
+	if err := os.RemoveAll(ep.config.tempFileDirectory); err != nil {
+		return fmt.Errorf("unable to clean up temporary file directory %s: %w", ep.config.tempFileDirectory, err)
+	}
+
+	if err := os.MkdirAll(ep.config.tempFileDirectory, 0700); err != nil {
+		return fmt.Errorf("unable to create temporary file directory %s: %w", ep.config.tempFileDirectory, err)
+	}
+
+	statement := fmt.Sprintf("CREATE TABLESPACE %s LOCATION %s",
+		pq.QuoteIdentifier(managedTempTablespace),
+		pq.QuoteLiteral(ep.config.tempFileDirectory))
+
+	if _, err := db.ExecContext(ctx, statement); err != nil {
+		return fmt.Errorf("unable to create tablespace %s: %w", managedTempTablespace, err)
+	}
+
+	return nil
+}
+
+// removeTempFiles removes the temporary files left within Config.TempFileDirectory, which Postgres would otherwise only
+// remove on its next start.
+func removeTempFiles(config Config) error {
+	if config.tempFileDirectory == "" {
+		return nil
+	}
+
+	tempDirs, err := filepath.Glob(filepath.Join(config.tempFileDirectory, "PG_*", "pgsql_tmp"))
+	if err != nil {
+		return err
+	}
+
+	for _, tempDir := range tempDirs {
+		if err := os.RemoveAll(tempDir); err != nil {
+			return fmt.Errorf("unable to remove temporary files %s: %w", tempDir, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,93 @@
+package embeddedpostgres
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_tempSettings(t *testing.T) {
+	assert.Empty(t, tempSettings(DefaultConfig()))
+
+	assert.Equal(t, map[string]string{
+		"stats_temp_directory": "/mnt/ram/stats",
+		"temp_tablespaces":     "embedded_postgres_temp,fast,faster",
+	}, tempSettings(DefaultConfig().
+		Version(V14).
+		StatsTempDirectory("/mnt/ram/stats").
+		TempTablespaces("fast", "faster").
+		TempFileDirectory("/mnt/ram/temp")))
+
+	assert.Equal(t, map[string]string{"temp_tablespaces": "fast"}, tempSettings(DefaultConfig().
+		Version(V15).
+		StatsTempDirectory("/mnt/ram/stats").
+		TempTablespaces("fast")))
+}
+
+func Test_writeTempConf(t *testing.T) {
+	dataPath := t.TempDir()
+
+	database := NewDatabase(DefaultConfig().
+		Version(V14).
+		DataPath(dataPath).
+		StatsTempDirectory("stats"))
+
+	require.NoError(t, os.MkdirAll(database.ConfDir(), 0700))
+	require.NoError(t, database.writeTempConf())
+
+	assert.DirExists(t, filepath.Join(dataPath, "stats"))
+
+	snippet, err := os.ReadFile(filepath.Join(database.ConfDir(), tempConfSnippet+".conf"))
+	require.NoError(t, err)
+	assert.Equal(t, "stats_temp_directory = 'stats'\n", string(snippet))
+}
+
+func Test_removeTempFiles(t *testing.T) {
+	tempFileDirectory := t.TempDir()
+	tempDir := filepath.Join(tempFileDirectory, "PG_15_202209061", "pgsql_tmp")
+	tableDir := filepath.Join(tempFileDirectory, "PG_15_202209061", "5")
+
+	require.NoError(t, os.MkdirAll(tempDir, 0700))
+	require.NoError(t, os.MkdirAll(tableDir, 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "pgsql_tmp1234.0"), []byte("spilled"), 0600))
+
+	require.NoError(t, removeTempFiles(DefaultConfig().TempFileDirectory(tempFileDirectory)))
+
+	assert.NoDirExists(t, tempDir)
+	assert.DirExists(t, tableDir)
+	assert.NoError(t, removeTempFiles(DefaultConfig()))
+}
+
+func Test_TempFileDirectory(t *testing.T) {
+	tempFileDirectory := filepath.Join(t.TempDir(), "temp")
+	require.NoError(t, os.MkdirAll(tempFileDirectory, 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(tempFileDirectory, "stale"), []byte("stale"), 0600))
+
+	database := NewDatabase(DefaultConfig().
+		RuntimePath(t.TempDir()).
+		TempFileDirectory(tempFileDirectory))
+	require.NoError(t, database.Start())
+
+	db, err := database.openDB("postgres")
+	require.NoError(t, err)
+
+	var location string
+	require.NoError(t, db.QueryRow("SELECT pg_tablespace_location(oid) FROM pg_tablespace WHERE spcname = $1", managedTempTablespace).Scan(&location))
+	assert.Equal(t, tempFileDirectory, location)
+
+	var tablespaces string
+	require.NoError(t, db.QueryRow("SELECT current_setting('temp_tablespaces')").Scan(&tablespaces))
+	assert.Equal(t, managedTempTablespace, tablespaces)
+
+	assert.NoError(t, db.Close())
+	assert.NoFileExists(t, filepath.Join(tempFileDirectory, "stale"))
+
+	require.NoError(t, database.Stop())
+
+	tempDirs, err := filepath.Glob(filepath.Join(tempFileDirectory, "PG_*", "pgsql_tmp"))
+	require.NoError(t, err)
+	assert.Empty(t, tempDirs)
+}
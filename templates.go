@@ -0,0 +1,123 @@
+package embeddedpostgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// CreateTemplate creates the database name, replacing any existing one, and calls seed with a connection to it to
+// create the schema and data to reset to, marking it as a template once seeded. Databases are reset to the template
+// with ResetFromTemplate, which takes a fraction of the time of running migrations again.
+func (ep *EmbeddedPostgres) CreateTemplate(name string, seed func(db *sql.DB) error) (err error) {
+	if !ep.isStarted() {
+		return errors.New("server has not been started")
+	}
+
+	ctx := context.Background()
+
+	db, err := ep.openDB("postgres")
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		err = connectionClose(db, err)
+	}()
+
+	if err := dropDatabase(ctx, db, name); err != nil {
+		return err
+	}
+
+	if _, err := db.ExecContext(ctx, createDatabaseStatement(DatabaseSpec{Name: name})); err != nil {
+		return fmt.Errorf("unable to create template %s: %w", name, err)
+	}
+
+	if err := ep.seedTemplate(name, seed); err != nil {
+		if dropErr := dropDatabase(ctx, db, name); dropErr != nil {
+			return fmt.Errorf("%w; %s", err, dropErr)
+		}
+
+		return err
+	}
+
+	if _, err := db.ExecContext(ctx, "ALTER DATABASE "+pq.QuoteIdentifier(name)+" WITH IS_TEMPLATE true"); err != nil {
+		return fmt.Errorf("unable to mark %s as a template: %w", name, err)
+	}
+
+	return nil
+}
+
+func (ep *EmbeddedPostgres) seedTemplate(name string, seed func(db *sql.DB) error) (err error) {
+	db, err := ep.openDB(name)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		err = connectionClose(db, err)
+	}()
+
+	if err := seed(db); err != nil {
+		return fmt.Errorf("unable to seed template %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// ResetFromTemplate replaces the database dbName with a copy of templateName, terminating connections to both first.
+// Connection pools to dbName reconnect to the copy.
+func (ep *EmbeddedPostgres) ResetFromTemplate(dbName, templateName string) (err error) {
+	if !ep.isStarted() {
+		return errors.New("server has not been started")
+	}
+
+	ctx := context.Background()
+
+	db, err := ep.openDB("postgres")
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		err = connectionClose(db, err)
+	}()
+
+	if err := dropDatabase(ctx, db, dbName); err != nil {
+		return err
+	}
+
+	return createDatabaseFromTemplate(ctx, db, templateName, dbName)
+}
+
+// dropDatabase drops the database if it exists, terminating connections to it and unmarking it as a template first.
+func dropDatabase(ctx context.Context, db *sql.DB, name string) error {
+	var template bool
+	err := db.QueryRowContext(ctx, "SELECT datistemplate FROM pg_database WHERE datname = $1", name).Scan(&template)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("unable to drop database %s: %w", name, err)
+	}
+
+	if template {
+		if _, err := db.ExecContext(ctx, "ALTER DATABASE "+pq.QuoteIdentifier(name)+" WITH IS_TEMPLATE false"); err != nil {
+			return fmt.Errorf("unable to drop database %s: %w", name, err)
+		}
+	}
+
+	if err := terminateConnections(ctx, db, name); err != nil {
+		return err
+	}
+
+	if _, err := db.ExecContext(ctx, "DROP DATABASE IF EXISTS "+pq.QuoteIdentifier(name)); err != nil {
+		return fmt.Errorf("unable to drop database %s: %w", name, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,67 @@
+package embeddedpostgres
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_CreateTemplate_NotStarted(t *testing.T) {
+	database := NewDatabase()
+
+	assert.EqualError(t, database.CreateTemplate("brewery", func(db *sql.DB) error {
+		return nil
+	}), "server has not been started")
+	assert.EqualError(t, database.ResetFromTemplate("postgres", "brewery"), "server has not been started")
+}
+
+func Test_ResetFromTemplate(t *testing.T) {
+	database := StartForTest(t)
+
+	require.NoError(t, database.CreateTemplate("brewery", func(db *sql.DB) error {
+		_, err := db.Exec("CREATE TABLE beer (name text); INSERT INTO beer VALUES ('stout')")
+		return err
+	}))
+
+	require.NoError(t, database.ResetFromTemplate("beer", "brewery"))
+
+	db, err := database.Open("postgres", WithDatabase("beer"))
+	require.NoError(t, err)
+
+	_, err = db.Exec("INSERT INTO beer VALUES ('lager')")
+	require.NoError(t, err)
+
+	require.NoError(t, database.ResetFromTemplate("beer", "brewery"))
+
+	var count int
+	require.NoError(t, db.QueryRow("SELECT count(*) FROM beer").Scan(&count))
+	assert.Equal(t, 1, count)
+
+	require.NoError(t, database.CreateTemplate("brewery", func(db *sql.DB) error {
+		_, err := db.Exec("CREATE TABLE beer (name text)")
+		return err
+	}))
+
+	require.NoError(t, database.ResetFromTemplate("beer", "brewery"))
+	require.NoError(t, db.QueryRow("SELECT count(*) FROM beer").Scan(&count))
+	assert.Equal(t, 0, count)
+}
+
+func Test_CreateTemplate_SeedFails(t *testing.T) {
+	database := StartForTest(t)
+
+	err := database.CreateTemplate("brewery", func(db *sql.DB) error {
+		return errors.New("ah it did not work")
+	})
+	assert.EqualError(t, err, "unable to seed template brewery: ah it did not work")
+
+	db, err := database.Open("postgres")
+	require.NoError(t, err)
+
+	var exists bool
+	require.NoError(t, db.QueryRow("SELECT EXISTS (SELECT 1 FROM pg_database WHERE datname = 'brewery')").Scan(&exists))
+	assert.False(t, exists)
+}
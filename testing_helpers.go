@@ -0,0 +1,159 @@
+package embeddedpostgres
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"testing"
+)
+
+var (
+	sharedDatabaseMu sync.Mutex
+	sharedDatabase   *EmbeddedPostgres
+)
+
+// ForEachVersion starts a Postgres instance for each of the versions in turn and runs fn against it as a subtest named
+// after the version, stopping the instance once the subtest completes.
+//
+// When called with a Config it is used as the base configuration for every version. Each version is given its own
+// temporary runtime, binaries and data directory, whilst downloaded archives are shared through the cache path.
+func ForEachVersion(t *testing.T, versions []PostgresVersion, fn func(t *testing.T, db *EmbeddedPostgres), config ...Config) {
+	t.Helper()
+
+	baseConfig := DefaultConfig()
+	if len(config) > 0 {
+		baseConfig = config[0]
+	}
+
+	for _, version := range versions {
+		version := version
+
+		t.Run(string(version), func(t *testing.T) {
+			database := NewDatabase(baseConfig.
+				Version(version).
+				RuntimePath(t.TempDir()).
+				BinariesPath("").
+				DataPath(""))
+
+			if err := database.Start(); err != nil {
+				t.Fatalf("unable to start postgres %s: %s", version, err)
+			}
+
+			defer func() {
+				if err := database.Stop(); err != nil {
+					t.Errorf("unable to stop postgres %s: %s", version, err)
+				}
+			}()
+
+			fn(t, database)
+		})
+	}
+}
+
+// StartForTest starts a Postgres instance for the test, stopping it once the test and its subtests complete, and fails
+// the test should it not start.
+//
+// When called with a Config it is used as the base configuration. The instance is given its own temporary runtime,
+// binaries and data directory and listens on a free port, so tests can run in parallel.
+func StartForTest(t testing.TB, config ...Config) *EmbeddedPostgres {
+	t.Helper()
+
+	baseConfig := DefaultConfig()
+	if len(config) > 0 {
+		baseConfig = config[0]
+	}
+
+	database := NewDatabase(baseConfig.
+		RuntimePath(t.TempDir()).
+		BinariesPath("").
+		DataPath("").
+		Port(0))
+
+	if err := database.Start(); err != nil {
+		t.Fatalf("unable to start postgres: %s", err)
+	}
+
+	t.Cleanup(func() {
+		if err := database.Stop(); err != nil {
+			t.Errorf("unable to stop postgres: %s", err)
+		}
+	})
+
+	return database
+}
+
+// RunWithDatabase starts a Postgres instance shared by all of a package's tests, runs them and stops the instance,
+// returning the exit code for TestMain to pass to os.Exit. Tests reach the instance through SharedDatabase.
+//
+// Should the tests be interrupted or terminated the instance is stopped before the process exits.
+func RunWithDatabase(m *testing.M, config Config) int {
+	return runWithDatabase(m.Run, config)
+}
+
+// SharedDatabase returns the instance started by RunWithDatabase, or nil outside of it.
+func SharedDatabase() *EmbeddedPostgres {
+	sharedDatabaseMu.Lock()
+	defer sharedDatabaseMu.Unlock()
+
+	return sharedDatabase
+}
+
+func setSharedDatabase(database *EmbeddedPostgres) {
+	sharedDatabaseMu.Lock()
+	defer sharedDatabaseMu.Unlock()
+
+	sharedDatabase = database
+}
+
+func runWithDatabase(run func() int, config Config) int {
+	database := NewDatabase(config)
+
+	if err := database.Start(); err != nil {
+		fmt.Fprintf(os.Stderr, "unable to start postgres: %s\n", err)
+		return 1
+	}
+
+	setSharedDatabase(database)
+	defer setSharedDatabase(nil)
+
+	var stopOnce sync.Once
+	var stopErr error
+	stop := func() error {
+		stopOnce.Do(func() {
+			stopErr = database.Stop()
+		})
+
+		return stopErr
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		select {
+		case sig := <-signals:
+			if err := stop(); err != nil {
+				fmt.Fprintf(os.Stderr, "unable to stop postgres on %s: %s\n", sig, err)
+			}
+			os.Exit(1)
+		case <-done:
+		}
+	}()
+
+	code := run()
+
+	if err := stop(); err != nil {
+		fmt.Fprintf(os.Stderr, "unable to stop postgres: %s\n", err)
+		if code == 0 {
+			code = 1
+		}
+	}
+
+	return code
+}
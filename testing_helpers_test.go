@@ -0,0 +1,121 @@
+package embeddedpostgres
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ForEachVersion(t *testing.T) {
+	var ran []PostgresVersion
+
+	ForEachVersion(t, []PostgresVersion{V15, V14}, func(t *testing.T, database *EmbeddedPostgres) {
+		ran = append(ran, database.config.version)
+
+		db, err := sql.Open("postgres", "host=localhost port=5432 user=postgres password=postgres dbname=postgres sslmode=disable")
+		assert.NoError(t, err)
+
+		var serverVersion string
+		assert.NoError(t, db.QueryRow("SHOW server_version").Scan(&serverVersion))
+		assert.True(t, strings.HasPrefix(string(database.config.version), fmt.Sprintf("%d.", majorVersion(PostgresVersion(serverVersion)))))
+		assert.NoError(t, db.Close())
+	})
+
+	assert.Equal(t, []PostgresVersion{V15, V14}, ran)
+}
+
+func Test_StartForTest(t *testing.T) {
+	var database *EmbeddedPostgres
+
+	t.Run("started", func(t *testing.T) {
+		database = StartForTest(t, DefaultConfig().Database("beer"))
+
+		db, err := database.Open("postgres")
+		require.NoError(t, err)
+
+		var name string
+		require.NoError(t, db.QueryRow("SELECT current_database()").Scan(&name))
+		assert.Equal(t, "beer", name)
+		assert.NotEqual(t, uint32(5432), database.Port())
+		assert.NoError(t, db.Close())
+	})
+
+	require.NotNil(t, database)
+	assert.False(t, database.isStarted())
+}
+
+type fatalRecorder struct {
+	testing.TB
+	failure string
+}
+
+func (r *fatalRecorder) Fatalf(format string, args ...interface{}) {
+	r.failure = fmt.Sprintf(format, args...)
+	panic(r)
+}
+
+func Test_StartForTest_FailsTest(t *testing.T) {
+	recorder := &fatalRecorder{TB: t}
+
+	defer func() {
+		assert.Equal(t, recorder, recover())
+		assert.True(t, strings.HasPrefix(recorder.failure, "unable to start postgres: "))
+		assert.Contains(t, recorder.failure, "ah it did not work")
+	}()
+
+	StartForTest(recorder, DefaultConfig().InitDatabaseStrategy(func(binaryExtractLocation, runtimePath, pgDataDir, username, password, locale string, extraArgs []string, logger *os.File) error {
+		return errors.New("ah it did not work")
+	}))
+}
+
+func Test_RunWithDatabase(t *testing.T) {
+	var database *EmbeddedPostgres
+
+	code := runWithDatabase(func() int {
+		database = SharedDatabase()
+		if database == nil {
+			return 2
+		}
+
+		db, err := database.Open("postgres")
+		if err != nil {
+			return 2
+		}
+
+		defer func() {
+			_ = db.Close()
+		}()
+
+		if err := db.Ping(); err != nil {
+			return 2
+		}
+
+		return 3
+	}, DefaultConfig().RuntimePath(t.TempDir()).Port(0))
+
+	assert.Equal(t, 3, code)
+	assert.Nil(t, SharedDatabase())
+	require.NotNil(t, database)
+	assert.False(t, database.isStarted())
+}
+
+func Test_RunWithDatabase_CannotStart(t *testing.T) {
+	ran := false
+
+	code := runWithDatabase(func() int {
+		ran = true
+		return 0
+	}, DefaultConfig().RuntimePath(t.TempDir()).Port(0).InitDatabaseStrategy(func(binaryExtractLocation, runtimePath, pgDataDir, username, password, locale string, extraArgs []string, logger *os.File) error {
+		return errors.New("ah it did not work")
+	}))
+
+	assert.Equal(t, 1, code)
+	assert.False(t, ran)
+	assert.Nil(t, SharedDatabase())
+}
@@ -0,0 +1,49 @@
+package embeddedpostgres
+
+import "time"
+
+// Timings records how long each phase of the last Start or Restart took, as returned by EmbeddedPostgres.Timings.
+// Phases that were skipped, such as the download when the binaries are already cached or initdb when the data
+// directory is reused, are zero.
+type Timings struct {
+	// Download is the time taken to fetch the Postgres binaries into the cache.
+	Download time.Duration
+	// Extraction is the time taken to extract the binaries and any extension archives.
+	Extraction time.Duration
+	// InitDB is the time taken to initialise a new data directory.
+	InitDB time.Duration
+	// ServerStart is the time taken for the server process to start and report itself ready.
+	ServerStart time.Duration
+	// Provision is the time taken to create and provision the configured database once the server is ready, including
+	// any recovery, init scripts, migrations and seeds.
+	Provision time.Duration
+	// HealthCheck is the time taken for the configured database to accept connections.
+	HealthCheck time.Duration
+	// Total is the time taken by Start or Restart as a whole.
+	Total time.Duration
+}
+
+// Timings returns how long each phase of the last Start or Restart took, to help find where time is spent starting
+// the server and what is worth caching between runs.
+func (ep *EmbeddedPostgres) Timings() Timings {
+	ep.timingsMu.Lock()
+	defer ep.timingsMu.Unlock()
+
+	return ep.timings
+}
+
+// resetTimings clears the timings as Start or Restart begins.
+func (ep *EmbeddedPostgres) resetTimings() {
+	ep.timingsMu.Lock()
+	defer ep.timingsMu.Unlock()
+
+	ep.timings = Timings{}
+}
+
+// recordTiming sets a phase of the timings, which are only written by Start and Restart but may be read concurrently.
+func (ep *EmbeddedPostgres) recordTiming(phase *time.Duration, d time.Duration) {
+	ep.timingsMu.Lock()
+	defer ep.timingsMu.Unlock()
+
+	*phase = d
+}
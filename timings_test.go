@@ -0,0 +1,52 @@
+package embeddedpostgres
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Timings(t *testing.T) {
+	database := NewDatabase(DefaultConfig().
+		RuntimePath(t.TempDir()).
+		DataPath(t.TempDir()).
+		Port(0))
+	require.NoError(t, database.Start())
+
+	timings := database.Timings()
+	assert.Positive(t, timings.Extraction)
+	assert.Positive(t, timings.InitDB)
+	assert.Positive(t, timings.ServerStart)
+	assert.Positive(t, timings.Provision)
+	assert.Positive(t, timings.HealthCheck)
+	assert.GreaterOrEqual(t, timings.Total,
+		timings.Download+timings.Extraction+timings.InitDB+timings.ServerStart+timings.Provision+timings.HealthCheck)
+
+	require.NoError(t, database.Restart())
+	require.NoError(t, database.Stop())
+
+	timings = database.Timings()
+	assert.Zero(t, timings.Download)
+	assert.Zero(t, timings.Extraction)
+	assert.Zero(t, timings.InitDB)
+	assert.Positive(t, timings.ServerStart)
+	assert.Positive(t, timings.Total)
+}
+
+func Test_Timings_DownloadWhenNotCached(t *testing.T) {
+	database := NewDatabase(DefaultConfig().BinariesPath(t.TempDir()))
+	database.remoteFetchStrategy = func() error {
+		time.Sleep(10 * time.Millisecond)
+		return nil
+	}
+
+	archive, cleanUp := createTempXzArchive()
+	defer cleanUp()
+
+	require.NoError(t, database.downloadAndExtractBinary(false, archive))
+
+	assert.GreaterOrEqual(t, database.Timings().Download, 10*time.Millisecond)
+	assert.Positive(t, database.Timings().Extraction)
+}
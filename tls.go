@@ -0,0 +1,259 @@
+package embeddedpostgres
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const tlsConfSnippet = "00-tls"
+
+func tlsDir(config Config) string {
+	return filepath.Join(config.runtimePath, "tls")
+}
+
+// tlsFiles returns the server certificate and key, which are generated into the runtime directory unless provided.
+func tlsFiles(config Config) (certFile, keyFile string) {
+	if config.tlsCertFile != "" {
+		return config.tlsCertFile, config.tlsKeyFile
+	}
+
+	return filepath.Join(tlsDir(config), "server.crt"), filepath.Join(tlsDir(config), "server.key")
+}
+
+func tlsSettings(config Config) map[string]string {
+	if !config.tls {
+		return nil
+	}
+
+	certFile, keyFile := tlsFiles(config)
+
+	settings := map[string]string{
+		"ssl":           "on",
+		"ssl_cert_file": certFile,
+		"ssl_key_file":  keyFile,
+	}
+
+	if config.tlsCertFile == "" {
+		settings["ssl_ca_file"] = filepath.Join(tlsDir(config), "ca.crt")
+	}
+
+	return settings
+}
+
+// writeTLSConf generates the certificates unless provided or already generated for the runtime directory, and writes
+// the settings serving them.
+func (ep *EmbeddedPostgres) writeTLSConf() error {
+	if ep.config.tls && ep.config.tlsCertFile == "" {
+		if err := generateServerCertificates(tlsDir(ep.config)); err != nil {
+			return err
+		}
+	}
+
+	return ep.writeManagedConfSnippet(tlsConfSnippet, tlsSettings(ep.config))
+}
+
+// TLSRootCertFile returns the certificate of the authority that signed the certificate generated for the server by
+// Config.TLS, so that clients can verify the server with sslmode=verify-full, for example with
+// ep.ConnectionString(WithSSLMode("verify-full"), WithParameter("sslrootcert", ep.TLSRootCertFile())).
+// It is empty when TLS is not enabled or the server certificate was provided.
+func (ep *EmbeddedPostgres) TLSRootCertFile() string {
+	if !ep.config.tls || ep.config.tlsCertFile != "" {
+		return ""
+	}
+
+	return filepath.Join(tlsDir(ep.config), "ca.crt")
+}
+
+// generateServerCertificates writes a certificate authority and a server certificate it signs for localhost into dir,
+// keeping any generated previously.
+func generateServerCertificates(dir string) error {
+	if _, err := os.Stat(filepath.Join(dir, "server.crt")); err == nil {
+		return nil
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("unable to create TLS directory %s: %w", dir, err)
+	}
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return fmt.Errorf("unable to generate certificate authority key: %w", err)
+	}
+
+	caTemplate, err := certificateTemplate("embedded-postgres CA")
+	if err != nil {
+		return err
+	}
+
+	caTemplate.IsCA = true
+	caTemplate.BasicConstraintsValid = true
+	caTemplate.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature
+
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, caKey.Public(), caKey)
+	if err != nil {
+		return fmt.Errorf("unable to create certificate authority: %w", err)
+	}
+
+	if err := writeCertificate(filepath.Join(dir, "ca.crt"), filepath.Join(dir, "ca.key"), caDER, caKey); err != nil {
+		return err
+	}
+
+	serverTemplate, err := certificateTemplate("localhost")
+	if err != nil {
+		return err
+	}
+
+	serverTemplate.DNSNames = []string{"localhost"}
+	serverTemplate.IPAddresses = []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback}
+	serverTemplate.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+
+	return issueCertificate(dir, serverTemplate, filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key"))
+}
+
+// issueCertificate signs template with the certificate authority in dir, writing the certificate and its key.
+func issueCertificate(dir string, template *x509.Certificate, certFile, keyFile string) error {
+	ca, err := tls.LoadX509KeyPair(filepath.Join(dir, "ca.crt"), filepath.Join(dir, "ca.key"))
+	if err != nil {
+		return fmt.Errorf("unable to load certificate authority: %w", err)
+	}
+
+	caCert, err := x509.ParseCertificate(ca.Certificate[0])
+	if err != nil {
+		return fmt.Errorf("unable to parse certificate authority: %w", err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return fmt.Errorf("unable to generate key for %s: %w", template.Subject.CommonName, err)
+	}
+
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+
+	der, err := x509.CreateCertificate(rand.Reader, template, caCert, key.Public(), ca.PrivateKey)
+	if err != nil {
+		return fmt.Errorf("unable to create certificate for %s: %w", template.Subject.CommonName, err)
+	}
+
+	return writeCertificate(certFile, keyFile, der, key)
+}
+
+func certificateTemplate(commonName string) (*x509.Certificate, error) {
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, fmt.Errorf("unable to generate certificate serial number: %w", err)
+	}
+
+	return &x509.Certificate{
+		SerialNumber: serialNumber,
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().AddDate(1, 0, 0),
+	}, nil
+}
+
+// writeCertificate writes the certificate and its key in PEM, readable only by the owner as Postgres and libpq
+// require of keys.
+func writeCertificate(certFile, keyFile string, der []byte, key crypto.Signer) error {
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return fmt.Errorf("unable to encode key %s: %w", keyFile, err)
+	}
+
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		return fmt.Errorf("unable to write key %s: %w", keyFile, err)
+	}
+
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		return fmt.Errorf("unable to write certificate %s: %w", certFile, err)
+	}
+
+	return nil
+}
+
+func checkTLS(config Config) error {
+	if (config.tlsCertFile == "") != (config.tlsKeyFile == "") {
+		return errors.New("a TLS certificate and key must be provided together")
+	}
+
+	if config.mutualTLS {
+		return checkMutualTLS(config)
+	}
+
+	return nil
+}
+
+// ClientCertificate returns a certificate and key for user signed by the authority generated by Config.TLS, which
+// the server accepts for connections as user over TLS when Config.MutualTLS is enabled. The pair is generated on the
+// first call for each user and can be passed to WithClientCertificate.
+func (ep *EmbeddedPostgres) ClientCertificate(user string) (certFile, keyFile string, err error) {
+	if err := checkMutualTLS(ep.config); err != nil {
+		return "", "", err
+	}
+
+	if ep.config.runtimePath == "" {
+		return "", "", errors.New("runtime directory has not been initialised, call Start first")
+	}
+
+	name := "client-" + url.PathEscape(user)
+	certFile = filepath.Join(tlsDir(ep.config), name+".crt")
+	keyFile = filepath.Join(tlsDir(ep.config), name+".key")
+
+	if _, err := os.Stat(certFile); err == nil {
+		return certFile, keyFile, nil
+	}
+
+	template, err := certificateTemplate(user)
+	if err != nil {
+		return "", "", err
+	}
+
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+
+	if err := issueCertificate(tlsDir(ep.config), template, certFile, keyFile); err != nil {
+		return "", "", err
+	}
+
+	return certFile, keyFile, nil
+}
+
+// tlsRequired reports whether the preset requires TCP connections to use TLS.
+func tlsRequired(config Config) bool {
+	definition, err := config.preset.definition(config.version)
+	return err == nil && definition.requireTLS
+}
+
+// internalSSLMode returns the sslmode of the connections made to the server by the library itself.
+func internalSSLMode(config Config) string {
+	// TLS is not available over a Unix-domain socket, to which hostssl entries do not apply
+	if tlsRequired(config) && !config.socketOnly {
+		return "require"
+	}
+
+	return "disable"
+}
+
+func checkMutualTLS(config Config) error {
+	if !config.mutualTLS {
+		return errors.New("mutual TLS is not enabled, enable it with Config.MutualTLS")
+	}
+
+	if !config.tls || config.tlsCertFile != "" {
+		return errors.New("mutual TLS requires generated certificates, enable them with Config.TLS(\"\", \"\")")
+	}
+
+	return nil
+}
@@ -0,0 +1,142 @@
+package embeddedpostgres
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_tlsSettings(t *testing.T) {
+	assert.Nil(t, tlsSettings(DefaultConfig()))
+
+	assert.Equal(t, map[string]string{
+		"ssl":           "on",
+		"ssl_cert_file": "/runtime/tls/server.crt",
+		"ssl_key_file":  "/runtime/tls/server.key",
+		"ssl_ca_file":   "/runtime/tls/ca.crt",
+	}, tlsSettings(DefaultConfig().RuntimePath("/runtime").TLS("", "")))
+
+	assert.Equal(t, map[string]string{
+		"ssl":           "on",
+		"ssl_cert_file": "/certs/pg.crt",
+		"ssl_key_file":  "/certs/pg.key",
+	}, tlsSettings(DefaultConfig().RuntimePath("/runtime").TLS("/certs/pg.crt", "/certs/pg.key")))
+}
+
+func Test_checkTLS(t *testing.T) {
+	assert.EqualError(t, checkTLS(DefaultConfig().TLS("/certs/pg.crt", "")), "a TLS certificate and key must be provided together")
+	assert.NoError(t, checkTLS(DefaultConfig().TLS("", "")))
+}
+
+func Test_TLSRootCertFile(t *testing.T) {
+	assert.Equal(t, "", NewDatabase().TLSRootCertFile())
+	assert.Equal(t, "", NewDatabase(DefaultConfig().TLS("/certs/pg.crt", "/certs/pg.key")).TLSRootCertFile())
+	assert.Equal(t, "/runtime/tls/ca.crt", NewDatabase(DefaultConfig().RuntimePath("/runtime").TLS("", "")).TLSRootCertFile())
+}
+
+func Test_generateServerCertificates(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "tls")
+	require.NoError(t, generateServerCertificates(dir))
+
+	caPEM, err := os.ReadFile(filepath.Join(dir, "ca.crt"))
+	require.NoError(t, err)
+
+	roots := x509.NewCertPool()
+	require.True(t, roots.AppendCertsFromPEM(caPEM))
+
+	server, err := tls.LoadX509KeyPair(filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key"))
+	require.NoError(t, err)
+
+	serverCert, err := x509.ParseCertificate(server.Certificate[0])
+	require.NoError(t, err)
+
+	_, err = serverCert.Verify(x509.VerifyOptions{DNSName: "localhost", Roots: roots})
+	assert.NoError(t, err)
+	assert.NoError(t, serverCert.VerifyHostname("127.0.0.1"))
+
+	info, err := os.Stat(filepath.Join(dir, "server.key"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// certificates generated previously are kept, so clients trusting the authority can still connect
+	require.NoError(t, generateServerCertificates(dir))
+
+	regeneratedPEM, err := os.ReadFile(filepath.Join(dir, "ca.crt"))
+	require.NoError(t, err)
+	assert.Equal(t, caPEM, regeneratedPEM)
+}
+
+func Test_TLS(t *testing.T) {
+	database := NewDatabase(DefaultConfig().RuntimePath(t.TempDir()).Port(0).TLS("", ""))
+	require.NoError(t, database.Start())
+
+	defer func() {
+		assert.NoError(t, database.Stop())
+	}()
+
+	db, err := database.Open("postgres", WithSSLMode("verify-full"), WithParameter("sslrootcert", database.TLSRootCertFile()))
+	require.NoError(t, err)
+
+	var ssl bool
+	require.NoError(t, db.QueryRow("SELECT ssl FROM pg_stat_ssl WHERE pid = pg_backend_pid()").Scan(&ssl))
+	assert.True(t, ssl)
+}
+
+func Test_checkMutualTLS(t *testing.T) {
+	assert.EqualError(t, checkMutualTLS(DefaultConfig()), "mutual TLS is not enabled, enable it with Config.MutualTLS")
+	assert.EqualError(t, checkMutualTLS(DefaultConfig().MutualTLS()), `mutual TLS requires generated certificates, enable them with Config.TLS("", "")`)
+	assert.EqualError(t, checkMutualTLS(DefaultConfig().TLS("/certs/pg.crt", "/certs/pg.key").MutualTLS()), `mutual TLS requires generated certificates, enable them with Config.TLS("", "")`)
+	assert.NoError(t, checkMutualTLS(DefaultConfig().TLS("", "").MutualTLS()))
+}
+
+func Test_ClientCertificate(t *testing.T) {
+	database := NewDatabase(DefaultConfig().RuntimePath(t.TempDir()).TLS("", "").MutualTLS())
+	require.NoError(t, generateServerCertificates(tlsDir(database.config)))
+
+	certFile, keyFile, err := database.ClientCertificate("gin")
+	require.NoError(t, err)
+
+	client, err := tls.LoadX509KeyPair(certFile, keyFile)
+	require.NoError(t, err)
+
+	clientCert, err := x509.ParseCertificate(client.Certificate[0])
+	require.NoError(t, err)
+	assert.Equal(t, "gin", clientCert.Subject.CommonName)
+
+	caPEM, err := os.ReadFile(database.TLSRootCertFile())
+	require.NoError(t, err)
+
+	roots := x509.NewCertPool()
+	require.True(t, roots.AppendCertsFromPEM(caPEM))
+
+	_, err = clientCert.Verify(x509.VerifyOptions{Roots: roots, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}})
+	assert.NoError(t, err)
+}
+
+func Test_MutualTLS(t *testing.T) {
+	database := NewDatabase(DefaultConfig().RuntimePath(t.TempDir()).Port(0).TLS("", "").MutualTLS())
+	require.NoError(t, database.Start())
+
+	defer func() {
+		assert.NoError(t, database.Stop())
+	}()
+
+	certFile, keyFile, err := database.ClientCertificate("postgres")
+	require.NoError(t, err)
+
+	db, err := database.Open("postgres",
+		WithUser("postgres", ""),
+		WithSSLMode("verify-full"),
+		WithParameter("sslrootcert", database.TLSRootCertFile()),
+		WithClientCertificate(certFile, keyFile))
+	require.NoError(t, err)
+
+	var clientDN string
+	require.NoError(t, db.QueryRow("SELECT client_dn FROM pg_stat_ssl WHERE pid = pg_backend_pid()").Scan(&clientDN))
+	assert.Equal(t, "/CN=postgres", clientDN)
+}
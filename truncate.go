@@ -0,0 +1,197 @@
+package embeddedpostgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// userTablesQuery lists the tables of the database other than those of the system catalogs and of extensions.
+const userTablesQuery = `SELECT n.nspname, c.relname
+FROM pg_class c
+JOIN pg_namespace n ON n.oid = c.relnamespace
+WHERE c.relkind IN ('r', 'p')
+AND n.nspname NOT IN ('pg_catalog', 'information_schema')
+AND n.nspname NOT LIKE 'pg\_toast%'
+AND n.nspname NOT LIKE 'pg\_temp\_%'
+AND NOT EXISTS (
+	SELECT 1 FROM pg_depend d
+	WHERE d.classid = 'pg_class'::regclass AND d.objid = c.oid AND d.deptype = 'e'
+)`
+
+// foreignKeysQuery lists the tables referenced by the foreign keys of each table.
+const foreignKeysQuery = `SELECT cn.nspname, c.relname, rn.nspname, r.relname
+FROM pg_constraint k
+JOIN pg_class c ON c.oid = k.conrelid
+JOIN pg_namespace cn ON cn.oid = c.relnamespace
+JOIN pg_class r ON r.oid = k.confrelid
+JOIN pg_namespace rn ON rn.oid = r.relnamespace
+WHERE k.contype = 'f'`
+
+// TruncateAll empties every user table of the configured database other than those named in except, given either as
+// table or schema.table, restarting their sequences. Tables are truncated in a single statement, ordered so that
+// tables come before those their foreign keys reference, and with CASCADE. When Config.SeedDirectory is set the seeds
+// are applied again afterwards, as the record of those applied is emptied along with everything else.
+func (ep *EmbeddedPostgres) TruncateAll(ctx context.Context, except ...string) (err error) {
+	if !ep.isStarted() {
+		return errors.New("server has not been started")
+	}
+
+	db, err := ep.openDB(ep.config.database)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		err = connectionClose(db, err)
+	}()
+
+	rows, err := db.QueryContext(ctx, userTablesQuery)
+	if err != nil {
+		return fmt.Errorf("unable to list tables: %w", err)
+	}
+
+	var tables []tableName
+	for rows.Next() {
+		var table tableName
+		if err := rows.Scan(&table.schema, &table.name); err != nil {
+			_ = rows.Close()
+			return fmt.Errorf("unable to list tables: %w", err)
+		}
+
+		if !table.matches(except) {
+			tables = append(tables, table)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("unable to list tables: %w", err)
+	}
+
+	references := map[tableName][]tableName{}
+
+	rows, err = db.QueryContext(ctx, foreignKeysQuery)
+	if err != nil {
+		return fmt.Errorf("unable to list foreign keys: %w", err)
+	}
+
+	for rows.Next() {
+		var table, referenced tableName
+		if err := rows.Scan(&table.schema, &table.name, &referenced.schema, &referenced.name); err != nil {
+			_ = rows.Close()
+			return fmt.Errorf("unable to list foreign keys: %w", err)
+		}
+
+		references[table] = append(references[table], referenced)
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("unable to list foreign keys: %w", err)
+	}
+
+	if len(tables) > 0 {
+		ordered := orderByReferences(tables, references)
+
+		quoted := make([]string, 0, len(ordered))
+		for _, table := range ordered {
+			quoted = append(quoted, table.quoted())
+		}
+
+		if _, err := db.ExecContext(ctx, "TRUNCATE "+strings.Join(quoted, ", ")+" RESTART IDENTITY CASCADE"); err != nil {
+			return fmt.Errorf("unable to truncate tables: %w", err)
+		}
+	}
+
+	if ep.config.seedDirectory != "" {
+		return ep.Seed(ctx, ep.config.seedDirectory)
+	}
+
+	return nil
+}
+
+type tableName struct {
+	schema string
+	name   string
+}
+
+func (t tableName) String() string {
+	return t.schema + "." + t.name
+}
+
+func (t tableName) quoted() string {
+	return pq.QuoteIdentifier(t.schema) + "." + pq.QuoteIdentifier(t.name)
+}
+
+// matches reports whether the table is among names, given either as table or schema.table.
+func (t tableName) matches(names []string) bool {
+	for _, name := range names {
+		if name == t.name || name == t.String() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// orderByReferences orders tables so that each comes before the tables it references, breaking ties and cycles by
+// name so that the order is stable.
+func orderByReferences(tables []tableName, references map[tableName][]tableName) []tableName {
+	sorted := append([]tableName(nil), tables...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].String() < sorted[j].String()
+	})
+
+	included := map[tableName]bool{}
+	for _, table := range sorted {
+		included[table] = true
+	}
+
+	// the number of included tables referencing each table, which must come first
+	referencedBy := map[tableName]int{}
+	for _, table := range sorted {
+		for _, referenced := range references[table] {
+			if included[referenced] && referenced != table {
+				referencedBy[referenced]++
+			}
+		}
+	}
+
+	ordered := make([]tableName, 0, len(sorted))
+	placed := map[tableName]bool{}
+
+	for len(ordered) < len(sorted) {
+		next := -1
+		for i, table := range sorted {
+			if !placed[table] && referencedBy[table] == 0 {
+				next = i
+				break
+			}
+		}
+
+		if next == -1 {
+			// a cycle, so place the first remaining table regardless
+			for i, table := range sorted {
+				if !placed[table] {
+					next = i
+					break
+				}
+			}
+		}
+
+		table := sorted[next]
+		placed[table] = true
+		ordered = append(ordered, table)
+
+		for _, referenced := range references[table] {
+			if included[referenced] && referenced != table {
+				referencedBy[referenced]--
+			}
+		}
+	}
+
+	return ordered
+}
@@ -0,0 +1,90 @@
+package embeddedpostgres
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_orderByReferences(t *testing.T) {
+	breweries := tableName{"public", "breweries"}
+	beers := tableName{"public", "beers"}
+	reviews := tableName{"public", "reviews"}
+	styles := tableName{"catalogue", "styles"}
+
+	ordered := orderByReferences([]tableName{breweries, beers, styles, reviews}, map[tableName][]tableName{
+		beers:   {breweries, styles},
+		reviews: {beers, beers},
+	})
+
+	assert.Equal(t, []tableName{reviews, beers, styles, breweries}, ordered)
+}
+
+func Test_orderByReferences_Cycle(t *testing.T) {
+	a := tableName{"public", "a"}
+	b := tableName{"public", "b"}
+	c := tableName{"public", "c"}
+
+	ordered := orderByReferences([]tableName{c, b, a}, map[tableName][]tableName{
+		a: {b, a},
+		b: {a},
+		c: {a},
+	})
+
+	assert.Equal(t, []tableName{c, a, b}, ordered)
+}
+
+func Test_tableName_matches(t *testing.T) {
+	table := tableName{"catalogue", "styles"}
+
+	assert.True(t, table.matches([]string{"beers", "styles"}))
+	assert.True(t, table.matches([]string{"catalogue.styles"}))
+	assert.False(t, table.matches([]string{"public.styles"}))
+	assert.False(t, table.matches(nil))
+}
+
+func Test_TruncateAll_NotStarted(t *testing.T) {
+	assert.EqualError(t, NewDatabase().TruncateAll(context.Background()), "server has not been started")
+}
+
+func Test_TruncateAll(t *testing.T) {
+	seeds := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(seeds, "001_styles.sql"), []byte(`
+CREATE TABLE IF NOT EXISTS styles (id serial PRIMARY KEY, name text);
+INSERT INTO styles (name) VALUES ('stout');`), 0600))
+
+	database := StartForTest(t, DefaultConfig().SeedDirectory(seeds))
+
+	db, err := database.Open("postgres")
+	require.NoError(t, err)
+
+	_, err = db.Exec(`
+CREATE TABLE breweries (id serial PRIMARY KEY, name text);
+CREATE TABLE beers (id serial PRIMARY KEY, brewery_id int REFERENCES breweries, style_id int REFERENCES styles);
+CREATE TABLE countries (name text);
+INSERT INTO breweries (name) VALUES ('brewdog');
+INSERT INTO beers (brewery_id, style_id) VALUES (1, 1);
+INSERT INTO countries VALUES ('scotland');`)
+	require.NoError(t, err)
+
+	require.NoError(t, database.TruncateAll(context.Background(), "countries"))
+
+	count := func(table string) int {
+		var count int
+		require.NoError(t, db.QueryRow("SELECT count(*) FROM "+table).Scan(&count))
+		return count
+	}
+
+	assert.Equal(t, 0, count("breweries"))
+	assert.Equal(t, 0, count("beers"))
+	assert.Equal(t, 1, count("countries"))
+	assert.Equal(t, 1, count("styles"))
+
+	var id int
+	require.NoError(t, db.QueryRow("INSERT INTO breweries (name) VALUES ('brewdog') RETURNING id").Scan(&id))
+	assert.Equal(t, 1, id)
+}
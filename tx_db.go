@@ -0,0 +1,200 @@
+package embeddedpostgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/lib/pq"
+)
+
+// TxDB opens a connection pool, as Open does with lib/pq, in which all work happens in a single transaction that is
+// rolled back when the pool is closed, so that tests sharing a database see nothing of each other's changes.
+//
+// The pool holds a single connection. Transactions begun on it are emulated with savepoints, and as with any
+// transaction a failed statement aborts the work done so far unless it was made within one of them.
+func (ep *EmbeddedPostgres) TxDB(opts ...ConnectionOption) (*sql.DB, error) {
+	if !ep.isStarted() {
+		return nil, errors.New("server has not been started")
+	}
+
+	connector, err := pq.NewConnector(ep.ConnectionString(opts...))
+	if err != nil {
+		return nil, fmt.Errorf("unable to open transactional connection pool: %w", err)
+	}
+
+	db := sql.OpenDB(&txConnector{connector: connector})
+	db.SetMaxOpenConns(1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), ep.config.startTimeout)
+	defer cancel()
+
+	if err := pingUntilHealthy(ctx, db); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	ep.poolsMu.Lock()
+	ep.pools = append(ep.pools, db)
+	ep.poolsMu.Unlock()
+
+	return db, nil
+}
+
+// txConnector hands out a single connection on which a transaction has been begun, rolling it back when closed.
+type txConnector struct {
+	connector driver.Connector
+
+	mu         sync.Mutex
+	conn       *txConn
+	closed     bool
+	savepoints int
+}
+
+func (c *txConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return nil, errors.New("transactional connection pool has been closed")
+	}
+
+	if c.conn != nil {
+		return c.conn, nil
+	}
+
+	conn, err := c.connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := &txConn{Conn: conn, connector: c}
+	if err := tx.exec(ctx, "BEGIN"); err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("unable to begin transaction: %w", err)
+	}
+
+	c.conn = tx
+
+	return tx, nil
+}
+
+func (c *txConnector) Driver() driver.Driver {
+	return c.connector.Driver()
+}
+
+// Close rolls back the transaction and closes the connection, and is called by sql.DB.Close.
+func (c *txConnector) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return nil
+	}
+
+	c.closed = true
+
+	if c.conn == nil {
+		return nil
+	}
+
+	err := c.conn.exec(context.Background(), "ROLLBACK")
+
+	if closeErr := c.conn.Conn.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return fmt.Errorf("unable to roll back transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (c *txConnector) nextSavepoint() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.savepoints++
+
+	return fmt.Sprintf("embedded_postgres_tx_%d", c.savepoints)
+}
+
+// txConn is the connection handed out by txConnector, which is left open when the pool releases it and which emulates
+// transactions with savepoints.
+type txConn struct {
+	driver.Conn
+	connector *txConnector
+}
+
+func (c *txConn) exec(ctx context.Context, query string) error {
+	_, err := c.ExecContext(ctx, query, nil)
+	return err
+}
+
+func (c *txConn) Close() error {
+	return nil
+}
+
+func (c *txConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *txConn) BeginTx(ctx context.Context, _ driver.TxOptions) (driver.Tx, error) {
+	savepoint := c.connector.nextSavepoint()
+
+	if err := c.exec(ctx, "SAVEPOINT "+savepoint); err != nil {
+		return nil, err
+	}
+
+	return &txSavepoint{conn: c, name: savepoint}, nil
+}
+
+func (c *txConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return preparer.PrepareContext(ctx, query)
+	}
+
+	return c.Prepare(query)
+}
+
+func (c *txConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if execer, ok := c.Conn.(driver.ExecerContext); ok {
+		return execer.ExecContext(ctx, query, args)
+	}
+
+	return nil, driver.ErrSkip
+}
+
+func (c *txConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if queryer, ok := c.Conn.(driver.QueryerContext); ok {
+		return queryer.QueryContext(ctx, query, args)
+	}
+
+	return nil, driver.ErrSkip
+}
+
+func (c *txConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+
+	return nil
+}
+
+// txSavepoint is a transaction begun within the connection's transaction.
+type txSavepoint struct {
+	conn *txConn
+	name string
+}
+
+func (s *txSavepoint) Commit() error {
+	return s.conn.exec(context.Background(), "RELEASE SAVEPOINT "+s.name)
+}
+
+func (s *txSavepoint) Rollback() error {
+	return s.conn.exec(context.Background(), "ROLLBACK TO SAVEPOINT "+s.name)
+}
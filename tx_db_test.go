@@ -0,0 +1,62 @@
+package embeddedpostgres
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_TxDB_NotStarted(t *testing.T) {
+	_, err := NewDatabase().TxDB()
+
+	assert.EqualError(t, err, "server has not been started")
+}
+
+func Test_TxDB(t *testing.T) {
+	database := StartForTest(t)
+
+	db, err := database.Open("postgres")
+	require.NoError(t, err)
+
+	_, err = db.Exec("CREATE TABLE beer (name text)")
+	require.NoError(t, err)
+
+	txDB, err := database.TxDB()
+	require.NoError(t, err)
+
+	_, err = txDB.Exec("INSERT INTO beer VALUES ($1)", "stout")
+	require.NoError(t, err)
+
+	tx, err := txDB.Begin()
+	require.NoError(t, err)
+	_, err = tx.Exec("INSERT INTO beer VALUES ('lager')")
+	require.NoError(t, err)
+	require.NoError(t, tx.Rollback())
+
+	tx, err = txDB.Begin()
+	require.NoError(t, err)
+	_, err = tx.Exec("INSERT INTO beer VALUES ('porter')")
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit())
+
+	var names []string
+	rows, err := txDB.Query("SELECT name FROM beer ORDER BY name")
+	require.NoError(t, err)
+	for rows.Next() {
+		var name string
+		require.NoError(t, rows.Scan(&name))
+		names = append(names, name)
+	}
+	require.NoError(t, rows.Err())
+	assert.Equal(t, []string{"porter", "stout"}, names)
+
+	var count int
+	require.NoError(t, db.QueryRow("SELECT count(*) FROM beer").Scan(&count))
+	assert.Equal(t, 0, count)
+
+	require.NoError(t, txDB.Close())
+
+	require.NoError(t, db.QueryRow("SELECT count(*) FROM beer").Scan(&count))
+	assert.Equal(t, 0, count)
+}
@@ -66,3 +66,13 @@ func shouldUseAlpineLinuxBuild() bool {
 	_, err := os.Stat("/etc/alpine-release")
 	return err == nil
 }
+
+// majorVersion returns the major component of a Postgres version, for example 15 for 15.3.0 and 9 for 9.6.24.
+func majorVersion(version PostgresVersion) int {
+	var major int
+	if _, err := fmt.Sscanf(string(version), "%d", &major); err != nil {
+		return 0
+	}
+
+	return major
+}
@@ -147,3 +147,10 @@ func Test_DefaultVersionStrategy_shouldUseAlpineLinuxBuild(t *testing.T) {
 		shouldUseAlpineLinuxBuild()
 	})
 }
+
+func Test_majorVersion(t *testing.T) {
+	assert.Equal(t, 15, majorVersion(V15))
+	assert.Equal(t, 10, majorVersion(V10))
+	assert.Equal(t, 9, majorVersion(V9))
+	assert.Equal(t, 0, majorVersion("latest"))
+}
@@ -0,0 +1,119 @@
+package embeddedpostgres
+
+import "time"
+
+// watcher is a background check started with watch that runs whilst the server is running.
+type watcher struct {
+	done     chan struct{}
+	finished chan struct{}
+}
+
+// watch runs check every interval in the background until the server stops, stopping the server itself once check
+// returns true. Watchers end when Stop is called, so they never stop a server started afterwards.
+func (ep *instanceState) watch(interval time.Duration, check func(now time.Time) bool) {
+	w := &watcher{
+		done:     make(chan struct{}),
+		finished: make(chan struct{}),
+	}
+
+	ep.watchers = append(ep.watchers, w)
+
+	go func() {
+		defer close(w.finished)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-w.done:
+				return
+			case now := <-ticker.C:
+				if !ep.isStarted() {
+					return
+				}
+
+				if check(now) {
+					ep.stopFromWatcher(w)
+					return
+				}
+			}
+		}
+	}()
+}
+
+// startWatchers starts the watchers of a server that has just started.
+func (ep *EmbeddedPostgres) startWatchers() error {
+	ep.watchIdle()
+	ep.watchStats()
+	ep.watchCrash()
+	ep.watchCSVLog()
+
+	return ep.watchDeadline()
+}
+
+// background returns a handle on the state for the checks of watchers, which unlike the instance returned by NewDatabase
+// is not tracked by trackLeaks, so that the watchers do not keep that instance reachable.
+func (ep *instanceState) background() *EmbeddedPostgres {
+	return &EmbeddedPostgres{instanceState: ep}
+}
+
+// stopWatchers ends all watchers, waiting for any that are stopping the server to finish doing so.
+func (ep *instanceState) stopWatchers() {
+	for _, w := range ep.watchers {
+		close(w.done)
+		<-w.finished
+	}
+
+	ep.watchers = nil
+}
+
+func (ep *instanceState) stopFromWatcher(w *watcher) {
+	ep.lifecycleMu.Lock()
+	defer ep.lifecycleMu.Unlock()
+
+	select {
+	case <-w.done:
+		// Stop has been called in the meantime and will stop the server itself
+		return
+	default:
+	}
+
+	if !ep.started {
+		return
+	}
+
+	// a server that has crashed has already been reported as such
+	crashed, err := ep.stopProcess()
+	if err != nil && !crashed {
+		ep.reportDiagnostic("unable to stop postgres: " + err.Error())
+	}
+
+	ep.stopDeadlineGuard()
+
+	if err := ep.releaseStopped(crashed); err != nil {
+		ep.reportDiagnostic(err.Error())
+	}
+}
+
+func (ep *instanceState) isStarted() bool {
+	ep.lifecycleMu.Lock()
+	defer ep.lifecycleMu.Unlock()
+
+	return ep.started
+}
+
+// watchInterval checks ten times within period, though no more often than every 100ms and at least every 10s.
+func watchInterval(period time.Duration) time.Duration {
+	interval := period / 10
+
+	if interval < 100*time.Millisecond {
+		return 100 * time.Millisecond
+	}
+
+	if interval > 10*time.Second {
+		return 10 * time.Second
+	}
+
+	return interval
+}
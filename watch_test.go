@@ -0,0 +1,152 @@
+//go:build !windows
+// +build !windows
+
+package embeddedpostgres
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func startFakeServer(t *testing.T) *EmbeddedPostgres {
+	t.Helper()
+
+	logger, err := newSyncedLogger(t.TempDir(), io.Discard)
+	require.NoError(t, err)
+
+	// exits cleanly when interrupted, as postgres does, once it has reported being ready
+	cmd := exec.Command("sh", "-c", "trap 'kill $!; exit 0' INT; echo ready; sleep 30 & wait")
+	stdout, err := cmd.StdoutPipe()
+	require.NoError(t, err)
+	require.NoError(t, cmd.Start())
+
+	ready, err := bufio.NewReader(stdout).ReadString('\n')
+	require.NoError(t, err)
+	require.Equal(t, "ready\n", ready)
+
+	// stops the server as pg_ctl would
+	binariesPath := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(binariesPath, "bin"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(binariesPath, "bin", "pg_ctl"),
+		[]byte(fmt.Sprintf("#!/bin/sh\nkill -INT %d\n", cmd.Process.Pid)), 0755))
+
+	database := NewDatabase(DefaultConfig().BinariesPath(binariesPath))
+	database.syncedLogger = logger
+	database.cmd = &postgresProcess{Config: database.config, Logger: logger, cmd: cmd}
+	database.started = true
+
+	return database
+}
+
+func Test_watch_StopsServer(t *testing.T) {
+	database := startFakeServer(t)
+
+	checks := 0
+	database.watch(10*time.Millisecond, func(now time.Time) bool {
+		checks++
+		return checks == 3
+	})
+
+	assert.Eventually(t, func() bool {
+		return !database.isStarted()
+	}, 5*time.Second, 10*time.Millisecond)
+
+	database.stopWatchers()
+	assert.Equal(t, 3, checks)
+	assert.EqualError(t, database.Stop(), "server has not been started")
+}
+
+func Test_watch_EndsOnStop(t *testing.T) {
+	database := startFakeServer(t)
+
+	database.watch(10*time.Millisecond, func(now time.Time) bool {
+		return false
+	})
+
+	time.Sleep(50 * time.Millisecond)
+
+	assert.NoError(t, database.Stop())
+
+	assert.False(t, database.isStarted())
+	assert.Empty(t, database.watchers)
+}
+
+func Test_watchInterval(t *testing.T) {
+	assert.Equal(t, 100*time.Millisecond, watchInterval(time.Millisecond))
+	assert.Equal(t, 3*time.Second, watchInterval(30*time.Second))
+	assert.Equal(t, 10*time.Second, watchInterval(time.Hour))
+}
+
+func Test_watchCrash(t *testing.T) {
+	database := startFakeServer(t)
+	events := database.Events()
+
+	database.watchCrash()
+	require.NoError(t, database.cmd.cmd.Process.Kill())
+
+	assert.Eventually(t, func() bool {
+		return !database.isStarted()
+	}, 5*time.Second, 10*time.Millisecond)
+
+	database.stopWatchers()
+
+	require.Len(t, events, 1)
+	event := <-events
+	assert.Equal(t, Crashed, event.Type)
+	assert.EqualError(t, event.Err, "signal: killed")
+}
+
+func Test_Stop_AfterCrash(t *testing.T) {
+	database := startFakeServer(t)
+	events := database.Events()
+
+	require.NoError(t, database.cmd.cmd.Process.Kill())
+	<-database.cmd.exited()
+
+	require.NoError(t, database.Stop())
+	assert.False(t, database.isStarted())
+	assert.EqualError(t, database.Stop(), "server has not been started")
+
+	require.Len(t, events, 1)
+	event := <-events
+	assert.Equal(t, Crashed, event.Type)
+	assert.EqualError(t, event.Err, "signal: killed")
+}
+
+func Test_trackLeaks_Watched(t *testing.T) {
+	warnings := make(chan string, 1)
+
+	var state *instanceState
+
+	func() {
+		database := startFakeServer(t)
+		database.config.idleTimeout = time.Hour
+		database.config.statsPollInterval = time.Hour
+
+		runtime.SetFinalizer(database, nil)
+		trackLeaks(database, func(warning string) {
+			warnings <- warning
+		})
+
+		require.NoError(t, database.startWatchers())
+		require.NotEmpty(t, database.watchers)
+
+		state = database.instanceState
+	}()
+
+	defer func() {
+		assert.NoError(t, state.background().Stop())
+	}()
+
+	assertLeakReported(t, warnings)
+}